@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
 	"github.com/cloudfoundry-incubator/inigo/fixtures"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
 	"github.com/cloudfoundry-incubator/inigo/world"
@@ -46,6 +48,7 @@ var _ = Describe("AppRunner", func() {
 
 		bridge = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
 			{"tps", componentMaker.TPS()},
+			{"tps-watcher", componentMaker.TPSWatcher()},
 			{"nsync-listener", componentMaker.NsyncListener()},
 		}))
 
@@ -192,6 +195,116 @@ var _ = Describe("AppRunner", func() {
 			})
 		})
 	})
+
+	Describe("Crashing", func() {
+		var (
+			fakeCC *fake_cc.FakeCC
+			cc     ifrit.Process
+		)
+
+		desireCrashingApp := func() {
+			processGuid := fmt.Sprintf("%s-%s", appId, factories.GenerateGuid())
+
+			runningMessage := []byte(
+				fmt.Sprintf(
+					`
+					{
+						"process_guid": "%s",
+						"droplet_uri": "%s",
+						"stack": "%s",
+						"start_command": "exit 17",
+						"num_instances": 1,
+						"environment":[{"name":"VCAP_APPLICATION", "value":"{}"}],
+						"memory_mb": 256,
+						"disk_mb": 1024,
+						"file_descriptors": 16384,
+						"routes": [],
+						"log_guid": "%s"
+					}
+					`,
+					processGuid,
+					fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "droplet.zip"),
+					componentMaker.Stack,
+					appId,
+				),
+			)
+
+			err := natsClient.Publish("diego.desire.app", runningMessage)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			fakeCC = componentMaker.FakeCC()
+			cc = ginkgomon.Invoke(fakeCC)
+		})
+
+		AfterEach(func() {
+			helpers.StopProcesses(cc)
+		})
+
+		It("tells CC why the instance crashed", func() {
+			desireCrashingApp()
+
+			Eventually(func() []fake_cc.AppCrash {
+				return fakeCC.AppCrashes(appId)
+			}).ShouldNot(BeEmpty())
+
+			crash := fakeCC.AppCrashes(appId)[0]
+			Ω(crash.Request.Index).Should(Equal(0))
+			Ω(crash.Request.Instance).ShouldNot(BeEmpty())
+			Ω(crash.Request.Reason).Should(Equal("CRASHED"))
+			Ω(crash.Request.ExitDescription).Should(ContainSubstring("17"))
+			Ω(crash.Request.CrashCount).Should(BeNumerically(">=", 1))
+		})
+
+		It("tells CC about each crash once, however often the instance changes state around it", func() {
+			desireCrashingApp()
+
+			// the first three crashes are restarted straight away, and the
+			// fourth only after a backoff far longer than the Consistently
+			Eventually(helpers.AcceptedCrashCountsPoller(fakeCC, appId)).Should(ContainElement(3))
+			Consistently(helpers.AcceptedCrashCountsPoller(fakeCC, appId), helpers.Timeouts.Consistently).Should(ConsistOf(1, 2, 3))
+		})
+
+		Context("when CC is down", func() {
+			BeforeEach(func() {
+				fakeCC.SetAppCrashedResponseStatusCode(http.StatusServiceUnavailable)
+			})
+
+			It("keeps trying, no faster as it goes, and tells CC once it is back", func() {
+				desireCrashingApp()
+
+				firstCrashGaps := func() []time.Duration {
+					return helpers.CrashReportGaps(fakeCC.AppCrashes(appId), 1)
+				}
+
+				Eventually(func() int {
+					return len(firstCrashGaps())
+				}).Should(BeNumerically(">=", 2))
+
+				gaps := firstCrashGaps()
+				for i := 1; i < len(gaps); i++ {
+					// less a little for scheduling
+					Ω(gaps[i]).Should(BeNumerically(">=", gaps[i-1]-100*time.Millisecond), "retry %d came sooner than the one before it", i)
+				}
+
+				fakeCC.SetAppCrashedResponseStatusCode(http.StatusOK)
+
+				firstCrashAccepted := func() int {
+					accepted := 0
+					for _, count := range helpers.AcceptedCrashCountsPoller(fakeCC, appId)() {
+						if count == 1 {
+							accepted++
+						}
+					}
+					return accepted
+				}
+
+				Eventually(firstCrashAccepted).Should(Equal(1))
+				Consistently(firstCrashAccepted, helpers.Timeouts.Consistently).Should(Equal(1))
+			})
+		})
+	})
 })
 
 func runningIndexPoller(tpsAddr string, guid string) func() []int {
@@ -0,0 +1,92 @@
+package ccbridge_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/helpers/tlsfixtures"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
+	"github.com/cloudfoundry/gunk/urljoiner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+var _ = Describe("Talking to CC over mutual TLS", func() {
+	var (
+		credentials tlsfixtures.Credentials
+		fakeCC      *fake_cc.FakeCC
+
+		brain  ifrit.Process
+		bridge ifrit.Process
+	)
+
+	BeforeEach(func() {
+		credentials = tlsfixtures.Generate(componentMaker.TempDirs.New("fake-cc-tls"))
+
+		tlsMaker := componentMaker.WithFakeCCTLS(credentials, true)
+
+		fileServer, _ := tlsMaker.FileServer()
+
+		fakeCC = tlsMaker.FakeCC()
+
+		brain = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"receptor", tlsMaker.Receptor()},
+			{"auctioneer", tlsMaker.Auctioneer()},
+			{"file-server", fileServer},
+		}))
+
+		bridge = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"cc", fakeCC},
+			{"stager", tlsMaker.Stager()},
+		}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(brain, bridge)
+	})
+
+	It("rejects clients without the client certificate", func() {
+		tlsConfig := credentials.ClientConfig()
+		tlsConfig.Certificates = nil
+
+		client := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+
+		_, err := client.Get(urljoiner.Join(fakeCC.Address(), "staging", "buildpack_cache", "some-app", "download"))
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("has the stager call back with the client certificate", func() {
+		appId := factories.GenerateGuid()
+		stagingGuid := fmt.Sprintf("%s-%s", appId, factories.GenerateGuid())
+
+		stageURL := urljoiner.Join("http://"+componentMaker.Addresses.Stager, "v1", "staging", stagingGuid)
+		request, err := http.NewRequest("PUT", stageURL, strings.NewReader(celllessStagingRequest(appId)))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		resp, err := http.DefaultClient.Do(request)
+		Ω(err).ShouldNot(HaveOccurred())
+		resp.Body.Close()
+		Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+		Eventually(fakeCC.StagingCallbacks).Should(HaveLen(1))
+
+		callback := fakeCC.StagingCallbacks()[0]
+		Ω(callback.StagingGuid).Should(Equal(stagingGuid))
+		Ω(callback.Response.Error).ShouldNot(BeNil())
+		Ω(callback.Response.Error.Id).Should(Equal(cc_messages.NO_COMPATIBLE_CELL))
+
+		Ω(callback.TLS).ShouldNot(BeNil())
+		Ω(callback.TLS.PeerCertificates).ShouldNot(BeEmpty())
+		Ω(callback.TLS.PeerCertificates[0].Subject.CommonName).Should(Equal(tlsfixtures.ClientCommonName))
+	})
+})
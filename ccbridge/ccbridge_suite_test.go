@@ -2,23 +2,16 @@ package ccbridge_test
 
 import (
 	"encoding/json"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gexec"
 	"github.com/pivotal-golang/lager/ginkgoreporter"
 	"github.com/tedsuo/ifrit"
-	"github.com/tedsuo/ifrit/ginkgomon"
-	"github.com/tedsuo/ifrit/grouper"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
-	"github.com/cloudfoundry-incubator/inigo/inigo_announcement_server"
 	"github.com/cloudfoundry-incubator/inigo/world"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry/gunk/diegonats"
@@ -27,17 +20,21 @@ import (
 var (
 	componentMaker world.ComponentMaker
 
-	plumbing       ifrit.Process
+	plumbing       map[string]ifrit.Process
 	receptorClient receptor.Client
 	natsClient     diegonats.NATSClient
 	gardenClient   garden.Client
+
+	leakDetector *helpers.ContainerLeakDetector
 )
 
 var _ = SynchronizedBeforeSuite(func() []byte {
-	payload, err := json.Marshal(world.BuiltArtifacts{
-		Executables: CompileTestedExecutables(),
-		Lifecycles:  BuildLifecycles(),
-	})
+	payload, err := json.Marshal(helpers.BuildOrImportArtifacts("ccbridge", func() world.BuiltArtifacts {
+		return world.BuiltArtifacts{
+			Executables: world.CompileTestedExecutables("tps-watcher"),
+			Lifecycles:  world.BuildLifecycles(helpers.Stack()),
+		}
+	}))
 	Ω(err).ShouldNot(HaveOccurred())
 
 	return payload
@@ -50,35 +47,60 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 	componentMaker = helpers.MakeComponentMaker(builtArtifacts)
 })
 
+var _ = SynchronizedAfterSuite(func() {
+	componentMaker.Timings.WriteReport("ccbridge")
+	componentMaker.TempDirs.RemoveAll()
+}, func() {
+	// only the first node imported them, and every node is done with them by now
+	componentMaker.Artifacts.RemoveImported()
+})
+
 var _ = BeforeEach(func() {
-	plumbing = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
-		{"etcd", componentMaker.Etcd()},
-		{"nats", componentMaker.NATS()},
-		{"garden-linux", componentMaker.GardenLinux("-allowHostAccess=true")},
-	}))
+	componentMaker.Timings.StartSpec()
+	helpers.NameSpecGuids(componentMaker.Timings.SpecID())
+
+	// each spec starts the receptor it needs, e.g. with TLS or auth
+	environment := world.BootstrapPlumbing(world.BootstrapConfig{
+		Maker:      componentMaker,
+		Plumbing:   []string{"etcd", "gnatsd", "garden-linux"},
+		GardenArgs: []string{"-allowHostAccess=true"},
+	})
 
-	gardenClient = componentMaker.GardenClient()
-	natsClient = componentMaker.NATSClient()
-	receptorClient = componentMaker.ReceptorClient()
+	plumbing = environment.Processes
+	gardenClient = environment.GardenClient
 
-	inigo_announcement_server.Start(componentMaker.ExternalAddress)
+	leakDetector = helpers.NewContainerLeakDetector(gardenClient)
+	componentMaker = componentMaker.WithContainerOwner(leakDetector.Owner)
+	natsClient = environment.NATSClient
+	// the receptor can refuse connections for a moment after it reports
+	// having started
+	receptorClient = helpers.NewRetryingReceptorClient(componentMaker.ReceptorClient(), helpers.Timeouts.Short)
 })
 
 var _ = AfterEach(func() {
-	inigo_announcement_server.Stop()
+	defer componentMaker.Timings.FinishSpec()
+
+	helpers.DumpReceptorStateOnFailure(receptorClient)
 
 	destroyContainerErrors := helpers.CleanupGarden(gardenClient)
 
-	helpers.StopProcesses(plumbing)
+	// the spec's executors leave their containers for CleanupGarden, so
+	// only those that outlive it are leaks
+	leaks := leakDetector.Leaks()
+
+	helpers.Teardown(plumbing)
 
 	Ω(destroyContainerErrors).Should(
 		BeEmpty(),
 		"%d containers failed to be destroyed!",
 		len(destroyContainerErrors),
 	)
+
+	Ω(leaks).Should(BeEmpty(), "the spec left %d of its containers behind: %v", len(leaks), leaks)
 })
 
 func TestCCBridge(t *testing.T) {
+	helpers.LoadConfig()
 	helpers.RegisterDefaultTimeouts()
 
 	RegisterFailHandler(Fail)
@@ -88,85 +110,21 @@ func TestCCBridge(t *testing.T) {
 	})
 }
 
-func CompileTestedExecutables() world.BuiltExecutables {
-	var err error
-
-	builtExecutables := world.BuiltExecutables{}
-
-	builtExecutables["garden-linux"], err = gexec.BuildIn(os.Getenv("GARDEN_LINUX_GOPATH"), "github.com/cloudfoundry-incubator/garden-linux", "-race", "-a", "-tags", "daemon")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["auctioneer"], err = gexec.BuildIn(os.Getenv("AUCTIONEER_GOPATH"), "github.com/cloudfoundry-incubator/auctioneer/cmd/auctioneer", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["exec"], err = gexec.BuildIn(os.Getenv("EXECUTOR_GOPATH"), "github.com/cloudfoundry-incubator/executor/cmd/executor", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["converger"], err = gexec.BuildIn(os.Getenv("CONVERGER_GOPATH"), "github.com/cloudfoundry-incubator/converger/cmd/converger", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["rep"], err = gexec.BuildIn(os.Getenv("REP_GOPATH"), "github.com/cloudfoundry-incubator/rep/cmd/rep", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["stager"], err = gexec.BuildIn(os.Getenv("STAGER_GOPATH"), "github.com/cloudfoundry-incubator/stager/cmd/stager", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["receptor"], err = gexec.BuildIn(os.Getenv("RECEPTOR_GOPATH"), "github.com/cloudfoundry-incubator/receptor/cmd/receptor", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["nsync-listener"], err = gexec.BuildIn(os.Getenv("NSYNC_GOPATH"), "github.com/cloudfoundry-incubator/nsync/cmd/nsync-listener", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["nsync-bulker"], err = gexec.BuildIn(os.Getenv("NSYNC_GOPATH"), "github.com/cloudfoundry-incubator/nsync/cmd/nsync-bulker", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["file-server"], err = gexec.BuildIn(os.Getenv("FILE_SERVER_GOPATH"), "github.com/cloudfoundry-incubator/file-server/cmd/file-server", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["route-emitter"], err = gexec.BuildIn(os.Getenv("ROUTE_EMITTER_GOPATH"), "github.com/cloudfoundry-incubator/route-emitter/cmd/route-emitter", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["tps"], err = gexec.BuildIn(os.Getenv("TPS_GOPATH"), "github.com/cloudfoundry-incubator/tps/cmd/tps", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtExecutables["router"], err = gexec.BuildIn(os.Getenv("ROUTER_GOPATH"), "github.com/cloudfoundry/gorouter", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	return builtExecutables
-}
-
-func BuildLifecycles() world.BuiltLifecycles {
-	builtLifecycles := world.BuiltLifecycles{}
-
-	builderPath, err := gexec.BuildIn(os.Getenv("BUILDPACK_APP_LIFECYCLE_GOPATH"), "github.com/cloudfoundry-incubator/buildpack_app_lifecycle/builder", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	healthcheckPath, err := gexec.BuildIn(os.Getenv("BUILDPACK_APP_LIFECYCLE_GOPATH"), "github.com/cloudfoundry-incubator/buildpack_app_lifecycle/healthcheck", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	launcherPath, err := gexec.BuildIn(os.Getenv("BUILDPACK_APP_LIFECYCLE_GOPATH"), "github.com/cloudfoundry-incubator/buildpack_app_lifecycle/launcher", "-race")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	lifecycleDir, err := ioutil.TempDir("", "lifecycle-dir")
-	Ω(err).ShouldNot(HaveOccurred())
-
-	err = os.Rename(builderPath, filepath.Join(lifecycleDir, "builder"))
-	Ω(err).ShouldNot(HaveOccurred())
-
-	err = os.Rename(healthcheckPath, filepath.Join(lifecycleDir, "healthcheck"))
-	Ω(err).ShouldNot(HaveOccurred())
-
-	err = os.Rename(launcherPath, filepath.Join(lifecycleDir, "launcher"))
-	Ω(err).ShouldNot(HaveOccurred())
-
-	cmd := exec.Command("tar", "-czf", "lifecycle.tar.gz", "builder", "launcher", "healthcheck")
-	cmd.Stderr = GinkgoWriter
-	cmd.Stdout = GinkgoWriter
-	cmd.Dir = lifecycleDir
-	err = cmd.Run()
-	Ω(err).ShouldNot(HaveOccurred())
-
-	builtLifecycles[helpers.StackName] = filepath.Join(lifecycleDir, "lifecycle.tar.gz")
-
-	return builtLifecycles
+// celllessStagingRequest is a staging request for suites that run no cell,
+// so that it fails straight back to CC without staging anything.
+func celllessStagingRequest(appId string) string {
+	return fmt.Sprintf(`{
+		"app_id": "%s",
+		"log_guid": "%s",
+		"memory_mb": 128,
+		"disk_mb": 128,
+		"file_descriptors": 1024,
+		"stack": "%s",
+		"lifecycle": "buildpack",
+		"lifecycle_data": {
+			"buildpacks": [],
+			"app_bits_download_uri": "http://example.com/app.zip",
+			"droplet_upload_uri": "http://example.com/droplet"
+		}
+	}`, appId, appId, componentMaker.Stack)
 }
@@ -0,0 +1,89 @@
+package ccbridge_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
+	"github.com/cloudfoundry/gunk/urljoiner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+var _ = Describe("Receptor authentication", func() {
+	var (
+		authMaker world.ComponentMaker
+		fakeCC    *fake_cc.FakeCC
+
+		brain  ifrit.Process
+		bridge ifrit.Process
+	)
+
+	BeforeEach(func() {
+		authMaker = componentMaker.WithReceptorAuth("some-user", "some-password")
+
+		fileServer, _ := authMaker.FileServer()
+
+		fakeCC = authMaker.FakeCC()
+
+		brain = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"receptor", authMaker.Receptor()},
+			{"auctioneer", authMaker.Auctioneer()},
+			{"file-server", fileServer},
+		}))
+
+		bridge = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"cc", fakeCC},
+			{"stager", authMaker.Stager()},
+		}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(brain, bridge)
+	})
+
+	It("rejects clients without credentials", func() {
+		_, err := authMaker.UnauthenticatedReceptorClient().Tasks()
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("rejects clients with the wrong credentials", func() {
+		_, err := authMaker.WithReceptorAuth("some-user", "wrong-password").ReceptorClient().Tasks()
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("accepts clients with the credentials", func() {
+		_, err := authMaker.ReceptorClient().Tasks()
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("has the stager present the credentials", func() {
+		appId := factories.GenerateGuid()
+		stagingGuid := fmt.Sprintf("%s-%s", appId, factories.GenerateGuid())
+
+		stageURL := urljoiner.Join("http://"+authMaker.Addresses.Stager, "v1", "staging", stagingGuid)
+		request, err := http.NewRequest("PUT", stageURL, strings.NewReader(celllessStagingRequest(appId)))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		resp, err := http.DefaultClient.Do(request)
+		Ω(err).ShouldNot(HaveOccurred())
+		resp.Body.Close()
+		Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+		Eventually(fakeCC.StagingCallbacks).Should(HaveLen(1))
+
+		callback := fakeCC.StagingCallbacks()[0]
+		Ω(callback.StagingGuid).Should(Equal(stagingGuid))
+		Ω(callback.Response.Error).ShouldNot(BeNil())
+		Ω(callback.Response.Error.Id).Should(Equal(cc_messages.NO_COMPATIBLE_CELL))
+	})
+})
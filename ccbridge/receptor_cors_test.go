@@ -0,0 +1,82 @@
+package ccbridge_test
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry/gunk/urljoiner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+var _ = Describe("Receptor CORS", func() {
+	const origin = "http://dashboard.example.com"
+
+	var (
+		maker world.ComponentMaker
+
+		receptorProcess ifrit.Process
+
+		tasksURL string
+	)
+
+	JustBeforeEach(func() {
+		receptorProcess = ginkgomon.Invoke(maker.Receptor())
+
+		tasksURL = urljoiner.Join("http://"+maker.Addresses.Receptor, "v1", "tasks")
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(receptorProcess)
+	})
+
+	Context("when CORS is enabled", func() {
+		BeforeEach(func() {
+			maker = componentMaker.WithReceptorCORS()
+		})
+
+		It("answers preflights for the origin", func() {
+			preflight := helpers.CORSPreflight(tasksURL, origin, "POST", "Content-Type")
+			Ω(preflight.StatusCode).Should(BeNumerically("<", 300))
+			Ω(preflight.AllowOrigin).Should(Equal(origin))
+			Ω(preflight.AllowMethods).Should(ContainSubstring("POST"))
+			Ω(preflight.AllowHeaders).Should(MatchRegexp("(?i)content-type"))
+		})
+
+		It("lets the origin read the responses to its requests", func() {
+			response := helpers.CORSRequest(tasksURL, origin)
+			Ω(response.StatusCode).Should(Equal(http.StatusOK))
+			Ω(response.AllowOrigin).Should(Equal(origin))
+		})
+
+		Context("when the receptor requires credentials", func() {
+			BeforeEach(func() {
+				maker = maker.WithReceptorAuth("some-user", "some-password")
+			})
+
+			It("tells the browser to send them", func() {
+				preflight := helpers.CORSPreflight(tasksURL, origin, "GET", "Authorization")
+				Ω(preflight.AllowOrigin).Should(Equal(origin))
+				Ω(preflight.AllowCredentials).Should(Equal("true"))
+			})
+		})
+	})
+
+	Context("when CORS is not enabled", func() {
+		BeforeEach(func() {
+			maker = componentMaker
+		})
+
+		It("does not let other origins read its responses", func() {
+			response := helpers.CORSRequest(tasksURL, origin)
+			Ω(response.StatusCode).Should(Equal(http.StatusOK))
+			Ω(response.AllowOrigin).Should(BeEmpty())
+
+			preflight := helpers.CORSPreflight(tasksURL, origin, "POST")
+			Ω(preflight.AllowOrigin).Should(BeEmpty())
+		})
+	})
+})
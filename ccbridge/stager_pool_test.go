@@ -0,0 +1,90 @@
+package ccbridge_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+var _ = Describe("A pool of stagers", func() {
+	const poolSize = 3
+
+	var (
+		fakeCC *fake_cc.FakeCC
+		pool   *helpers.StagerPool
+
+		brain ifrit.Process
+		cc    ifrit.Process
+	)
+
+	newStagingGuid := func() string {
+		return fmt.Sprintf("%s-%s", factories.GenerateGuid(), factories.GenerateGuid())
+	}
+
+	BeforeEach(func() {
+		fileServer, _ := componentMaker.FileServer()
+
+		brain = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"receptor", componentMaker.Receptor()},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"file-server", fileServer},
+		}))
+
+		fakeCC = componentMaker.FakeCC()
+		cc = ginkgomon.Invoke(fakeCC)
+
+		pool = helpers.StartStagerPool(componentMaker, poolSize)
+	})
+
+	AfterEach(func() {
+		pool.Stop()
+		helpers.StopProcesses(brain, cc)
+	})
+
+	It("spreads staging requests across every stager", func() {
+		stagingGuids := []string{}
+		for i := 0; i < poolSize; i++ {
+			stagingGuid := newStagingGuid()
+			stagingGuids = append(stagingGuids, stagingGuid)
+
+			Ω(pool.Stage(stagingGuid, celllessStagingRequest(stagingGuid))).Should(Equal(i))
+		}
+
+		Eventually(fakeCC.StagingCallbacks).Should(HaveLen(poolSize))
+
+		for i, stagingGuid := range stagingGuids {
+			Eventually(pool.HandledByPoller(stagingGuid)).Should(Equal([]int{i}))
+		}
+	})
+
+	Context("when a stager dies", func() {
+		BeforeEach(func() {
+			pool.Kill(0)
+		})
+
+		It("stages on another one", func() {
+			stagingGuid := newStagingGuid()
+
+			handler := pool.Stage(stagingGuid, celllessStagingRequest(stagingGuid))
+			Ω(handler).ShouldNot(Equal(0))
+
+			Eventually(fakeCC.StagingCallbacks).Should(HaveLen(1))
+
+			callback := fakeCC.StagingCallbacks()[0]
+			Ω(callback.StagingGuid).Should(Equal(stagingGuid))
+			Ω(callback.Response.Error).ShouldNot(BeNil())
+			Ω(callback.Response.Error.Id).Should(Equal(cc_messages.NO_COMPATIBLE_CELL))
+
+			Ω(pool.HandledBy(stagingGuid)).Should(Equal([]int{handler}))
+		})
+	})
+})
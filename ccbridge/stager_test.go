@@ -14,9 +14,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
 	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
 	"github.com/cloudfoundry-incubator/inigo/world"
 	"github.com/cloudfoundry/gunk/urljoiner"
@@ -224,7 +226,7 @@ EOF
 						"disk_mb": 128,
 						"file_descriptors": 1024,
 						"environment": [{ "name": "SOME_STAGING_ENV", "value": "%s"}],
-						"stack": "lucid64",
+						"stack": "%s",
 						"lifecycle": "buildpack",
 						"lifecycle_data": {
 							"app_bits_download_uri": "%s",
@@ -237,6 +239,7 @@ EOF
 					appId,
 					memory,
 					outputGuid,
+					componentMaker.Stack,
 					fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "app.zip"),
 					buildArtifactsUploadUri,
 					dropletUploadUri,
@@ -307,8 +310,7 @@ EOF
 						Ω(buildArtifactContents).Should(HaveKey("./inserted-into-artifacts-cache"))
 
 						//Fetch the compiled droplet from the fakeCC
-						dropletData, ok := fakeCC.UploadedDroplets[appId]
-						Ω(ok).Should(BeTrue())
+						dropletData := fakeCC.UploadedDroplet(appId)
 						Ω(dropletData).ShouldNot(BeEmpty())
 
 						//Unzip the droplet
@@ -393,6 +395,373 @@ EOF
 				stageWith(cc_messages.CUSTOM_BUILDPACK, "git-buildpack", "buildpack/.git")
 			})
 
+			Context("with a generated buildpack", func() {
+				const buildpackKey = "generated-buildpack-key"
+
+				var buildpack fixtures.Buildpack
+
+				BeforeEach(func() {
+					buildpack = fixtures.Buildpack{
+						DetectedName:  "Generated Buildpack 1.2.3",
+						ProcessTypes:  map[string]string{"web": "./my-app --port $PORT"},
+						CompiledFiles: []string{"generated"},
+						CachedFiles:   []string{"generated-cache"},
+					}
+
+					zip_helper.CreateZipArchive(
+						filepath.Join(fileServerStaticDir, "generated_buildpack.zip"),
+						buildpack.Files(),
+					)
+
+					buildpacksToUse, _ = createBuildpack("generated-buildpack", buildpackKey, "generated_buildpack.zip")
+				})
+
+				It("reports the buildpack's detected name and start command to CC", func() {
+					fakeCC.SetExpectedStagingResponse(stagingGuid, buildpack.StagingResponse(buildpackKey))
+
+					resp, err := stageApplication(stagingGuid, string(stagingMessage))
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+					Eventually(fakeCC.StagingCallbacks).Should(HaveLen(1))
+					Ω(fakeCC.StagingCallbacks()[0].RespondedWith).Should(Equal(http.StatusOK), string(fakeCC.StagingCallbacks()[0].Body))
+
+					dropletData := fakeCC.UploadedDroplet(appId)
+					Ω(dropletData).ShouldNot(BeEmpty())
+				})
+
+				Context("when the app has a Procfile", func() {
+					procfile := map[string]string{
+						"web":    "./my-app --procfile-web",
+						"worker": "./my-app --procfile-worker",
+					}
+
+					BeforeEach(func() {
+						zip_helper.CreateZipArchive(filepath.Join(fileServerStaticDir, "app.zip"), fixtures.ProcfileApp(procfile))
+					})
+
+					It("starts the web process with the Procfile's command rather than the buildpack's", func() {
+						fakeCC.SetExpectedStagingResponse(stagingGuid, buildpack.StagingResponseWithProcfile(buildpackKey, procfile))
+
+						resp, err := stageApplication(stagingGuid, string(stagingMessage))
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+						Eventually(fakeCC.StagingCallbacks).Should(HaveLen(1))
+						Ω(fakeCC.StagingCallbacks()[0].RespondedWith).Should(Equal(http.StatusOK), string(fakeCC.StagingCallbacks()[0].Body))
+
+						dropletContents := untarGzipped(fakeCC.UploadedDroplet(appId))
+						Ω(dropletContents).Should(HaveKey("./app/Procfile"))
+
+						stagingInfo := map[string]string{}
+						err = candiedyaml.NewDecoder(bytes.NewReader(dropletContents["./staging_info.yml"])).Decode(&stagingInfo)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						Ω(stagingInfo["start_command"]).Should(Equal(procfile["web"]))
+					})
+				})
+
+				Context("when the app is staged again", func() {
+					const coldCompileSeconds = 5
+
+					stage := func(stagingGuid string) (time.Duration, map[string][]byte) {
+						callbacks := len(fakeCC.StagingCallbacks())
+						startedAt := time.Now()
+
+						resp, err := stageApplication(stagingGuid, string(stagingMessage))
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+						Eventually(fakeCC.StagingCallbacks).Should(HaveLen(callbacks + 1))
+						callback := fakeCC.StagingCallbacks()[callbacks]
+						Ω(callback.RespondedWith).Should(Equal(http.StatusOK), string(callback.Body))
+
+						return callback.ReceivedAt.Sub(startedAt), untarGzipped(fakeCC.UploadedDroplet(appId))
+					}
+
+					BeforeEach(func() {
+						buildpack.CacheListingFile = "cache-listing"
+						buildpack.ColdCompileSeconds = coldCompileSeconds
+
+						zip_helper.CreateZipArchive(
+							filepath.Join(fileServerStaticDir, "generated_buildpack.zip"),
+							buildpack.Files(),
+						)
+					})
+
+					JustBeforeEach(func() {
+						var message map[string]interface{}
+						err := json.Unmarshal(stagingMessage, &message)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						lifecycleData := message["lifecycle_data"].(map[string]interface{})
+						lifecycleData["build_artifacts_cache_download_uri"] = fakeCC.BuildArtifactsCacheDownloadURI(appId)
+
+						stagingMessage, err = json.Marshal(message)
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					It("restores the build artifacts cache uploaded the first time, and compiles faster with it", func() {
+						By("staging with no cache")
+						coldDuration, droplet := stage(stagingGuid)
+						Ω(string(droplet["./app/cache-listing"])).Should(BeEmpty())
+						Ω(untarGzipped(fakeCC.UploadedBuildArtifactsCache(appId))).Should(HaveKey("./generated-cache"))
+
+						By("staging with the cache from the first time")
+						warmDuration, droplet := stage(fmt.Sprintf("%s-%s", appId, factories.GenerateGuid()))
+						Ω(strings.Fields(string(droplet["./app/cache-listing"]))).Should(ContainElement("generated-cache"))
+						Ω(warmDuration).Should(BeNumerically("<", coldDuration-coldCompileSeconds*time.Second/2))
+
+						downloads := fakeCC.RequestsTo("^/staging/buildpack_cache/" + appId + "/download$")
+						Ω(downloads).Should(HaveLen(2))
+						Ω(downloads[0].RespondedWith).Should(Equal(http.StatusNotFound))
+						Ω(downloads[1].RespondedWith).Should(Equal(http.StatusOK))
+					})
+				})
+
+				Context("when the buildpack fails to compile", func() {
+					BeforeEach(func() {
+						buildpack.CompileExitStatus = 1
+
+						zip_helper.CreateZipArchive(
+							filepath.Join(fileServerStaticDir, "generated_buildpack.zip"),
+							buildpack.Files(),
+						)
+					})
+
+					It("responds with a staging error", func() {
+						resp, err := stageApplication(stagingGuid, string(stagingMessage))
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+						Eventually(fakeCC.StagingResponses).Should(HaveLen(1))
+						Ω(fakeCC.StagingResponses()[0].Error).ShouldNot(BeNil())
+						Ω(fakeCC.StagingResponses()[0].Error.Id).Should(Equal(cc_messages.STAGING_ERROR))
+					})
+
+					for _, exitStatus := range []int{2, 222, 223, 255} {
+						exitStatus := exitStatus
+
+						Context(fmt.Sprintf("with exit status %d", exitStatus), func() {
+							BeforeEach(func() {
+								buildpack.CompileExitStatus = exitStatus
+
+								zip_helper.CreateZipArchive(
+									filepath.Join(fileServerStaticDir, "generated_buildpack.zip"),
+									buildpack.Files(),
+								)
+							})
+
+							It("tells CC exactly that staging failed, and uploads nothing", func() {
+								resp, err := stageApplication(stagingGuid, string(stagingMessage))
+								Ω(err).ShouldNot(HaveOccurred())
+								Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+								helpers.ExpectStagingError(fakeCC, stagingGuid, helpers.StagingFailedError)
+								Ω(fakeCC.WasUploaded(appId)).Should(BeFalse())
+								Ω(fakeCC.UploadedBuildArtifactsCache(appId)).Should(BeNil())
+							})
+						})
+					}
+				})
+
+				Context("when the buildpack hangs in detect past the staging timeout", func() {
+					const stagingTimeout = 15 * time.Second
+
+					BeforeEach(func() {
+						buildpack.DetectSeconds = 300
+
+						zip_helper.CreateZipArchive(
+							filepath.Join(fileServerStaticDir, "generated_buildpack.zip"),
+							buildpack.Files(),
+						)
+					})
+
+					JustBeforeEach(func() {
+						var message map[string]interface{}
+						err := json.Unmarshal(stagingMessage, &message)
+						Ω(err).ShouldNot(HaveOccurred())
+
+						message["timeout"] = int(stagingTimeout / time.Second)
+
+						stagingMessage, err = json.Marshal(message)
+						Ω(err).ShouldNot(HaveOccurred())
+					})
+
+					It("gives up once the timeout passes, and tells CC exactly that staging failed", func() {
+						startedAt := time.Now()
+
+						resp, err := stageApplication(stagingGuid, string(stagingMessage))
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+						callback := helpers.ExpectStagingError(fakeCC, stagingGuid, helpers.StagingFailedError, stagingTimeout+helpers.Timeouts.Long)
+						Ω(callback.ReceivedAt.Sub(startedAt)).Should(BeNumerically(">=", stagingTimeout))
+						Ω(fakeCC.WasUploaded(appId)).Should(BeFalse())
+					})
+				})
+
+				Context("when staging is stopped while compiling", func() {
+					BeforeEach(func() {
+						buildpack.CompileSeconds = 300
+
+						zip_helper.CreateZipArchive(
+							filepath.Join(fileServerStaticDir, "generated_buildpack.zip"),
+							buildpack.Files(),
+						)
+					})
+
+					It("cancels the staging task, destroys its container, and tells CC", func() {
+						resp, err := stageApplication(stagingGuid, string(stagingMessage))
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+
+						helpers.WaitForStagingToStart(receptorClient, stagingGuid)
+
+						helpers.StopStaging(componentMaker.Addresses.Stager, stagingGuid)
+
+						helpers.ExpectStagingCancelled(receptorClient, gardenClient, fakeCC, stagingGuid)
+						Ω(fakeCC.WasUploaded(appId)).Should(BeFalse())
+					})
+				})
+			})
+
+			Context("with buildpacks from a buildpack server", func() {
+				var buildpackServer *helpers.FakeBuildpackServer
+
+				var (
+					detecting = fixtures.Buildpack{
+						DetectedName:  "Served Buildpack",
+						ProcessTypes:  map[string]string{"web": "./my-app --served"},
+						CompiledFiles: []string{"compiled-by-detecting"},
+					}
+
+					// would never be picked by detection
+					nonDetecting = fixtures.Buildpack{
+						ProcessTypes:  map[string]string{"web": "./my-app --custom"},
+						CompiledFiles: []string{"compiled-by-non-detecting"},
+					}
+				)
+
+				buildpacksJSON := func(buildpacks ...map[string]string) string {
+					encoded, err := json.Marshal(buildpacks)
+					Ω(err).ShouldNot(HaveOccurred())
+					return string(encoded)
+				}
+
+				customBuildpack := func(url string) map[string]string {
+					return map[string]string{"name": cc_messages.CUSTOM_BUILDPACK, "key": url, "url": url}
+				}
+
+				stagedBuildpackKey := func() string {
+					Eventually(fakeCC.StagingCallbacks).Should(HaveLen(1))
+					callback := fakeCC.StagingCallbacks()[0]
+					Ω(callback.RespondedWith).Should(Equal(http.StatusOK), string(callback.Body))
+					Ω(callback.Response.LifecycleData).ShouldNot(BeNil())
+
+					var lifecycleData cc_messages.BuildpackStagingResponse
+					err := json.Unmarshal(*callback.Response.LifecycleData, &lifecycleData)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					return lifecycleData.BuildpackKey
+				}
+
+				stage := func() {
+					resp, err := stageApplication(stagingGuid, string(stagingMessage))
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+				}
+
+				BeforeEach(func() {
+					buildpackServer = helpers.NewFakeBuildpackServer(componentMaker.ExternalAddress)
+					buildpackServer.AddZipBuildpack("detecting", detecting.Files())
+					buildpackServer.AddZipBuildpack("non-detecting", nonDetecting.Files())
+				})
+
+				AfterEach(func() {
+					buildpackServer.Close()
+				})
+
+				Context("given several admin buildpacks inline", func() {
+					BeforeEach(func() {
+						buildpacksToUse = buildpacksJSON(
+							map[string]string{"name": "non-detecting", "key": "non-detecting-key", "url": buildpackServer.ZipURL("non-detecting")},
+							map[string]string{"name": "detecting", "key": "detecting-key", "url": buildpackServer.ZipURL("detecting")},
+						)
+					})
+
+					It("downloads each of them once, and stages with the first one to detect the app", func() {
+						fakeCC.SetExpectedStagingResponse(stagingGuid, detecting.StagingResponse("detecting-key"))
+
+						stage()
+
+						Ω(stagedBuildpackKey()).Should(Equal("detecting-key"))
+						Ω(untarGzipped(fakeCC.UploadedDroplet(appId))).Should(HaveKey("./app/compiled-by-detecting"))
+
+						Ω(buildpackServer.Requests("non-detecting")).Should(Equal(1))
+						Ω(buildpackServer.Requests("detecting")).Should(Equal(1))
+					})
+				})
+
+				Context("given a custom buildpack URL to a zip", func() {
+					BeforeEach(func() {
+						buildpacksToUse = buildpacksJSON(customBuildpack(buildpackServer.ZipURL("non-detecting")))
+					})
+
+					It("downloads it, and stages with it without running its detect", func() {
+						stage()
+
+						Ω(stagedBuildpackKey()).Should(Equal(buildpackServer.ZipURL("non-detecting")))
+						Ω(untarGzipped(fakeCC.UploadedDroplet(appId))).Should(HaveKey("./app/compiled-by-non-detecting"))
+
+						Ω(buildpackServer.Requests("non-detecting")).Should(Equal(1))
+					})
+
+					Context("when the buildpack server refuses it", func() {
+						BeforeEach(func() {
+							buildpackServer.Refuse("non-detecting", http.StatusNotFound)
+						})
+
+						It("tells CC exactly that staging failed, and uploads nothing", func() {
+							stage()
+
+							helpers.ExpectStagingError(fakeCC, stagingGuid, helpers.StagingFailedError)
+							Ω(buildpackServer.Requests("non-detecting")).Should(BeNumerically(">=", 1))
+							Ω(fakeCC.WasUploaded(appId)).Should(BeFalse())
+						})
+					})
+				})
+
+				Context("given a custom buildpack URL to a git repository", func() {
+					BeforeEach(func() {
+						buildpackServer.AddGitBuildpack("non-detecting-git", nonDetecting.Files())
+						buildpacksToUse = buildpacksJSON(customBuildpack(buildpackServer.GitURL("non-detecting-git")))
+					})
+
+					It("clones it, and stages with it without running its detect", func() {
+						stage()
+
+						Ω(stagedBuildpackKey()).Should(Equal(buildpackServer.GitURL("non-detecting-git")))
+						Ω(untarGzipped(fakeCC.UploadedDroplet(appId))).Should(HaveKey("./app/compiled-by-non-detecting"))
+
+						Ω(buildpackServer.Requests("non-detecting-git")).Should(BeNumerically(">=", 1))
+					})
+				})
+
+				Context("given a custom buildpack URL that nothing is listening on", func() {
+					BeforeEach(func() {
+						buildpacksToUse = buildpacksJSON(customBuildpack(helpers.UnreachableBuildpackURL(componentMaker.ExternalAddress)))
+					})
+
+					It("tells CC exactly that staging failed, and uploads nothing", func() {
+						stage()
+
+						helpers.ExpectStagingError(fakeCC, stagingGuid, helpers.StagingFailedError)
+						Ω(fakeCC.WasUploaded(appId)).Should(BeFalse())
+					})
+				})
+			})
+
 			Context("when no detected buildpack present", func() {
 				BeforeEach(func() {
 					buildpacksToUse, _ = createBuildpack("busted-test-buildpack", "busted-test-buildpack-key", busted_buildpack_zip)
@@ -403,15 +772,8 @@ EOF
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
 
-					Eventually(fakeCC.StagingResponses).Should(HaveLen(1))
-					Ω(fakeCC.StagingResponses()[0]).Should(Equal(
-						cc_messages.StagingResponseForCC{
-							Error: &cc_messages.StagingError{
-								Id:      cc_messages.STAGING_ERROR,
-								Message: "staging failed",
-							},
-						}))
-					Ω(fakeCC.StagingGuids()[0]).Should(Equal(stagingGuid))
+					helpers.ExpectStagingError(fakeCC, stagingGuid, helpers.StagingFailedError)
+					Ω(fakeCC.StagingGuids()).Should(Equal([]string{stagingGuid}))
 				})
 			})
 
@@ -509,6 +871,16 @@ EOF
 
 				Eventually(fakeCC.StagingResponses).Should(HaveLen(NUM_ATTEMPTS * NUM_RETRIES))
 				Consistently(fakeCC.StagingResponses).Should(HaveLen(NUM_ATTEMPTS * NUM_RETRIES))
+
+				callbacks := fakeCC.StagingCallbacks()
+				for i, callback := range callbacks {
+					Ω(callback.StagingGuid).Should(Equal(stagingGuid))
+					Ω(callback.RespondedWith).Should(Equal(http.StatusServiceUnavailable))
+
+					if i > 0 {
+						Ω(callback.ReceivedAt).ShouldNot(BeTemporally("<", callbacks[i-1].ReceivedAt))
+					}
+				}
 			})
 		})
 	})
@@ -531,6 +903,29 @@ func downloadBuildArtifactsCache(appId string) []byte {
 	return bytes
 }
 
+func untarGzipped(tgz []byte) map[string][]byte {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(tgz))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	tarReader := tar.NewReader(gzipReader)
+
+	contents := map[string][]byte{}
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		Ω(err).ShouldNot(HaveOccurred())
+
+		content, err := ioutil.ReadAll(tarReader)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		contents[hdr.Name] = content
+	}
+
+	return contents
+}
+
 func execute(dir string, execCmd string, args ...string) {
 	cmd := exec.Command(execCmd, args...)
 	cmd.Dir = dir
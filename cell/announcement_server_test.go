@@ -0,0 +1,23 @@
+package cell_test
+
+import (
+	"github.com/cloudfoundry-incubator/inigo/inigo_announcement_server"
+	"github.com/cloudfoundry-incubator/inigo/world"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("The announcement server", func() {
+	It("keeps its announcements across restarts", func() {
+		announcementClient.Announce("before-restart")
+
+		announcementServer, announcementServerRunner = world.Restart(announcementServer, announcementServerRunner)
+
+		Ω(announcementClient.Announcements()).Should(Equal([]string{"before-restart"}))
+
+		announcementClient.Announce("after-restart")
+
+		Ω(announcementClient.AnnouncementsInOrder()).Should(inigo_announcement_server.AnnouncedBefore("before-restart", "after-restart"))
+	})
+})
@@ -0,0 +1,127 @@
+package cell_test
+
+import (
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("API version mismatches", func() {
+	const oldVersion = "v0"
+
+	skipUnlessBuilt := func(component string) {
+		if _, found := componentMaker.Artifacts.Versions[oldVersion][component]; !found {
+			Skip("no " + oldVersion + " " + component + " was built or fetched; set COMPONENT_VERSIONS or PINNED_COMPONENT_VERSIONS")
+		}
+	}
+
+	Context("with an old rep and the current auctioneer", func() {
+		var (
+			auctioneer ifrit.Process
+			cell       ifrit.Process
+		)
+
+		BeforeEach(func() {
+			auctioneer = nil
+			cell = nil
+
+			skipUnlessBuilt("rep")
+
+			auctioneer = ginkgomon.Invoke(componentMaker.Auctioneer())
+
+			oldRepMaker := componentMaker.WithComponentVersion(oldVersion, "rep")
+			cell = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+				{"exec", componentMaker.Executor()},
+				{"rep", oldRepMaker.Rep()},
+			}))
+		})
+
+		AfterEach(func() {
+			helpers.StopProcesses(auctioneer, cell)
+		})
+
+		// the pinned release is the last one, which the current components
+		// must still work with for rolling upgrades
+		It("runs tasks on the old rep, without either of them exiting", func() {
+			taskGuid := helpers.NewGuid("task")
+
+			err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+				Domain:   INIGO_DOMAIN,
+				TaskGuid: taskGuid,
+				Stack:    componentMaker.Stack,
+				Action: &models.RunAction{
+					Path: "true",
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			task := helpers.CompletedTask(receptorClient, taskGuid)
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.CellID).Should(Equal(componentMaker.CellID()))
+
+			Consistently(auctioneer.Wait()).ShouldNot(Receive(), "the auctioneer exited")
+			Consistently(cell.Wait()).ShouldNot(Receive(), "the old rep, or its executor, exited")
+		})
+	})
+
+	Context("with an old receptor and current clients", func() {
+		var (
+			oldReceptorMaker world.ComponentMaker
+			oldReceptor      ifrit.Process
+		)
+
+		BeforeEach(func() {
+			oldReceptor = nil
+
+			skipUnlessBuilt("receptor")
+
+			// alongside the suite's own, against the same etcd
+			oldReceptorMaker = componentMaker.WithSeparateReceptor(80).WithComponentVersion(oldVersion, "receptor")
+			oldReceptor = ginkgomon.Invoke(oldReceptorMaker.Receptor())
+		})
+
+		AfterEach(func() {
+			helpers.StopProcesses(oldReceptor)
+		})
+
+		It("answers them with receptor errors rather than malformed responses, and keeps running", func() {
+			oldReceptorClient := oldReceptorMaker.ReceptorClient()
+
+			err := oldReceptorClient.CreateTask(receptor.TaskCreateRequest{
+				Domain:   INIGO_DOMAIN,
+				TaskGuid: helpers.NewGuid("task"),
+				Stack:    componentMaker.Stack,
+				Action: &models.RunAction{
+					Path: "true",
+				},
+			})
+			helpers.ExpectNilOrReceptorError(err)
+
+			_, err = oldReceptorClient.Tasks()
+			helpers.ExpectNilOrReceptorError(err)
+
+			_, err = oldReceptorClient.DesiredLRPs()
+			helpers.ExpectNilOrReceptorError(err)
+
+			_, err = oldReceptorClient.ActualLRPs()
+			helpers.ExpectNilOrReceptorError(err)
+
+			_, err = oldReceptorClient.Cells()
+			helpers.ExpectNilOrReceptorError(err)
+
+			_, err = oldReceptorClient.Domains()
+			helpers.ExpectNilOrReceptorError(err)
+
+			Consistently(oldReceptor.Wait()).ShouldNot(Receive(), "the old receptor exited")
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package cell_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exported artifacts", func() {
+	var (
+		artifacts world.BuiltArtifacts
+
+		exportDir string
+		importDir string
+	)
+
+	// stands in for something built; small, so that exporting is quick
+	builtFile := func(dir string, name string, mode os.FileMode) string {
+		filePath := filepath.Join(dir, name)
+
+		err := ioutil.WriteFile(filePath, []byte("built "+name), mode)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return filePath
+	}
+
+	BeforeEach(func() {
+		builtDir := componentMaker.TempDirs.New("built-artifacts")
+		exportDir = componentMaker.TempDirs.New("exported-artifacts")
+		importDir = filepath.Join(componentMaker.TempDirs.New("imported-artifacts"), "extracted")
+
+		artifacts = world.BuiltArtifacts{
+			Executables: world.BuiltExecutables{
+				"file-server": builtFile(builtDir, "file-server", 0755),
+			},
+			Lifecycles: world.BuiltLifecycles{
+				componentMaker.Stack: builtFile(builtDir, "lifecycle.tgz", 0644),
+			},
+			Versions: map[string]world.BuiltExecutables{
+				"v0": {"rep": builtFile(builtDir, "rep-v0", 0755)},
+			},
+			Fixtures: world.BuiltExecutables{
+				"announce": builtFile(builtDir, "announce", 0755),
+			},
+		}
+	})
+
+	It("names the tarball after the suite and node", func() {
+		tarballPath := world.ExportArtifacts(artifacts, exportDir, "cell")
+		Ω(tarballPath).Should(Equal(filepath.Join(exportDir, fmt.Sprintf("artifacts-cell-node-%d.tgz", GinkgoParallelNode()))))
+	})
+
+	It("imports what was exported, with the same contents and modes", func() {
+		imported, manifest := world.ImportArtifacts(world.ExportArtifacts(artifacts, exportDir, "cell"), importDir)
+		Ω(imported.ImportedDir).Should(Equal(importDir))
+
+		expectSameFile := func(importedPath string, builtPath string) {
+			Ω(importedPath).Should(HavePrefix(importDir))
+
+			built, err := ioutil.ReadFile(builtPath)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ioutil.ReadFile(importedPath)).Should(Equal(built))
+
+			builtInfo, err := os.Stat(builtPath)
+			Ω(err).ShouldNot(HaveOccurred())
+			importedInfo, err := os.Stat(importedPath)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(importedInfo.Mode()).Should(Equal(builtInfo.Mode()))
+		}
+
+		Ω(imported.Executables).Should(HaveLen(1))
+		expectSameFile(imported.Executables["file-server"], artifacts.Executables["file-server"])
+
+		Ω(imported.Lifecycles).Should(HaveLen(1))
+		expectSameFile(imported.Lifecycles[componentMaker.Stack], artifacts.Lifecycles[componentMaker.Stack])
+
+		Ω(imported.Versions).Should(HaveLen(1))
+		Ω(imported.Versions["v0"]).Should(HaveLen(1))
+		expectSameFile(imported.Versions["v0"]["rep"], artifacts.Versions["v0"]["rep"])
+
+		Ω(imported.Fixtures).Should(HaveLen(1))
+		expectSameFile(imported.Fixtures["announce"], artifacts.Fixtures["announce"])
+
+		Ω(manifest.Sources).Should(HaveLen(4))
+		Ω(manifest.Sources[manifest.Executables["file-server"]].ImportPath).Should(Equal("github.com/cloudfoundry-incubator/file-server/cmd/file-server"))
+		Ω(manifest.Sources[manifest.Fixtures["announce"]].ImportPath).Should(Equal("github.com/cloudfoundry-incubator/inigo/fixtures/announce"))
+	})
+
+	It("gives the same bytes when the same artifacts are exported twice", func() {
+		first, err := ioutil.ReadFile(world.ExportArtifacts(artifacts, exportDir, "cell"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		second, err := ioutil.ReadFile(world.ExportArtifacts(artifacts, exportDir, "cell"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(second).Should(Equal(first))
+	})
+
+	It("removes what it imported once asked to", func() {
+		imported, _ := world.ImportArtifacts(world.ExportArtifacts(artifacts, exportDir, "cell"), importDir)
+
+		imported.RemoveImported()
+
+		_, err := os.Stat(importDir)
+		Ω(os.IsNotExist(err)).Should(BeTrue(), "%s is still there", importDir)
+	})
+})
@@ -0,0 +1,72 @@
+package cell_test
+
+import (
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Auction placement", func() {
+	const (
+		cellCount    = 3
+		cellMemoryMB = 1024
+
+		instances        = 6
+		instanceMemoryMB = 128
+		instancesPerCell = instances / cellCount
+	)
+
+	var (
+		maker world.ComponentMaker
+
+		auctioneer ifrit.Process
+		cells      *helpers.Cells
+
+		placement map[string]int
+	)
+
+	BeforeEach(func() {
+		maker = componentMaker
+	})
+
+	JustBeforeEach(func() {
+		auctioneer = ginkgomon.Invoke(maker.Auctioneer())
+		cells = helpers.StartCells(maker, receptorClient, cellCount, cellMemoryMB)
+
+		placement = helpers.DesireAndPlace(receptorClient, maker, INIGO_DOMAIN, helpers.NewGuid("lrp"), instances, instanceMemoryMB)
+	})
+
+	AfterEach(func() {
+		cells.Stop()
+		helpers.StopProcesses(auctioneer)
+	})
+
+	Context("with the default weights", func() {
+		It("spreads instances evenly across the cells", func() {
+			Ω(placement).Should(HaveLen(cellCount))
+
+			for cellID, count := range placement {
+				Ω(count).Should(Equal(instancesPerCell), "cell %s got %d instances", cellID, count)
+			}
+		})
+	})
+
+	Context("when the auctioneer bin-packs", func() {
+		BeforeEach(func() {
+			maker = componentMaker.WithAuctionWeights(1, 0)
+		})
+
+		It("fills one cell before using another", func() {
+			// they all fit on one
+			Ω(placement).Should(HaveLen(1))
+
+			for _, count := range placement {
+				Ω(count).Should(Equal(instances))
+			}
+		})
+	})
+})
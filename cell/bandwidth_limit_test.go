@@ -0,0 +1,83 @@
+package cell_test
+
+import (
+	"os"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Container bandwidth limits", func() {
+	const (
+		bytesPerSecond = 256 * 1024
+		burstBytes     = 64 * 1024
+
+		// four seconds' worth at the limit, so that the burst is a small
+		// part of it
+		transferBytes = 4 * bytesPerSecond
+
+		// the most a limited transfer may reach, with slack for the burst and
+		// for what the sockets buffer before the limit bites
+		limitedCeiling = 1.25 * bytesPerSecond
+	)
+
+	var (
+		runtime ifrit.Process
+
+		lrp receptor.ActualLRPResponse
+	)
+
+	JustBeforeEach(func() {
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"exec", componentMaker.Executor()},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		lrp = helpers.DesireThroughputLRP(receptorClient, INIGO_DOMAIN, componentMaker.Stack, helpers.NewGuid("lrp"), transferBytes)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	Context("without a bandwidth limit", func() {
+		It("leaves the container's traffic unlimited", func() {
+			Ω(helpers.ContainerBandwidthLimits(gardenClient, lrp.InstanceGuid)).Should(BeZero())
+		})
+
+		It("moves traffic in and out faster than the limit would allow", func() {
+			Ω(helpers.MeasureThroughputIn(lrp, transferBytes)).Should(BeNumerically(">", limitedCeiling))
+			Ω(helpers.MeasureThroughputOut(lrp, transferBytes)).Should(BeNumerically(">", limitedCeiling))
+		})
+	})
+
+	Context("with a bandwidth limit", func() {
+		JustBeforeEach(func() {
+			helpers.LimitContainerBandwidth(gardenClient, lrp.InstanceGuid, bytesPerSecond, burstBytes)
+		})
+
+		It("has Garden limit the container to it", func() {
+			Ω(helpers.ContainerBandwidthLimits(gardenClient, lrp.InstanceGuid)).Should(Equal(garden.BandwidthLimits{
+				RateInBytesPerSecond:      bytesPerSecond,
+				BurstRateInBytesPerSecond: burstBytes,
+			}))
+		})
+
+		// and not to much less, or a slow host would pass for a limited one
+		It("limits traffic into the container to the rate", func() {
+			Ω(helpers.MeasureThroughputIn(lrp, transferBytes)).Should(BeNumerically("~", bytesPerSecond, limitedCeiling-bytesPerSecond))
+		})
+
+		It("limits traffic out of the container to the rate", func() {
+			Ω(helpers.MeasureThroughputOut(lrp, transferBytes)).Should(BeNumerically("~", bytesPerSecond, limitedCeiling-bytesPerSecond))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package cell_test
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Uploading through cc-uploader", func() {
+	var (
+		fakeCC *fake_cc.FakeCC
+
+		runtime ifrit.Process
+
+		appGuid string
+	)
+
+	uploadTask := func(contents string, to string) string {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:   INIGO_DOMAIN,
+			TaskGuid: taskGuid,
+			Stack:    componentMaker.Stack,
+			Action: models.Serial(
+				&models.RunAction{
+					Path: "sh",
+					Args: []string{"-c", "printf " + contents + " > /tmp/upload"},
+				},
+				&models.UploadAction{
+					From: "/tmp/upload",
+					To:   to,
+				},
+			),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return taskGuid
+	}
+
+	BeforeEach(func() {
+		appGuid = helpers.NewGuid("app")
+
+		fakeCC = componentMaker.FakeCC()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"cc", fakeCC},
+			{"cc-uploader", componentMaker.CCUploader()},
+			{"exec", componentMaker.Executor()},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	Describe("uploading a droplet", func() {
+		BeforeEach(func() {
+			fakeCC.SetDropletJobPolls(3)
+		})
+
+		It("relays it to CC, and waits for CC's upload job to finish", func() {
+			taskGuid := uploadTask("the-droplet", helpers.CCUploaderDropletURL(
+				componentMaker.Addresses.CCUploader,
+				appGuid,
+				fakeCC.DropletUploadURI(appGuid),
+			))
+
+			task := helpers.CompletedTask(receptorClient, taskGuid)
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+			Ω(string(fakeCC.UploadedDroplet(appGuid))).Should(Equal("the-droplet"))
+			Ω(fakeCC.DropletJobPolls(appGuid)).Should(Equal(3))
+
+			uploads := fakeCC.RequestsTo("^/staging/droplets/" + appGuid + "/upload$")
+			Ω(uploads).Should(HaveLen(1))
+			Ω(uploads[0].Query.Get("async")).Should(Equal("true"))
+			Ω(uploads[0].RespondedWith).Should(Equal(http.StatusCreated))
+		})
+	})
+
+	Describe("uploading a build artifacts cache", func() {
+		It("relays it to CC", func() {
+			taskGuid := uploadTask("the-cache", helpers.CCUploaderBuildArtifactsURL(
+				componentMaker.Addresses.CCUploader,
+				appGuid,
+				fakeCC.BuildArtifactsCacheUploadURI(appGuid),
+			))
+
+			task := helpers.CompletedTask(receptorClient, taskGuid)
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+			Ω(string(fakeCC.UploadedBuildArtifactsCache(appGuid))).Should(Equal("the-cache"))
+			Ω(fakeCC.RequestsTo("^/v2/jobs/")).Should(BeEmpty())
+		})
+	})
+})
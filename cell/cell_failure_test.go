@@ -0,0 +1,118 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cell failure", func() {
+	const instances = 4
+
+	var (
+		runtime ifrit.Process
+
+		cellA ifrit.Process
+		cellB ifrit.Process
+
+		processGuid string
+	)
+
+	makeCell := func(cellID string, index int) ifrit.Process {
+		cellMaker := componentMaker.Cell(cellID, index)
+
+		return ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"executor", cellMaker.Executor("-memoryMB", "1024")},
+			{"rep", cellMaker.Rep()},
+		}))
+	}
+
+	BeforeEach(func() {
+		processGuid = helpers.NewGuid("lrp")
+
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"converger", componentMaker.Converger("-convergeRepeatInterval", "1s")},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		cellA = makeCell("cell-a", 0)
+		cellB = makeCell("cell-b", 1)
+
+		helpers.WaitForCellRegistration(receptorClient, "cell-a")
+		helpers.WaitForCellRegistration(receptorClient, "cell-b")
+
+		test_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime, cellA, cellB)
+	})
+
+	It("recreates the dead cell's instances on the surviving cell", func() {
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   instances,
+			Stack:       componentMaker.Stack,
+			MemoryMB:    128,
+
+			Ports: []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(helpers.RunningLRPCountPoller(receptorClient, processGuid)).Should(Equal(instances))
+
+		lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		onCellA := 0
+		for _, lrp := range lrps {
+			if lrp.CellID == "cell-a" {
+				onCellA++
+			}
+		}
+
+		Ω(onCellA).Should(BeNumerically(">", 0), "the auctioneer put nothing on cell-a")
+
+		By("killing cell-a")
+		killedAt := helpers.KillCell(cellA)
+
+		migrations := helpers.WaitForInstancesToMigrate(receptorClient, processGuid, "cell-a", killedAt, helpers.Timeouts.Crazy)
+		Ω(migrations).Should(HaveLen(onCellA))
+
+		for _, migration := range migrations {
+			Ω(migration.ToCellID).Should(Equal("cell-b"))
+			Ω(migration.Latency).Should(BeNumerically("<", time.Minute), "instance %d took %s to migrate", migration.Index, migration.Latency)
+		}
+	})
+})
@@ -0,0 +1,40 @@
+package cell_test
+
+import (
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Component environment", func() {
+	var (
+		runner   *world.TimedRunner
+		executor ifrit.Process
+	)
+
+	AfterEach(func() {
+		helpers.StopProcesses(executor)
+	})
+
+	It("starts components with the environment given to WithEnv, on top of the suite's own", func() {
+		runner = componentMaker.WithEnv("INIGO_COMPONENT_ENV=from-with-env").Executor()
+		executor = ginkgomon.Invoke(runner)
+
+		env := helpers.ProcessEnv(runner)
+		Ω(env).Should(ContainElement("INIGO_COMPONENT_ENV=from-with-env"))
+		Ω(env).Should(ContainElement("PATH=" + os.Getenv("PATH")))
+	})
+
+	It("leaves components started without it alone", func() {
+		runner = componentMaker.Executor()
+		executor = ginkgomon.Invoke(runner)
+
+		Ω(helpers.ProcessEnv(runner)).ShouldNot(ContainElement(HavePrefix("INIGO_COMPONENT_ENV=")))
+	})
+})
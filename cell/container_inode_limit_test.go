@@ -0,0 +1,97 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_metron"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Container inode limits", func() {
+	const inodeLimit = 1000
+
+	var (
+		fakeMetron *fake_metron.FakeMetron
+		runtime    ifrit.Process
+
+		logGuid string
+	)
+
+	BeforeEach(func() {
+		maker := componentMaker.WithContainerInodeLimit(inodeLimit)
+
+		// the executor forwards the Task's output here, where the error
+		// the inodes ran out with can be read back
+		fakeMetron = maker.FakeMetron()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"metron", fakeMetron},
+			{"exec", maker.Executor()},
+			{"rep", maker.Rep()},
+			{"auctioneer", maker.Auctioneer()},
+		}))
+
+		logGuid = helpers.NewGuid("log")
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	createFiles := func(count int) receptor.TaskResponse {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:    INIGO_DOMAIN,
+			TaskGuid:  taskGuid,
+			Stack:     componentMaker.Stack,
+			LogGuid:   logGuid,
+			LogSource: "TASK",
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"-c", fmt.Sprintf(`
+					mkdir files
+					for i in $(seq 1 %d); do
+						if ! error=$(touch files/${i} 2>&1); then
+							echo "Created $((i - 1)) files before running out of inodes: ${error}"
+							exit 1
+						fi
+					done
+				`, count)},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return helpers.CompletedTask(receptorClient, taskGuid)
+	}
+
+	taskOutput := func() []string {
+		lines := []string{}
+		for _, message := range fakeMetron.LogMessages(logGuid) {
+			lines = append(lines, message.Message)
+		}
+
+		return lines
+	}
+
+	It("lets the container create fewer files than the limit", func() {
+		task := createFiles(inodeLimit / 10)
+		Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+	})
+
+	It("fails the Task once the container creates more files than the limit, with the quota's error", func() {
+		task := createFiles(2 * inodeLimit)
+		Ω(task.Failed).Should(BeTrue())
+
+		Eventually(taskOutput).Should(ContainElement(MatchRegexp(`before running out of inodes: .*(Disk quota exceeded|No space left on device)`)))
+	})
+})
@@ -0,0 +1,114 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Container-to-container networking", func() {
+	var (
+		networkingMaker world.ComponentMaker
+
+		allowContainerToContainer bool
+
+		garden  ifrit.Process
+		runtime ifrit.Process
+
+		serverGuid string
+		clientGuid string
+	)
+
+	desireLRP := func(processGuid string) {
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   1,
+			Stack:       networkingMaker.Stack,
+			MemoryMB:    128,
+
+			Ports: []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", networkingMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		helpers.WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
+	}
+
+	BeforeEach(func() {
+		networkingMaker = componentMaker.WithContainerNetworking()
+
+		allowContainerToContainer = false
+
+		serverGuid = helpers.NewGuid("lrp")
+		clientGuid = helpers.NewGuid("lrp")
+	})
+
+	JustBeforeEach(func() {
+		garden = ginkgomon.Invoke(networkingMaker.GardenLinux(networkingMaker.ContainerNetworkingGardenFlags(allowContainerToContainer)...))
+
+		fileServer, fileServerStaticDir := networkingMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", networkingMaker.Executor()},
+			{"rep", networkingMaker.Rep()},
+			{"auctioneer", networkingMaker.Auctioneer()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+
+		desireLRP(serverGuid)
+		desireLRP(clientGuid)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+
+		destroyContainerErrors := helpers.CleanupGarden(networkingMaker.GardenClient())
+
+		helpers.StopProcesses(garden)
+
+		Ω(destroyContainerErrors).Should(BeEmpty(), "containers failed to be destroyed on the container networking garden")
+	})
+
+	It("blocks connections between containers by default", func() {
+		Consistently(helpers.ContainerToContainerPoller(receptorClient, networkingMaker.GardenClient(), clientGuid, serverGuid, 8080)).Should(BeFalse())
+	})
+
+	Context("when a policy allows containers to reach each other", func() {
+		BeforeEach(func() {
+			allowContainerToContainer = true
+		})
+
+		It("lets them connect by container IP", func() {
+			Eventually(helpers.ContainerToContainerPoller(receptorClient, networkingMaker.GardenClient(), clientGuid, serverGuid, 8080)).Should(BeTrue())
+		})
+	})
+})
@@ -5,13 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/cloudfoundry-incubator/inigo/fixtures"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 	"github.com/tedsuo/ifrit/grouper"
@@ -79,9 +79,9 @@ var _ = Describe("Convergence to desired state", func() {
 			fixtures.HelloWorldIndexLRP(),
 		)
 
-		appId = factories.GenerateGuid()
+		appId = helpers.NewGuid("app")
 
-		processGuid = factories.GenerateGuid()
+		processGuid = helpers.NewGuid("lrp")
 
 		runningLRPsPoller = func() []receptor.ActualLRPResponse {
 			return helpers.ActiveActualLRPs(receptorClient, processGuid)
@@ -91,7 +91,13 @@ var _ = Describe("Convergence to desired state", func() {
 	})
 
 	AfterEach(func() {
-		helpers.StopProcesses(auctioneer, executor, rep, converger, runtime)
+		helpers.Teardown(map[string]ifrit.Process{
+			"auctioneer": auctioneer,
+			"executor":   executor,
+			"rep":        rep,
+			"converger":  converger,
+			"runtime":    runtime,
+		})
 	})
 
 	Describe("Executor fault tolerance", func() {
@@ -267,4 +273,46 @@ var _ = Describe("Convergence to desired state", func() {
 			})
 		})
 	})
+
+	Describe("Convergence latency", func() {
+		var (
+			budget time.Duration
+
+			// the executor's, so that orphaning a container can tag it as
+			// the executor's own
+			ownerName string
+		)
+
+		BeforeEach(func() {
+			budget = helpers.Timeouts.ConvergenceBudget
+			ownerName = helpers.NewGuid("executor")
+
+			auctioneer = ginkgomon.Invoke(componentMaker.Auctioneer())
+			executor = ginkgomon.Invoke(componentMaker.WithContainerOwner(ownerName).Executor())
+			rep = ginkgomon.Invoke(componentMaker.Rep())
+			converger = ginkgomon.Invoke(componentMaker.Converger(
+				"-convergeRepeatInterval", "1s",
+			))
+
+			err := receptorClient.CreateDesiredLRP(constructDesiredLRPRequest(1))
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("replaces a lost actual LRP within the budget", func() {
+			divergence := helpers.DivergeByDeletingActualLRP(componentMaker.Addresses.Etcd, receptorClient, processGuid, 0)
+			divergence.ExpectRepairWithin(budget)
+
+			Eventually(helloWorldInstancePoller).Should(Equal([]string{"0"}))
+		})
+
+		It("cleans up an orphaned container within the budget", func() {
+			helpers.WaitForLRPInstanceState(receptorClient, processGuid, 0, receptor.ActualLRPStateRunning)
+
+			divergence := helpers.DivergeByOrphaningContainer(gardenClient, ownerName)
+			divergence.ExpectRepairWithin(budget)
+
+			By("leaving the instance that is wanted alone")
+			Ω(helloWorldInstancePoller()).Should(Equal([]string{"0"}))
+		})
+	})
 })
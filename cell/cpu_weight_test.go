@@ -0,0 +1,87 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CPU weight", func() {
+	var runtime ifrit.Process
+
+	BeforeEach(func() {
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", componentMaker.Executor("-memoryMB", "1024")},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "busy.zip"),
+			fixtures.BusyLoop(),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	runBusyTask := func(cpuWeight uint) string {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:    INIGO_DOMAIN,
+			TaskGuid:  taskGuid,
+			Stack:     componentMaker.Stack,
+			MemoryMB:  128,
+			CPUWeight: cpuWeight,
+			Action: models.Serial(
+				&models.DownloadAction{
+					From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "busy.zip"),
+					To:   ".",
+				},
+				&models.RunAction{
+					Path: "bash",
+					Args: []string{"busy.sh"},
+				},
+			),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(helpers.ContainerCPUUsagePoller(gardenClient, taskGuid)).Should(BeNumerically(">", 0))
+
+		return taskGuid
+	}
+
+	It("shares CPU between competing containers in proportion to their weight", func() {
+		heavyGuid := runBusyTask(100)
+		lightGuid := runBusyTask(25)
+
+		heavyBefore := helpers.ContainerCPUUsage(gardenClient, heavyGuid)
+		lightBefore := helpers.ContainerCPUUsage(gardenClient, lightGuid)
+
+		time.Sleep(10 * time.Second)
+
+		heavyUsed := helpers.ContainerCPUUsage(gardenClient, heavyGuid) - heavyBefore
+		lightUsed := helpers.ContainerCPUUsage(gardenClient, lightGuid) - lightBefore
+
+		// 4:1 in theory; leave plenty of slack for scheduler noise
+		Ω(heavyUsed).Should(BeNumerically(">", 2*lightUsed))
+	})
+})
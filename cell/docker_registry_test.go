@@ -0,0 +1,90 @@
+package cell_test
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Private docker registry", func() {
+	const (
+		image    = "private-rootfs"
+		username = "inigo"
+		password = "secret"
+	)
+
+	var (
+		maker    world.ComponentMaker
+		registry *helpers.FakeDockerRegistry
+
+		registryGarden       ifrit.Process
+		registryGardenClient garden.Client
+	)
+
+	BeforeEach(func() {
+		registry = helpers.NewFakeDockerRegistry("127.0.0.1")
+		registry.AddImage(image, helpers.LayerOf(fixtures.TinyRootFS(image, 1024)))
+
+		maker = componentMaker.WithDockerRegistry(registry.Address)
+
+		registryGarden = ginkgomon.Invoke(maker.GardenLinux(maker.DockerRegistryFlags()...))
+		registryGardenClient = maker.GardenClient()
+	})
+
+	AfterEach(func() {
+		destroyContainerErrors := helpers.CleanupGarden(registryGardenClient)
+
+		helpers.StopProcesses(registryGarden)
+		registry.Close()
+
+		Ω(destroyContainerErrors).Should(BeEmpty(), "containers failed to be destroyed")
+	})
+
+	Context("when the registry wants no credentials", func() {
+		It("pulls the image without any", func() {
+			_, err := registryGardenClient.Create(garden.ContainerSpec{RootFSPath: maker.DockerRootFSURL(image)})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(registry.AuthorizedRequests()).ShouldNot(BeEmpty())
+			Ω(registry.UnauthorizedRequests()).Should(BeEmpty())
+		})
+	})
+
+	Context("when the registry wants credentials", func() {
+		BeforeEach(func() {
+			registry.RequireCredentials(username, password)
+		})
+
+		It("pulls the image with them", func() {
+			maker = maker.WithDockerRegistryCredentials(username, password)
+
+			_, err := registryGardenClient.Create(garden.ContainerSpec{RootFSPath: maker.DockerRootFSURL(image)})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(registry.AuthorizedRequests()).ShouldNot(BeEmpty())
+		})
+
+		It("pulls nothing without them", func() {
+			_, err := registryGardenClient.Create(garden.ContainerSpec{RootFSPath: maker.DockerRootFSURL(image)})
+			Ω(err).Should(HaveOccurred())
+
+			Ω(registry.UnauthorizedRequests()).ShouldNot(BeEmpty())
+			Ω(registry.AuthorizedRequests()).Should(BeEmpty())
+		})
+
+		It("pulls nothing with the wrong ones", func() {
+			maker = maker.WithDockerRegistryCredentials(username, "wrong")
+
+			_, err := registryGardenClient.Create(garden.ContainerSpec{RootFSPath: maker.DockerRootFSURL(image)})
+			Ω(err).Should(HaveOccurred())
+
+			Ω(registry.AuthorizedRequests()).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Domain freshness", func() {
+	const convergeInterval = time.Second
+	const staleTTL = 2 * time.Second
+
+	var (
+		runtime   ifrit.Process
+		rep       ifrit.Process
+		converger ifrit.Process
+
+		domain      string
+		processGuid string
+	)
+
+	startConverger := func() ifrit.Process {
+		return ginkgomon.Invoke(componentMaker.Converger("-convergeRepeatInterval", convergeInterval.String()))
+	}
+
+	BeforeEach(func() {
+		domain = helpers.NewGuid("freshness")
+		processGuid = helpers.NewGuid("lrp")
+
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", componentMaker.Executor()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		rep = ginkgomon.Invoke(componentMaker.Rep())
+		converger = startConverger()
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+
+		helpers.BumpDomain(receptorClient, domain, 0)
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      domain,
+			Stack:       componentMaker.Stack,
+			ProcessGuid: processGuid,
+			Instances:   2,
+
+			Ports: []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(helpers.RunningLRPCountPoller(receptorClient, processGuid)).Should(Equal(2))
+
+		By("scaling down while the rep and converger are away, so that an extra instance is left over")
+		converger.Signal(syscall.SIGKILL)
+		rep.Signal(syscall.SIGKILL)
+		Eventually(converger.Wait()).Should(Receive())
+		Eventually(rep.Wait()).Should(Receive())
+
+		onePlease := 1
+		err = receptorClient.UpdateDesiredLRP(processGuid, receptor.DesiredLRPUpdateRequest{
+			Instances: &onePlease,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(converger, rep, runtime)
+	})
+
+	Context("when the domain is fresh", func() {
+		It("retires the extra instance", func() {
+			rep = ginkgomon.Invoke(componentMaker.Rep())
+			converger = startConverger()
+
+			helpers.ExpectExtraInstancesRetired(receptorClient, processGuid, 1)
+		})
+	})
+
+	Context("when the domain goes stale", func() {
+		BeforeEach(func() {
+			helpers.BumpDomain(receptorClient, domain, staleTTL)
+			helpers.WaitForDomainToGoStale(receptorClient, domain, staleTTL)
+
+			rep = ginkgomon.Invoke(componentMaker.Rep())
+			converger = startConverger()
+		})
+
+		It("leaves the extra instance alone until the domain is fresh again", func() {
+			helpers.ExpectExtraInstancesKept(receptorClient, processGuid, 2, convergeInterval)
+
+			helpers.BumpDomain(receptorClient, domain, 0)
+
+			helpers.ExpectExtraInstancesRetired(receptorClient, processGuid, 1)
+		})
+	})
+})
@@ -0,0 +1,291 @@
+package cell_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/inigo/chaos"
+	"github.com/cloudfoundry-incubator/inigo/fake_blobstore"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Downloading", func() {
+	var (
+		blobstore *fake_blobstore.FakeBlobstore
+
+		executorArgs   []string
+		executorRunner *world.TimedRunner
+
+		runtime ifrit.Process
+	)
+
+	zipOf := func(files ...archive_helper.ArchiveFile) []byte {
+		zipPath := filepath.Join(componentMaker.TempDirs.New("download-zips"), "blob.zip")
+		archive_helper.CreateZipArchive(zipPath, files)
+
+		zip, err := ioutil.ReadFile(zipPath)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return zip
+	}
+
+	downloadTask := func(blobName string, cacheKey string) string {
+		return helpers.DesireDownloadTask(receptorClient, componentMaker, INIGO_DOMAIN, blobstore.URL(blobName), cacheKey)
+	}
+
+	BeforeEach(func() {
+		blobstore = componentMaker.FakeBlobstore()
+		blobstore.SetBlob("the-blob", zipOf(fixtures.VersionedDownload("version one")...))
+
+		executorArgs = []string{}
+	})
+
+	JustBeforeEach(func() {
+		executorRunner = componentMaker.Executor(executorArgs...)
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"blobstore", blobstore},
+			{"exec", executorRunner},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	Context("when the connection is reset mid-download", func() {
+		BeforeEach(func() {
+			blobstore.ResetConnectionsAfter("the-blob", 10, 1)
+		})
+
+		It("retries the download", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", ""))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("version one"))
+
+			requests := blobstore.Requests("the-blob")
+			Ω(len(requests)).Should(BeNumerically(">=", 2))
+			Ω(requests[0].Reset).Should(BeTrue())
+		})
+	})
+
+	Context("when the download is slow", func() {
+		BeforeEach(func() {
+			blobstore.Throttle("the-blob", 1024)
+		})
+
+		It("waits for it", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", ""))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("version one"))
+		})
+	})
+
+	Context("when the checksum does not match the content", func() {
+		BeforeEach(func() {
+			blobstore.CorruptChecksum("the-blob", true)
+		})
+
+		It("fails the Task", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", ""))
+			Ω(task.Failed).Should(BeTrue())
+		})
+	})
+
+	Context("when the disk fills up mid-download", func() {
+		var pressure *chaos.DiskPressure
+
+		BeforeEach(func() {
+			if os.Getenv("DISK_PRESSURE") != "1" {
+				Skip("filling the disk only happens with DISK_PRESSURE=1")
+			}
+
+			// random so that zipping it does not shrink it to nothing
+			random := make([]byte, 4*1024*1024)
+			_, err := rand.Read(random)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			blobstore.SetBlob("the-big-blob", zipOf(archive_helper.ArchiveFile{Name: "contents", Body: hex.EncodeToString(random)}))
+		})
+
+		JustBeforeEach(func() {
+			pressure = chaos.FillDiskLeaving(helpers.ExecutorCachePath(executorRunner), 1024*1024)
+		})
+
+		AfterEach(func() {
+			if pressure != nil {
+				pressure.Release()
+			}
+		})
+
+		It("fails the Task, and downloads fine once there is room again", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-big-blob", "the-cache-key"))
+			Ω(task.Failed).Should(BeTrue())
+
+			pressure.Release()
+
+			task = helpers.CompletedTask(receptorClient, downloadTask("the-big-blob", "the-cache-key"))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+		})
+	})
+
+	Context("when downloading with a cache key", func() {
+		It("revalidates the cached copy, and fetches it again once its ETag changes", func() {
+			By("downloading it the first time")
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("version one"))
+
+			firstETag := blobstore.ETag("the-blob")
+
+			By("hitting the cache the second time")
+			task = helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("version one"))
+
+			cachePath := helpers.ExecutorCachePath(executorRunner)
+			Ω(helpers.ExecutorCacheEntries(cachePath)).Should(HaveLen(1))
+
+			requests := blobstore.Requests("the-blob")
+			Ω(requests).Should(HaveLen(2))
+			Ω(requests[1].Header.Get("If-None-Match")).Should(Equal(firstETag))
+			Ω(requests[1].RespondedWith).Should(Equal(http.StatusNotModified))
+
+			By("changing the blob")
+			blobstore.SetBlob("the-blob", zipOf(fixtures.VersionedDownload("version two")...))
+
+			task = helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("version two"))
+
+			requests = blobstore.Requests("the-blob")
+			Ω(requests).Should(HaveLen(3))
+			Ω(requests[2].RespondedWith).Should(Equal(http.StatusOK))
+
+			Ω(helpers.ExecutorCacheEntries(cachePath)).Should(HaveLen(1))
+		})
+
+		It("shares the cached copy with other tasks downloading under the same key", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+			for i := 0; i < 2; i++ {
+				task = helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+				Ω(task.Result).Should(Equal("version one"))
+
+				helpers.ExpectRevalidated(blobstore, "the-blob")
+			}
+
+			Ω(helpers.ExecutorCacheEntries(helpers.ExecutorCachePath(executorRunner))).Should(HaveLen(1))
+		})
+
+		It("downloads in full under a key that nothing has been cached under", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+			task = helpers.CompletedTask(receptorClient, downloadTask("the-blob", helpers.CacheBustingKey("the-cache-key")))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("version one"))
+
+			helpers.ExpectFetchedInFull(blobstore, "the-blob")
+
+			requests := blobstore.Requests("the-blob")
+			Ω(requests).Should(HaveLen(2))
+			Ω(requests[1].Header.Get("If-None-Match")).Should(BeEmpty())
+
+			Ω(helpers.ExecutorCacheEntries(helpers.ExecutorCachePath(executorRunner))).Should(HaveLen(2))
+		})
+
+		Context("when the blob is served without an ETag", func() {
+			BeforeEach(func() {
+				blobstore.OmitETag("the-blob", true)
+			})
+
+			It("revalidates the cached copy by Last-Modified, and fetches it again once that changes", func() {
+				task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+				firstLastModified := blobstore.LastModified("the-blob")
+
+				task = helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+				Ω(task.Result).Should(Equal("version one"))
+
+				helpers.ExpectRevalidated(blobstore, "the-blob")
+				Ω(blobstore.Requests("the-blob")[1].Header.Get("If-Modified-Since")).Should(Equal(firstLastModified.Format(http.TimeFormat)))
+
+				By("changing the blob")
+				blobstore.SetBlob("the-blob", zipOf(fixtures.VersionedDownload("version two")...))
+
+				task = helpers.CompletedTask(receptorClient, downloadTask("the-blob", "the-cache-key"))
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+				Ω(task.Result).Should(Equal("version two"))
+
+				helpers.ExpectFetchedInFull(blobstore, "the-blob")
+				Ω(helpers.ExecutorCacheEntries(helpers.ExecutorCachePath(executorRunner))).Should(HaveLen(1))
+			})
+		})
+
+		It("does not cache downloads without a cache key", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask("the-blob", ""))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+			Ω(helpers.ExecutorCacheEntries(helpers.ExecutorCachePath(executorRunner))).Should(BeEmpty())
+		})
+
+		Context("when the cache is too small for everything", func() {
+			var maxCacheSize int
+
+			BeforeEach(func() {
+				// random so that zipping it does not shrink it to nothing
+				random := make([]byte, 32*1024)
+				_, err := rand.Read(random)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				body := zipOf(archive_helper.ArchiveFile{Name: "contents", Body: hex.EncodeToString(random)})
+				blobstore.SetBlob("one-blob", body)
+				blobstore.SetBlob("another-blob", body)
+
+				// room for one of them, but not both
+				maxCacheSize = len(body) * 3 / 2
+
+				executorArgs = []string{"-maxCacheSizeInBytes", strconv.Itoa(maxCacheSize)}
+			})
+
+			It("evicts older entries to stay under the limit", func() {
+				cachePath := helpers.ExecutorCachePath(executorRunner)
+
+				task := helpers.CompletedTask(receptorClient, downloadTask("one-blob", "one-cache-key"))
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+				Ω(helpers.ExecutorCacheEntries(cachePath)).Should(HaveLen(1))
+
+				task = helpers.CompletedTask(receptorClient, downloadTask("another-blob", "another-cache-key"))
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+				Ω(helpers.ExecutorCacheEntries(cachePath)).Should(HaveLen(1))
+				Ω(helpers.ExecutorCacheSize(cachePath)).Should(BeNumerically("<=", maxCacheSize))
+
+				By("downloading the evicted one again")
+				task = helpers.CompletedTask(receptorClient, downloadTask("one-blob", "one-cache-key"))
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+				Ω(blobstore.Requests("one-blob")[1].RespondedWith).Should(Equal(http.StatusOK))
+			})
+		})
+	})
+})
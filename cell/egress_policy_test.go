@@ -0,0 +1,159 @@
+package cell_test
+
+import (
+	"net"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Container egress policy", func() {
+	var (
+		policy      world.EgressPolicy
+		egressMaker world.ComponentMaker
+
+		garden  ifrit.Process
+		runtime ifrit.Process
+
+		hostListener         net.Listener
+		linkLocalListener    *helpers.NamespacedListener
+		externalListener     *helpers.NamespacedListener
+		linkLocalDestination helpers.EgressDestination
+		externalDestination  helpers.EgressDestination
+		destinations         []helpers.EgressDestination
+	)
+
+	expectEgress := func(expected map[string]bool) {
+		lrp := helpers.DesireEgressProbeLRP(receptorClient, INIGO_DOMAIN, egressMaker.Stack, helpers.NewGuid("lrp"), destinations)
+		helpers.ExpectEgress(lrp, destinations, expected)
+	}
+
+	BeforeEach(func() {
+		garden = nil
+		runtime = nil
+		linkLocalListener = nil
+		externalListener = nil
+
+		var hostDestination helpers.EgressDestination
+		hostDestination, hostListener = helpers.HostDestination(componentMaker.ExternalAddress)
+		linkLocalDestination, linkLocalListener = helpers.NamespacedDestination(componentMaker, "link-local", helpers.LinkLocalSubnet)
+		externalDestination, externalListener = helpers.NamespacedDestination(componentMaker, "external", helpers.ExternalSubnet)
+
+		destinations = []helpers.EgressDestination{
+			hostDestination,
+			linkLocalDestination,
+			externalDestination,
+		}
+
+		// like a cell's
+		policy = world.EgressPolicy{
+			DenyNetworks:    []string{"0.0.0.0/0"},
+			AllowHostAccess: true,
+		}
+	})
+
+	JustBeforeEach(func() {
+		egressMaker = componentMaker.WithEgressPolicy(policy)
+
+		garden = ginkgomon.Invoke(egressMaker.GardenLinux())
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"exec", egressMaker.Executor()},
+			{"rep", egressMaker.Rep()},
+			{"auctioneer", egressMaker.Auctioneer()},
+		}))
+	})
+
+	AfterEach(func() {
+		// the namespaced ones may not have been made, if making one failed
+		defer hostListener.Close()
+		defer linkLocalListener.Close()
+		defer externalListener.Close()
+
+		if garden == nil {
+			return
+		}
+
+		helpers.StopProcesses(runtime)
+
+		destroyContainerErrors := helpers.CleanupGarden(egressMaker.GardenClient())
+
+		helpers.StopProcesses(garden)
+
+		Ω(destroyContainerErrors).Should(BeEmpty(), "containers failed to be destroyed on the egress policy garden")
+	})
+
+	// so that the specs below can only pass by the policy keeping them out
+	Context("when nothing is denied", func() {
+		BeforeEach(func() {
+			policy.DenyNetworks = nil
+		})
+
+		It("lets containers reach everything", func() {
+			expectEgress(map[string]bool{
+				"host":       true,
+				"link-local": true,
+				"external":   true,
+			})
+		})
+	})
+
+	Context("when every network is denied but the host", func() {
+		It("lets containers reach the host, and nothing else", func() {
+			expectEgress(map[string]bool{
+				"host":       true,
+				"link-local": false,
+				"external":   false,
+			})
+		})
+	})
+
+	Context("when the host is denied too", func() {
+		BeforeEach(func() {
+			policy.AllowHostAccess = false
+		})
+
+		It("lets containers reach nothing", func() {
+			expectEgress(map[string]bool{
+				"host":       false,
+				"link-local": false,
+				"external":   false,
+			})
+		})
+	})
+
+	Context("when only link-local addresses are denied", func() {
+		BeforeEach(func() {
+			policy.DenyNetworks = []string{"169.254.0.0/16"}
+		})
+
+		It("keeps containers from link-local addresses, and nothing else", func() {
+			expectEgress(map[string]bool{
+				"host":       true,
+				"link-local": false,
+				"external":   true,
+			})
+		})
+	})
+
+	Context("when an external network is allowed through the deny-all", func() {
+		BeforeEach(func() {
+			policy.AllowNetworks = []string{externalDestination.IP + "/32"}
+		})
+
+		It("lets containers reach it, and still not link-local addresses", func() {
+			expectEgress(map[string]bool{
+				"host":       true,
+				"link-local": false,
+				"external":   true,
+			})
+		})
+	})
+})
@@ -8,10 +8,10 @@ import (
 
 	"github.com/cloudfoundry-incubator/inigo/fixtures"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
 	"github.com/pivotal-golang/archiver/extractor/test_helper"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
@@ -25,16 +25,11 @@ var _ = Describe("Evacuation", func() {
 	var (
 		runtime ifrit.Process
 
-		cellAID           string
-		cellAExecutorAddr string
-		cellARepAddr      string
+		cellAMaker world.ComponentMaker
+		cellBMaker world.ComponentMaker
 
-		cellBID           string
-		cellBExecutorAddr string
-		cellBRepAddr      string
-
-		cellARepRunner *ginkgomon.Runner
-		cellBRepRunner *ginkgomon.Runner
+		cellARepRunner *world.TimedRunner
+		cellBRepRunner *world.TimedRunner
 
 		cellA ifrit.Process
 		cellB ifrit.Process
@@ -44,8 +39,8 @@ var _ = Describe("Evacuation", func() {
 	)
 
 	BeforeEach(func() {
-		processGuid = factories.GenerateGuid()
-		appId = factories.GenerateGuid()
+		processGuid = helpers.NewGuid("lrp")
+		appId = helpers.NewGuid("app")
 
 		fileServer, fileServerStaticDir := componentMaker.FileServer()
 
@@ -57,45 +52,25 @@ var _ = Describe("Evacuation", func() {
 			{"route-emitter", componentMaker.RouteEmitter()},
 		}))
 
-		cellAID = "cell-a"
-		cellBID = "cell-b"
-
-		cellAExecutorAddr = fmt.Sprintf("127.0.0.1:%d", 13100+GinkgoParallelNode())
-		cellBExecutorAddr = fmt.Sprintf("127.0.0.1:%d", 13200+GinkgoParallelNode())
+		cellAMaker = componentMaker.Cell("cell-a", 0)
+		cellBMaker = componentMaker.Cell("cell-b", 1)
 
-		cellARepAddr = fmt.Sprintf("0.0.0.0:%d", 14100+GinkgoParallelNode())
-		cellBRepAddr = fmt.Sprintf("0.0.0.0:%d", 14200+GinkgoParallelNode())
-
-		cellARepRunner = componentMaker.Rep(
-			"-cellID", cellAID,
-			"-executorURL", "http://"+cellAExecutorAddr,
-			"-listenAddr", cellARepAddr,
-			"-evacuationTimeout", "30s",
-		)
-
-		cellBRepRunner = componentMaker.Rep(
-			"-cellID", cellBID,
-			"-executorURL", "http://"+cellBExecutorAddr,
-			"-listenAddr", cellBRepAddr,
-			"-evacuationTimeout", "30s",
-		)
+		cellARepRunner = cellAMaker.Rep("-evacuationTimeout", "30s")
+		cellBRepRunner = cellBMaker.Rep("-evacuationTimeout", "30s")
 
 		cellA = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
-			{"executor", componentMaker.Executor(
-				"-containerOwnerName", cellAID+"-executor",
-				"-listenAddr", cellAExecutorAddr,
-			)},
+			{"executor", cellAMaker.Executor()},
 			{"rep", cellARepRunner},
 		}))
 
 		cellB = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
-			{"executor", componentMaker.Executor(
-				"-containerOwnerName", cellBID+"-executor",
-				"-listenAddr", cellBExecutorAddr,
-			)},
+			{"executor", cellBMaker.Executor()},
 			{"rep", cellBRepRunner},
 		}))
 
+		helpers.WaitForCellRegistration(receptorClient, cellAMaker.CellID())
+		helpers.WaitForCellRegistration(receptorClient, cellBMaker.CellID())
+
 		test_helper.CreateZipArchive(
 			filepath.Join(fileServerStaticDir, "lrp.zip"),
 			fixtures.HelloWorldIndexLRP(),
@@ -137,21 +112,21 @@ var _ = Describe("Evacuation", func() {
 		Ω(err).ShouldNot(HaveOccurred())
 
 		By("running an actual LRP instance")
-		Eventually(helpers.LRPStatePoller(receptorClient, processGuid, nil)).Should(Equal(receptor.ActualLRPStateRunning))
+		helpers.WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
 		Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses.Router, "lrp-route")).Should(Equal(http.StatusOK))
 
 		actualLRP, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, 0)
 		Ω(err).ShouldNot(HaveOccurred())
 
 		var evacuatingRepAddr string
-		var evacutaingRepRunner *ginkgomon.Runner
+		var evacutaingRepRunner *world.TimedRunner
 
 		switch actualLRP.CellID {
-		case cellAID:
-			evacuatingRepAddr = cellARepAddr
+		case cellAMaker.CellID():
+			evacuatingRepAddr = cellAMaker.Addresses.Rep
 			evacutaingRepRunner = cellARepRunner
-		case cellBID:
-			evacuatingRepAddr = cellBRepAddr
+		case cellBMaker.CellID():
+			evacuatingRepAddr = cellBMaker.Addresses.Rep
 			evacutaingRepRunner = cellBRepRunner
 		default:
 			panic("what? who?")
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/pivotal-golang/archiver/extractor/test_helper"
@@ -13,12 +14,14 @@ import (
 	"github.com/tedsuo/ifrit/ginkgomon"
 
 	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/chaos"
+	"github.com/cloudfoundry-incubator/inigo/fake_metron"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
-	"github.com/cloudfoundry-incubator/inigo/inigo_announcement_server"
+	"github.com/cloudfoundry-incubator/inigo/world"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/rep"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
@@ -27,6 +30,8 @@ import (
 var _ = Describe("Executor", func() {
 	var (
 		executorProcess, fileServerProcess, repProcess, auctioneerProcess, convergerProcess ifrit.Process
+
+		executorRunner *world.TimedRunner
 	)
 
 	var fileServerStaticDir string
@@ -36,7 +41,8 @@ var _ = Describe("Executor", func() {
 
 		fileServerRunner, fileServerStaticDir = componentMaker.FileServer()
 
-		executorProcess = ginkgomon.Invoke(componentMaker.Executor("-memoryMB", "1024"))
+		executorRunner = componentMaker.Executor("-memoryMB", "1024")
+		executorProcess = ginkgomon.Invoke(executorRunner)
 		fileServerProcess = ginkgomon.Invoke(fileServerRunner)
 		repProcess = ginkgomon.Invoke(componentMaker.Rep())
 		auctioneerProcess = ginkgomon.Invoke(componentMaker.Auctioneer())
@@ -49,14 +55,24 @@ var _ = Describe("Executor", func() {
 
 	Describe("Heartbeating", func() {
 		It("should heartbeat its presence (through the rep)", func() {
-			Eventually(receptorClient.Cells).Should(HaveLen(1))
+			cell := helpers.WaitForCellRegistration(receptorClient, componentMaker.CellID())
+			Ω(cell.Stack).Should(Equal(componentMaker.Stack))
+			Ω(cell.Capacity.MemoryMB).Should(Equal(1024))
+		})
+
+		It("should stop heartbeating when the rep goes away", func() {
+			helpers.WaitForCellRegistration(receptorClient, componentMaker.CellID())
+
+			helpers.StopProcesses(repProcess)
+
+			helpers.WaitForCellDeregistration(receptorClient, componentMaker.CellID())
 		})
 	})
 
 	Describe("Resource limits", func() {
 		It("should only pick up tasks if it has capacity", func() {
-			firstGuyGuid := factories.GenerateGuid()
-			secondGuyGuid := factories.GenerateGuid()
+			firstGuyGuid := helpers.NewGuid("task")
+			secondGuyGuid := helpers.NewGuid("task")
 
 			err := receptorClient.CreateTask(receptor.TaskCreateRequest{
 				TaskGuid: firstGuyGuid,
@@ -66,12 +82,12 @@ var _ = Describe("Executor", func() {
 				DiskMB:   1024,
 				Action: &models.RunAction{
 					Path: "/bin/bash",
-					Args: []string{"-c", "curl " + inigo_announcement_server.AnnounceURL(firstGuyGuid) + " && tail -f /dev/null"},
+					Args: []string{"-c", "curl " + announcementClient.AnnounceURL(firstGuyGuid) + " && tail -f /dev/null"},
 				},
 			})
 			Ω(err).ShouldNot(HaveOccurred())
 
-			Eventually(inigo_announcement_server.Announcements).Should(ContainElement(firstGuyGuid))
+			Eventually(announcementClient.Announcements).Should(ContainElement(firstGuyGuid))
 
 			err = receptorClient.CreateTask(receptor.TaskCreateRequest{
 				TaskGuid: secondGuyGuid,
@@ -81,12 +97,12 @@ var _ = Describe("Executor", func() {
 				DiskMB:   1024,
 				Action: &models.RunAction{
 					Path: "curl",
-					Args: []string{inigo_announcement_server.AnnounceURL(secondGuyGuid)},
+					Args: []string{announcementClient.AnnounceURL(secondGuyGuid)},
 				},
 			})
 			Ω(err).ShouldNot(HaveOccurred())
 
-			Consistently(inigo_announcement_server.Announcements).ShouldNot(ContainElement(secondGuyGuid))
+			Consistently(announcementClient.Announcements).ShouldNot(ContainElement(secondGuyGuid))
 		})
 	})
 
@@ -95,7 +111,7 @@ var _ = Describe("Executor", func() {
 			var taskGuid string
 
 			BeforeEach(func() {
-				taskGuid = factories.GenerateGuid()
+				taskGuid = helpers.NewGuid("task")
 
 				err := receptorClient.CreateTask(receptor.TaskCreateRequest{
 					TaskGuid: taskGuid,
@@ -119,8 +135,7 @@ var _ = Describe("Executor", func() {
 				}).Should(Equal(executor.StateRunning))
 
 				// bounce executor
-				ginkgomon.Kill(executorProcess)
-				executorProcess = ginkgomon.Invoke(componentMaker.Executor("-memoryMB", "1024"))
+				executorProcess, executorRunner = componentMaker.RestartExecutor(executorProcess, executorRunner)
 			})
 
 			It("eventually marks the task completed and failed", func() {
@@ -141,13 +156,14 @@ var _ = Describe("Executor", func() {
 
 		Context("when a lrp is running and then something causes the container to go away", func() {
 			var (
-				instanceGuid string
-				processGuid  string
-				index        int
+				instanceGuid  string
+				processGuid   string
+				containerGuid string
+				index         int
 			)
 
 			BeforeEach(func() {
-				processGuid = factories.GenerateGuid()
+				processGuid = helpers.NewGuid("lrp")
 				index = 0
 
 				err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
@@ -179,7 +195,7 @@ var _ = Describe("Executor", func() {
 				}).Should(HaveLen(1))
 
 				instanceGuid = actualLRPs[0].InstanceGuid
-				containerGuid := rep.LRPContainerGuid(processGuid, instanceGuid)
+				containerGuid = rep.LRPContainerGuid(processGuid, instanceGuid)
 
 				executorClient := componentMaker.ExecutorClient()
 
@@ -191,22 +207,61 @@ var _ = Describe("Executor", func() {
 
 					return executor.StateInvalid
 				}).Should(Equal(executor.StateRunning))
-
-				// bounce executor
-				ginkgomon.Kill(executorProcess)
-				executorProcess = ginkgomon.Invoke(componentMaker.Executor("-memoryMB", "1024"))
 			})
 
-			It("eventually deletes the original lrp", func() {
-				lrpMatchesOriginalInstanceGuid := func() (bool, error) {
-					actualLRP, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, index)
-					if err != nil {
-						return false, err
-					}
-					return actualLRP.InstanceGuid == instanceGuid, nil
+			lrpMatchesOriginalInstanceGuid := func() (bool, error) {
+				actualLRP, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, index)
+				if err != nil {
+					return false, err
 				}
+				return actualLRP.InstanceGuid == instanceGuid, nil
+			}
+
+			Context("because the executor restarts", func() {
+				BeforeEach(func() {
+					executorProcess, executorRunner = componentMaker.RestartExecutor(executorProcess, executorRunner)
+				})
+
+				It("eventually deletes the original lrp", func() {
+					Eventually(lrpMatchesOriginalInstanceGuid).Should(BeFalse())
+				})
+			})
+
+			Context("because its garden container is destroyed", func() {
+				BeforeEach(func() {
+					destroyed := chaos.DestroyContainersMatching(gardenClient, nil)
+					Ω(destroyed).Should(ConsistOf(containerGuid))
+				})
+
+				It("eventually deletes the original lrp", func() {
+					Eventually(lrpMatchesOriginalInstanceGuid).Should(BeFalse())
+				})
+			})
+
+			Context("because its processes are killed but the container remains", func() {
+				BeforeEach(func() {
+					chaos.KillContainerProcesses(gardenClient, containerGuid)
+				})
+
+				It("eventually deletes the original lrp", func() {
+					Eventually(lrpMatchesOriginalInstanceGuid).Should(BeFalse())
+				})
+			})
+
+			Context("because its processes are paused", func() {
+				BeforeEach(func() {
+					chaos.PauseContainerProcesses(gardenClient, containerGuid)
+				})
+
+				It("keeps the original lrp, which carries on once they are resumed", func() {
+					// the monitor runs processes of its own, so it still passes
+					Consistently(lrpMatchesOriginalInstanceGuid).Should(BeTrue())
+
+					chaos.ResumeContainerProcesses(gardenClient, containerGuid)
 
-				Eventually(lrpMatchesOriginalInstanceGuid).Should(BeFalse())
+					Consistently(lrpMatchesOriginalInstanceGuid).Should(BeTrue())
+					Ω(helpers.ActiveActualLRPs(receptorClient, processGuid)).Should(HaveLen(1))
+				})
 			})
 		})
 	})
@@ -215,8 +270,8 @@ var _ = Describe("Executor", func() {
 		var wrongStack = "penguin"
 
 		It("should only pick up tasks if the stacks match", func() {
-			matchingGuid := factories.GenerateGuid()
-			nonMatchingGuid := factories.GenerateGuid()
+			matchingGuid := helpers.NewGuid("task")
+			nonMatchingGuid := helpers.NewGuid("task")
 
 			err := receptorClient.CreateTask(receptor.TaskCreateRequest{
 				TaskGuid: matchingGuid,
@@ -224,7 +279,7 @@ var _ = Describe("Executor", func() {
 				Stack:    componentMaker.Stack,
 				Action: &models.RunAction{
 					Path: "curl",
-					Args: []string{inigo_announcement_server.AnnounceURL(matchingGuid)},
+					Args: []string{announcementClient.AnnounceURL(matchingGuid)},
 				},
 			})
 			Ω(err).ShouldNot(HaveOccurred())
@@ -235,13 +290,13 @@ var _ = Describe("Executor", func() {
 				Stack:    wrongStack,
 				Action: &models.RunAction{
 					Path: "curl",
-					Args: []string{inigo_announcement_server.AnnounceURL(nonMatchingGuid)},
+					Args: []string{announcementClient.AnnounceURL(nonMatchingGuid)},
 				},
 			})
 			Ω(err).ShouldNot(HaveOccurred())
 
-			Consistently(inigo_announcement_server.Announcements).ShouldNot(ContainElement(nonMatchingGuid), "Did not expect to see this app running, as it has the wrong stack.")
-			Eventually(inigo_announcement_server.Announcements).Should(ContainElement(matchingGuid))
+			Consistently(announcementClient.Announcements).ShouldNot(ContainElement(nonMatchingGuid), "Did not expect to see this app running, as it has the wrong stack.")
+			Eventually(announcementClient.Announcements).Should(ContainElement(matchingGuid))
 		})
 	})
 
@@ -249,7 +304,7 @@ var _ = Describe("Executor", func() {
 		var guid string
 
 		BeforeEach(func() {
-			guid = factories.GenerateGuid()
+			guid = helpers.NewGuid("task")
 		})
 
 		It("runs the command with the provided environment", func() {
@@ -321,7 +376,7 @@ var _ = Describe("Executor", func() {
 					Action: models.Serial(
 						&models.RunAction{
 							Path: "curl",
-							Args: []string{inigo_announcement_server.AnnounceURL("before-memory-overdose")},
+							Args: []string{announcementClient.AnnounceURL("before-memory-overdose")},
 						},
 						&models.RunAction{
 							Path: "sh",
@@ -329,13 +384,13 @@ var _ = Describe("Executor", func() {
 						},
 						&models.RunAction{
 							Path: "curl",
-							Args: []string{inigo_announcement_server.AnnounceURL("after-memory-overdose")},
+							Args: []string{announcementClient.AnnounceURL("after-memory-overdose")},
 						},
 					),
 				})
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Eventually(inigo_announcement_server.Announcements).Should(ContainElement("before-memory-overdose"))
+				Eventually(announcementClient.Announcements).Should(ContainElement("before-memory-overdose"))
 
 				var task receptor.TaskResponse
 				Eventually(func() interface{} {
@@ -350,7 +405,98 @@ var _ = Describe("Executor", func() {
 				Ω(task.Failed).Should(BeTrue())
 				Ω(task.FailureReason).Should(ContainSubstring("out of memory"))
 
-				Ω(inigo_announcement_server.Announcements()).ShouldNot(ContainElement("after-memory-overdose"))
+				Ω(announcementClient.Announcements()).ShouldNot(ContainElement("after-memory-overdose"))
+			})
+		})
+
+		Context("with a disk limit", func() {
+			const diskLimitMB = 64
+
+			var (
+				fakeMetron        *fake_metron.FakeMetron
+				fakeMetronProcess ifrit.Process
+				logGuid           string
+			)
+
+			BeforeEach(func() {
+				test_helper.CreateZipArchive(
+					filepath.Join(fileServerStaticDir, "disk-filler.zip"),
+					fixtures.DiskFiller(),
+				)
+
+				// the executor forwards the Task's output here, where the
+				// error the disk ran out with can be read back
+				fakeMetron = componentMaker.FakeMetron()
+				fakeMetronProcess = ginkgomon.Invoke(fakeMetron)
+
+				logGuid = helpers.NewGuid("log")
+			})
+
+			AfterEach(func() {
+				helpers.StopProcesses(fakeMetronProcess)
+			})
+
+			fill := func(args ...string) {
+				err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+					Domain:    INIGO_DOMAIN,
+					TaskGuid:  guid,
+					Stack:     componentMaker.Stack,
+					MemoryMB:  1024,
+					DiskMB:    diskLimitMB,
+					LogGuid:   logGuid,
+					LogSource: "TASK",
+					Action: models.Serial(
+						&models.DownloadAction{
+							From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "disk-filler.zip"),
+							To:   ".",
+						},
+						&models.RunAction{
+							Path: "bash",
+							Args: append([]string{"fill.sh"}, args...),
+						},
+					),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			taskOutput := func() []string {
+				lines := []string{}
+				for _, message := range fakeMetron.LogMessages(logGuid) {
+					lines = append(lines, message.Message)
+				}
+
+				return lines
+			}
+
+			It("lets the command write less than the limit", func() {
+				fill(strconv.Itoa(diskLimitMB / 4))
+
+				task := helpers.CompletedTask(receptorClient, guid)
+				Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+				Eventually(taskOutput).Should(ContainElement(ContainSubstring(fmt.Sprintf("Wrote %dMB", diskLimitMB/4))))
+			})
+
+			It("reports what the command wrote as the container's disk usage, and the rootfs on top of it in total", func() {
+				written := uint64(diskLimitMB/4) * 1024 * 1024
+
+				fill(strconv.Itoa(diskLimitMB/4), "hold")
+
+				Eventually(helpers.ContainerDiskUsagePoller(gardenClient, guid, helpers.ExclusiveDiskUsage)).Should(BeNumerically(">=", written))
+				exclusive := helpers.ContainerDiskUsage(gardenClient, guid, helpers.ExclusiveDiskUsage)
+				Ω(exclusive).Should(BeNumerically("<", uint64(diskLimitMB)*1024*1024))
+
+				Ω(helpers.ContainerDiskUsage(gardenClient, guid, helpers.TotalDiskUsage)).Should(BeNumerically(">", exclusive))
+			})
+
+			It("fails the Task once the command writes more than the limit, with the quota's error", func() {
+				fill(strconv.Itoa(2 * diskLimitMB))
+
+				task := helpers.CompletedTask(receptorClient, guid)
+				Ω(task.Failed).Should(BeTrue())
+				Ω(task.FailureReason).Should(ContainSubstring("status 1"))
+
+				Eventually(taskOutput).Should(ContainElement(MatchRegexp(`before running out of disk: .*(Disk quota exceeded|No space left on device)`)))
 			})
 		})
 
@@ -446,12 +592,12 @@ echo should have died by now
 		var guid string
 
 		BeforeEach(func() {
-			guid = factories.GenerateGuid()
+			guid = helpers.NewGuid("task")
 
 			test_helper.CreateTarGZArchive(filepath.Join(fileServerStaticDir, "announce.tar.gz"), []test_helper.ArchiveFile{
 				{
 					Name: "announce",
-					Body: fmt.Sprintf("#!/bin/sh\n\ncurl %s", inigo_announcement_server.AnnounceURL(guid)),
+					Body: fmt.Sprintf("#!/bin/sh\n\ncurl %s", announcementClient.AnnounceURL(guid)),
 					Mode: 0755,
 				},
 			})
@@ -474,7 +620,7 @@ echo should have died by now
 			})
 			Ω(err).ShouldNot(HaveOccurred())
 
-			Eventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
+			Eventually(announcementClient.Announcements).Should(ContainElement(guid))
 		})
 	})
 
@@ -487,7 +633,7 @@ echo should have died by now
 		var gotRequest chan struct{}
 
 		BeforeEach(func() {
-			guid = factories.GenerateGuid()
+			guid = helpers.NewGuid("task")
 
 			gotRequest = make(chan struct{})
 
@@ -524,7 +670,7 @@ echo should have died by now
 					},
 					&models.RunAction{
 						Path: "curl",
-						Args: []string{inigo_announcement_server.AnnounceURL(guid)},
+						Args: []string{announcementClient.AnnounceURL(guid)},
 					},
 				),
 			})
@@ -532,13 +678,13 @@ echo should have died by now
 
 			Eventually(gotRequest).Should(BeClosed())
 
-			Eventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
+			Eventually(announcementClient.Announcements).Should(ContainElement(guid))
 		})
 	})
 
 	Describe("Fetching results", func() {
 		It("should fetch the contents of the requested file and provide the content in the completed Task", func() {
-			guid := factories.GenerateGuid()
+			guid := helpers.NewGuid("task")
 
 			err := receptorClient.CreateTask(receptor.TaskCreateRequest{
 				Domain:     INIGO_DOMAIN,
@@ -564,5 +710,69 @@ echo should have died by now
 
 			Ω(task.Result).Should(Equal("tasty thingy\n"))
 		})
+
+		Context("when the result file is too large", func() {
+			var guid string
+
+			BeforeEach(func() {
+				guid = helpers.NewGuid("task")
+
+				test_helper.CreateZipArchive(
+					filepath.Join(fileServerStaticDir, "result-file-writer.zip"),
+					fixtures.ResultFileWriter(),
+				)
+			})
+
+			createTaskWithResultOfSize := func(size int) {
+				err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+					Domain:     INIGO_DOMAIN,
+					TaskGuid:   guid,
+					Stack:      componentMaker.Stack,
+					ResultFile: "result",
+					Action: models.Serial(
+						&models.DownloadAction{
+							From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "result-file-writer.zip"),
+							To:   ".",
+						},
+						&models.RunAction{
+							Path: "bash",
+							Args: []string{"write-result.sh", "result", strconv.Itoa(size)},
+						},
+					),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			It("fails the Task, saying so", func() {
+				size := world.DefaultMaxResultFileSize + 1
+
+				createTaskWithResultOfSize(size)
+
+				helpers.ExpectTaskToFailWith(receptorClient, guid, helpers.ResultFileTooLargeReason(size, world.DefaultMaxResultFileSize))
+			})
+
+			Context("with a lower limit configured", func() {
+				const limit = 100
+
+				BeforeEach(func() {
+					helpers.StopProcesses(executorProcess)
+					executorProcess = ginkgomon.Invoke(componentMaker.WithMaxResultFileSize(limit).Executor("-memoryMB", "1024"))
+				})
+
+				It("accepts result files right at the limit", func() {
+					createTaskWithResultOfSize(limit)
+
+					task := helpers.CompletedTask(receptorClient, guid)
+					Ω(task.Failed).Should(BeFalse())
+					Ω(task.Result).Should(HaveLen(limit))
+				})
+
+				It("rejects result files over the limit", func() {
+					createTaskWithResultOfSize(limit + 1)
+
+					helpers.ExpectTaskToFailWith(receptorClient, guid, helpers.ResultFileTooLargeReason(limit+1, limit))
+				})
+			})
+		})
 	})
 })
@@ -0,0 +1,103 @@
+package cell_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Downloading from a file-server behind TLS", func() {
+	var (
+		maker        world.ComponentMaker
+		executorArgs []string
+
+		runtime ifrit.Process
+	)
+
+	downloadTask := func(from string, cacheKey string) string {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:     INIGO_DOMAIN,
+			TaskGuid:   taskGuid,
+			Stack:      maker.Stack,
+			ResultFile: "/tmp/download/contents",
+			Action: models.Serial(
+				&models.DownloadAction{
+					From:     from,
+					To:       "/tmp/download",
+					CacheKey: cacheKey,
+				},
+				&models.RunAction{
+					Path: "true",
+				},
+			),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return taskGuid
+	}
+
+	BeforeEach(func() {
+		maker = componentMaker.WithFileServerTLS()
+		executorArgs = []string{}
+	})
+
+	JustBeforeEach(func() {
+		fileServer, fileServerStaticDir := maker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", maker.Executor(executorArgs...)},
+			{"rep", maker.Rep()},
+			{"auctioneer", maker.Auctioneer()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "blob.zip"),
+			[]archive_helper.ArchiveFile{{Name: "contents", Body: "the contents"}},
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	Context("when the executor verifies certificates", func() {
+		It("refuses the file-server's self-signed one", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask(maker.FileServerURL("blob.zip"), ""))
+			Ω(task.Failed).Should(BeTrue())
+		})
+	})
+
+	Context("when the executor skips certificate verification", func() {
+		BeforeEach(func() {
+			helpers.SkipUnlessExecutorSupports(componentMaker, "skipCertVerify")
+
+			executorArgs = []string{"-skipCertVerify"}
+		})
+
+		It("downloads over HTTPS", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask(maker.FileServerURL("blob.zip"), ""))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("the contents"))
+		})
+
+		It("downloads over HTTPS into its cache", func() {
+			task := helpers.CompletedTask(receptorClient, downloadTask(maker.FileServerURL("blob.zip"), "the-cache-key"))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+			Ω(task.Result).Should(Equal("the contents"))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Garden graph cleanup", func() {
+	const (
+		thresholdMB = 4
+		layerBytes  = 2 * 1024 * 1024
+
+		// more than fit under the threshold together
+		imageCount = 4
+	)
+
+	var (
+		maker    world.ComponentMaker
+		registry *helpers.FakeDockerRegistry
+
+		cleaningGarden       ifrit.Process
+		cleaningGardenClient garden.Client
+
+		images []string
+	)
+
+	BeforeEach(func() {
+		cleaningGarden = nil
+
+		if os.Getenv("GARDEN_GRAPH_CLEANUP") != "1" {
+			Skip("graph cleanup is only tested with GARDEN_GRAPH_CLEANUP=1")
+		}
+
+		registry = helpers.NewFakeDockerRegistry("127.0.0.1")
+
+		images = []string{}
+		for i := 0; i < imageCount; i++ {
+			name := fmt.Sprintf("tiny-rootfs-%d", i)
+			registry.AddImage(name, helpers.LayerOf(fixtures.TinyRootFS(name, layerBytes)))
+
+			images = append(images, name)
+		}
+
+		maker = componentMaker.WithGardenGraphCleanup(thresholdMB)
+
+		cleaningGarden = ginkgomon.Invoke(maker.GardenLinux(maker.GardenGraphCleanupFlags(registry.Address)...))
+		cleaningGardenClient = maker.GardenClient()
+	})
+
+	AfterEach(func() {
+		if cleaningGarden == nil {
+			return
+		}
+
+		destroyContainerErrors := helpers.CleanupGarden(cleaningGardenClient)
+
+		helpers.StopProcesses(cleaningGarden)
+		registry.Close()
+
+		Ω(destroyContainerErrors).Should(BeEmpty(), "containers failed to be destroyed")
+	})
+
+	It("deletes the layers that no container uses once the graph outgrows the threshold", func() {
+		By("creating and destroying a container from each image but the last")
+		for _, image := range images[:len(images)-1] {
+			container, err := cleaningGardenClient.Create(garden.ContainerSpec{RootFSPath: registry.RootFSURL(image)})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = cleaningGardenClient.Destroy(container.Handle())
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		By("creating a container from the last one")
+		container, err := cleaningGardenClient.Create(garden.ContainerSpec{RootFSPath: registry.RootFSURL(images[len(images)-1])})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(helpers.DirectorySizePoller(maker.GardenGraphPath)).Should(BeNumerically("<", thresholdMB*1024*1024))
+
+		By("keeping the layer that is still in use")
+		_, err = cleaningGardenClient.Lookup(container.Handle())
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(helpers.DirectorySize(maker.GardenGraphPath)).Should(BeNumerically(">=", layerBytes))
+	})
+})
@@ -0,0 +1,128 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Garden restarts", func() {
+	var (
+		snapshotMaker world.ComponentMaker
+
+		garden  *helpers.RestartableGarden
+		runtime ifrit.Process
+
+		processGuid string
+		runningLRP  receptor.ActualLRPResponse
+	)
+
+	containerHandles := func() []string {
+		return helpers.ContainerHandles(helpers.GardenContainers(snapshotMaker.GardenClient()))
+	}
+
+	BeforeEach(func() {
+		processGuid = helpers.NewGuid("lrp")
+
+		snapshotMaker = componentMaker.WithGardenSnapshots()
+
+		garden = helpers.StartGarden(snapshotMaker,
+			"-denyNetworks=0.0.0.0/0",
+			"-allowHostAccess=true",
+		)
+
+		fileServer, fileServerStaticDir := snapshotMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", snapshotMaker.Executor()},
+			{"rep", snapshotMaker.Rep()},
+			{"auctioneer", snapshotMaker.Auctioneer()},
+			{"converger", snapshotMaker.Converger("-convergeRepeatInterval", "1s")},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   1,
+			Stack:       snapshotMaker.Stack,
+			MemoryMB:    128,
+
+			Ports: []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", snapshotMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		helpers.PollUntilStableState(helpers.LRPStatePoller(receptorClient, processGuid, &runningLRP), Equal(receptor.ActualLRPStateRunning), 3)
+		Ω(containerHandles()).Should(ContainElement(runningLRP.InstanceGuid))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+
+		destroyContainerErrors := helpers.CleanupGarden(snapshotMaker.GardenClient())
+
+		garden.Stop()
+
+		Ω(destroyContainerErrors).Should(BeEmpty(), "containers failed to be destroyed on the snapshotting garden")
+	})
+
+	Context("when garden restores its containers", func() {
+		BeforeEach(func() {
+			garden.Restart()
+		})
+
+		It("keeps the instance running in its original container", func() {
+			Ω(containerHandles()).Should(ContainElement(runningLRP.InstanceGuid))
+
+			var lrp receptor.ActualLRPResponse
+			Consistently(helpers.LRPStatePoller(receptorClient, processGuid, &lrp)).Should(Equal(receptor.ActualLRPStateRunning))
+			Ω(lrp.InstanceGuid).Should(Equal(runningLRP.InstanceGuid))
+		})
+	})
+
+	Context("when garden loses its containers", func() {
+		BeforeEach(func() {
+			garden.RestartLosingContainers()
+		})
+
+		It("starts the instance again in a new container", func() {
+			Ω(containerHandles()).ShouldNot(ContainElement(runningLRP.InstanceGuid))
+
+			helpers.PollUntilStableState(func() bool {
+				var lrp receptor.ActualLRPResponse
+				state := helpers.LRPStatePoller(receptorClient, processGuid, &lrp)()
+
+				return state == receptor.ActualLRPStateRunning && lrp.InstanceGuid != runningLRP.InstanceGuid
+			}, BeTrue(), 3)
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Graceful shutdown", func() {
+	var (
+		runtime   ifrit.Process
+		cell      *helpers.TaskCell
+		otherCell *helpers.TaskCell
+
+		processGuid   string
+		announcements helpers.DrainAnnouncements
+		drainTime     time.Duration
+	)
+
+	BeforeEach(func() {
+		processGuid = helpers.NewGuid("lrp")
+		announcements = helpers.NewDrainAnnouncements(processGuid)
+		drainTime = 2 * time.Second
+		otherCell = nil
+
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"converger", componentMaker.Converger("-convergeRepeatInterval", "1s")},
+		}))
+
+		cell = helpers.StartTaskCell(componentMaker, receptorClient, 0)
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "drain.zip"),
+			fixtures.GracefulShutdownLRP(),
+		)
+	})
+
+	JustBeforeEach(func() {
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   1,
+			Stack:       componentMaker.Stack,
+			MemoryMB:    128,
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "drain.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"drain.sh"},
+				Env:  announcements.Env(announcementClient, drainTime),
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(announcementClient.Announcements).Should(ContainElement(announcements.Started))
+	})
+
+	AfterEach(func() {
+		if otherCell != nil {
+			otherCell.Stop()
+		}
+
+		cell.Stop()
+		helpers.StopProcesses(runtime)
+	})
+
+	Context("when the instance is stopped", func() {
+		JustBeforeEach(func() {
+			helpers.ScaleLRP(receptorClient, processGuid, 0)
+		})
+
+		It("sends it SIGTERM and lets it drain", func() {
+			helpers.ExpectDrainedGracefully(announcementClient, announcements, drainTime)
+		})
+
+		Context("and it drains for longer than the grace period", func() {
+			BeforeEach(func() {
+				drainTime = helpers.StopGracePeriod + 10*time.Second
+			})
+
+			It("kills it once the grace period is up", func() {
+				helpers.ExpectKilledWhileDraining(announcementClient, announcements, drainTime)
+			})
+		})
+	})
+
+	Context("when the instance's cell evacuates", func() {
+		JustBeforeEach(func() {
+			otherCell = helpers.StartTaskCell(componentMaker, receptorClient, 1)
+			cell.Fail(helpers.CellEvacuation)
+		})
+
+		It("sends it SIGTERM and lets it drain once it is running elsewhere", func() {
+			helpers.ExpectDrainedGracefully(announcementClient, announcements, drainTime)
+
+			Eventually(helpers.PlacementPoller(receptorClient, processGuid)).Should(Equal(map[string]int{otherCell.ID: 1}))
+		})
+	})
+})
@@ -0,0 +1,110 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRP instance environment", func() {
+	var (
+		processGuid string
+
+		runtime ifrit.Process
+
+		env helpers.InstanceEnv
+	)
+
+	BeforeEach(func() {
+		processGuid = helpers.NewGuid("lrp")
+
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", componentMaker.Router()},
+			{"file-server", fileServer},
+			{"exec", componentMaker.Executor("-exportNetworkEnvVars=true")},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"route-emitter", componentMaker.RouteEmitter()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.InstanceEnvLRP(),
+		)
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   1,
+			Stack:       componentMaker.Stack,
+
+			Routes: cfroutes.CFRoutes{{Port: 8080, Hostnames: []string{"env-route"}}}.RoutingInfo(),
+			Ports:  []uint16{8080, 9090},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env: []models.EnvironmentVariable{
+					{"PORT", "8080"},
+					{"VCAP_APPLICATION", `{"application_name":"some-app","instance_index":0}`},
+				},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			var err error
+			env, err = helpers.FetchInstanceEnv(componentMaker.Addresses.Router, "env-route")
+			return err
+		}).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	It("describes where the instance can be reached", func() {
+		lrp, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, 0)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		hostPorts := map[uint16]uint16{}
+		for _, port := range lrp.Ports {
+			hostPorts[uint16(port.ContainerPort)] = uint16(port.HostPort)
+		}
+
+		Ω(env.CFInstanceIP).Should(Equal(lrp.Address))
+		Ω(env.CFInstancePort).Should(Equal(strconv.Itoa(int(hostPorts[8080]))))
+		Ω(env.CFInstanceAddr).Should(Equal(fmt.Sprintf("%s:%d", lrp.Address, hostPorts[8080])))
+
+		Ω(env.CFInstancePorts).Should(ConsistOf(
+			helpers.InstancePortMapping{External: hostPorts[8080], Internal: 8080},
+			helpers.InstancePortMapping{External: hostPorts[9090], Internal: 9090},
+		))
+	})
+
+	It("passes VCAP_APPLICATION through", func() {
+		Ω(env.VCAPApplication).Should(HaveKeyWithValue("application_name", "some-app"))
+		Ω(env.VCAPApplication).Should(HaveKeyWithValue("instance_index", BeNumerically("==", 0)))
+	})
+})
@@ -0,0 +1,128 @@
+package cell_test
+
+import (
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Isolation", func() {
+	var runtime ifrit.Process
+
+	probeTask := func(privileged bool) helpers.IsolationReport {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:     INIGO_DOMAIN,
+			TaskGuid:   taskGuid,
+			Stack:      componentMaker.Stack,
+			Privileged: privileged,
+			ResultFile: "/tmp/isolation",
+			Action: &models.RunAction{
+				Path: "bash",
+				// always run as root; the container is what is (un)privileged
+				Privileged: true,
+				Args:       []string{"-c", helpers.IsolationProbe() + " > /tmp/isolation"},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		task := helpers.CompletedTask(receptorClient, taskGuid)
+		Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+		report, err := helpers.ParseIsolationReport(task.Result)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return report
+	}
+
+	probeLRP := func(privileged bool) helpers.IsolationReport {
+		processGuid := helpers.NewGuid("lrp")
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   1,
+			Stack:       componentMaker.Stack,
+			Privileged:  privileged,
+
+			Routes: cfroutes.CFRoutes{{Hostnames: []string{processGuid}, Port: 8080}}.RoutingInfo(),
+			Ports:  []uint16{8080},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				// always run as root; the container is what is (un)privileged
+				Privileged: true,
+				Args:       []string{"-c", helpers.IsolationProbeServer(8080)},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var report helpers.IsolationReport
+		Eventually(func() error {
+			var err error
+			report, err = helpers.FetchIsolationReport(componentMaker.Addresses.Router, processGuid)
+			return err
+		}).ShouldNot(HaveOccurred())
+
+		return report
+	}
+
+	BeforeEach(func() {
+		maker := componentMaker.WithPrivilegedContainers()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"exec", maker.Executor()},
+			{"rep", maker.Rep()},
+			{"auctioneer", maker.Auctioneer()},
+
+			{"router", maker.Router()},
+			{"route-emitter", maker.RouteEmitter()},
+		}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	Describe("an unprivileged LRP", func() {
+		It("cannot write to /proc/sys or see the host's processes", func() {
+			report := probeLRP(false)
+			Ω(report.ProcSysWritable).Should(BeFalse())
+			Ω(report.HostPIDsVisible).Should(BeFalse())
+		})
+	})
+
+	Describe("an unprivileged Task", func() {
+		It("cannot write to /proc/sys or see the host's processes", func() {
+			report := probeTask(false)
+			Ω(report.ProcSysWritable).Should(BeFalse())
+			Ω(report.HostPIDsVisible).Should(BeFalse())
+		})
+	})
+
+	Describe("a privileged Task", func() {
+		It("can write to /proc/sys, but still cannot see the host's processes", func() {
+			report := probeTask(true)
+			Ω(report.ProcSysWritable).Should(BeTrue())
+			Ω(report.HostPIDsVisible).Should(BeFalse())
+		})
+	})
+
+	Describe("a privileged LRP", func() {
+		It("can write to /proc/sys, but still cannot see the host's processes", func() {
+			report := probeLRP(true)
+			Ω(report.ProcSysWritable).Should(BeTrue())
+			Ω(report.HostPIDsVisible).Should(BeFalse())
+		})
+	})
+})
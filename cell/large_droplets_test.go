@@ -0,0 +1,184 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Large droplets", func() {
+	const dropletMB = 64
+
+	var (
+		dropletBytes = fixtures.LargeDropletBytes(dropletMB)
+
+		// far less than the droplet, so that holding all of it at once
+		// shows up, but far more than any sensible copy buffer
+		streamingBudget = dropletBytes / 4
+	)
+
+	var runtime ifrit.Process
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	Describe("uploading one through cc-uploader", func() {
+		var (
+			fakeCC     *fake_cc.FakeCC
+			ccUploader *world.TimedRunner
+
+			appGuid string
+		)
+
+		uploadTask := func() string {
+			taskGuid := helpers.NewGuid("task")
+
+			err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+				Domain:   INIGO_DOMAIN,
+				TaskGuid: taskGuid,
+				Stack:    componentMaker.Stack,
+				Action: models.Serial(
+					&models.RunAction{
+						Path: "sh",
+						Args: []string{"-c", fixtures.LargeDropletScript("/tmp/droplet", dropletMB)},
+					},
+					&models.UploadAction{
+						From: "/tmp/droplet",
+						To: helpers.CCUploaderDropletURL(
+							componentMaker.Addresses.CCUploader,
+							appGuid,
+							fakeCC.DropletUploadURI(appGuid),
+						),
+					},
+				),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			return taskGuid
+		}
+
+		BeforeEach(func() {
+			appGuid = helpers.NewGuid("app")
+
+			fakeCC = componentMaker.FakeCC()
+			ccUploader = componentMaker.CCUploader()
+
+			runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+				{"cc", fakeCC},
+				{"cc-uploader", ccUploader},
+				{"exec", componentMaker.Executor()},
+				{"rep", componentMaker.Rep()},
+				{"auctioneer", componentMaker.Auctioneer()},
+			}))
+		})
+
+		It("relays all of it to CC without holding it all in memory", func() {
+			peakBefore := helpers.PeakResidentBytes(ccUploader)
+
+			task := helpers.CompletedTask(receptorClient, uploadTask())
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+			digest, found := fakeCC.UploadedDropletDigest(appGuid)
+			Ω(found).Should(BeTrue())
+			Ω(digest).Should(Equal(fake_cc.UploadDigest{
+				Size:   dropletBytes,
+				SHA256: fixtures.LargeDropletDigest(dropletMB),
+
+				Truncated: true,
+			}))
+
+			Ω(helpers.PeakResidentBytes(ccUploader) - peakBefore).Should(BeNumerically("<", streamingBudget))
+		})
+
+		Context("when CC refuses droplets that big", func() {
+			BeforeEach(func() {
+				fakeCC.SetMaxDropletUploadBytes(dropletBytes / 2)
+			})
+
+			It("fails the task", func() {
+				task := helpers.CompletedTask(receptorClient, uploadTask())
+				Ω(task.Failed).Should(BeTrue())
+
+				Ω(fakeCC.WasUploaded(appGuid)).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("downloading one from the file-server", func() {
+		var fileServer *world.TimedRunner
+
+		downloadTask := func(diskMB int) string {
+			taskGuid := helpers.NewGuid("task")
+
+			dropletPath := "/tmp/download/" + fixtures.LargeDropletName
+
+			err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+				Domain:   INIGO_DOMAIN,
+				TaskGuid: taskGuid,
+				Stack:    componentMaker.Stack,
+				DiskMB:   diskMB,
+				Action: models.Serial(
+					&models.DownloadAction{
+						From: componentMaker.FileServerURL("large-droplet.tgz"),
+						To:   "/tmp/download",
+					},
+					&models.RunAction{
+						Path: "sh",
+						Args: []string{"-c", fmt.Sprintf(
+							`test $(stat -c %%s %s) -eq %d && test "$(head -c %d %s)" = %s`,
+							dropletPath, dropletBytes,
+							len(fixtures.LargeDropletMarker), dropletPath, fixtures.LargeDropletMarker,
+						)},
+					},
+				),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			return taskGuid
+		}
+
+		BeforeEach(func() {
+			fileServerRunner, fileServerStaticDir := componentMaker.FileServer()
+			fileServer = fileServerRunner.(*world.TimedRunner)
+
+			runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+				{"file-server", fileServer},
+				{"exec", componentMaker.Executor()},
+				{"rep", componentMaker.Rep()},
+				{"auctioneer", componentMaker.Auctioneer()},
+			}))
+
+			fixtures.WriteLargeArchive(filepath.Join(fileServerStaticDir, "large-droplet.tgz"), dropletMB)
+		})
+
+		It("serves all of it without holding it all in memory", func() {
+			peakBefore := helpers.PeakResidentBytes(fileServer)
+
+			task := helpers.CompletedTask(receptorClient, downloadTask(4*dropletMB))
+			Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+
+			Ω(helpers.PeakResidentBytes(fileServer) - peakBefore).Should(BeNumerically("<", streamingBudget))
+		})
+
+		Context("when it does not fit in the container's disk", func() {
+			It("fails the task", func() {
+				task := helpers.CompletedTask(receptorClient, downloadTask(dropletMB/4))
+				Ω(task.Failed).Should(BeTrue())
+			})
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_metron"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log forwarding", func() {
+	const noisyLines = 50
+
+	var (
+		maker      world.ComponentMaker
+		fakeMetron *fake_metron.FakeMetron
+
+		runtime ifrit.Process
+
+		logGuid string
+	)
+
+	BeforeEach(func() {
+		maker = componentMaker
+		logGuid = helpers.NewGuid("log")
+	})
+
+	JustBeforeEach(func() {
+		fileServer, fileServerStaticDir := maker.FileServer()
+		fakeMetron = maker.FakeMetron()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"metron", fakeMetron},
+			{"file-server", fileServer},
+			{"exec", maker.Executor("-memoryMB", "1024")},
+			{"rep", maker.Rep()},
+			{"auctioneer", maker.Auctioneer()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "noisy.zip"),
+			fixtures.NoisyLogger(),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	logNoisily := func(lines int) {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:    INIGO_DOMAIN,
+			TaskGuid:  taskGuid,
+			Stack:     maker.Stack,
+			MemoryMB:  128,
+			LogGuid:   logGuid,
+			LogSource: "APP",
+			Action: models.Serial(
+				&models.DownloadAction{
+					From: fmt.Sprintf("http://%s/v1/static/%s", maker.Addresses.FileServer, "noisy.zip"),
+					To:   ".",
+				},
+				&models.RunAction{
+					Path: "bash",
+					Args: []string{"noisy.sh", strconv.Itoa(lines)},
+				},
+			),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		task := helpers.CompletedTask(receptorClient, taskGuid)
+		Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+	}
+
+	appLinesPoller := func() func() []string {
+		return func() []string {
+			lines := []string{}
+			for _, message := range fakeMetron.LogMessages(logGuid) {
+				if strings.HasPrefix(message.Message, "noisy line") {
+					lines = append(lines, message.Message)
+				}
+			}
+
+			return lines
+		}
+	}
+
+	It("forwards every line a Task logs", func() {
+		logNoisily(noisyLines)
+
+		Eventually(appLinesPoller()).Should(HaveLen(noisyLines))
+	})
+})
@@ -0,0 +1,115 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRP start timeout", func() {
+	var (
+		processGuid  string
+		startupDelay time.Duration
+		startTimeout time.Duration
+
+		runtime ifrit.Process
+	)
+
+	BeforeEach(func() {
+		processGuid = helpers.NewGuid("lrp")
+
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", componentMaker.Executor()},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.SlowStartingLRP(),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	JustBeforeEach(func() {
+		env := []models.EnvironmentVariable{
+			{"PORT", "8080"},
+			{"STARTUP_DELAY_SECONDS", strconv.Itoa(int(startupDelay.Seconds()))},
+		}
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:       INIGO_DOMAIN,
+			ProcessGuid:  processGuid,
+			Instances:    1,
+			Stack:        componentMaker.Stack,
+			StartTimeout: uint(startTimeout.Seconds()),
+
+			Ports: []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  env,
+			},
+
+			Monitor: &models.RunAction{
+				Path: "bash",
+				Args: []string{"healthcheck.sh"},
+				Env:  env,
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Context("when the app starts within its start timeout", func() {
+		BeforeEach(func() {
+			startupDelay = 10 * time.Second
+			startTimeout = time.Minute
+		})
+
+		It("stays starting until its healthcheck passes, then runs", func() {
+			startedAt := time.Now()
+
+			helpers.ExpectLRPToStayStarting(receptorClient, processGuid, startupDelay/2)
+
+			Ω(helpers.WaitForLRPToRun(receptorClient, processGuid, startedAt)).Should(BeNumerically(">=", startupDelay))
+			Ω(helpers.CrashCountPoller(receptorClient, processGuid, 0)()).Should(BeZero())
+		})
+	})
+
+	Context("when the app takes longer than its start timeout", func() {
+		BeforeEach(func() {
+			startupDelay = 5 * time.Minute
+			startTimeout = 5 * time.Second
+		})
+
+		It("crashes it once the start timeout expires", func() {
+			helpers.ExpectLRPToCrashAfterStartTimeout(receptorClient, processGuid, startTimeout)
+		})
+	})
+})
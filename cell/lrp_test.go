@@ -10,11 +10,12 @@ import (
 
 	"github.com/cloudfoundry-incubator/inigo/fixtures"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/helpers/grpcecho"
+	"github.com/cloudfoundry-incubator/inigo/world"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
 	"github.com/cloudfoundry-incubator/runtime-schema/diego_errors"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
 	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
@@ -36,7 +37,7 @@ var _ = Describe("LRP", func() {
 	)
 
 	BeforeEach(func() {
-		processGuid = factories.GenerateGuid()
+		processGuid = helpers.NewGuid("lrp")
 
 		var fileServer ifrit.Runner
 		fileServer, fileServerStaticDir = componentMaker.FileServer()
@@ -108,6 +109,151 @@ var _ = Describe("LRP", func() {
 			}).Should(HaveLen(1))
 
 			Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses.Router, "lrp-route")).Should(ConsistOf([]string{"0"}))
+			Ω(helpers.RouterRoutes(componentMaker.Addresses.RouterStatus)).Should(HaveKeyWithValue("lrp-route", HaveLen(1)))
+		})
+
+		It("can be traced through the output of the components the spec ran, by its spec id", func() {
+			helpers.WaitForLRPInstanceState(receptorClient, processGuid, 0, receptor.ActualLRPStateRunning)
+
+			specID := componentMaker.Timings.SpecID()
+
+			lines := helpers.ComponentLinesMentioning(componentMaker, specID)
+			Ω(lines).Should(HaveKey("rep"))
+			Ω(lines).Should(HaveKey("executor"))
+
+			for _, runner := range componentMaker.Timings.SpecComponents() {
+				if runner.Name == "rep" || runner.Name == "executor" {
+					Ω(helpers.ProcessEnv(runner)).Should(ContainElement(world.SpecIDEnv + "=" + specID))
+				}
+			}
+		})
+
+		Context("when subscribed to the receptor's event stream", func() {
+			var receptorEvents *helpers.ReceptorEventCollector
+
+			BeforeEach(func() {
+				receptorEvents = helpers.ReceptorEvents(receptorClient)
+			})
+
+			AfterEach(func() {
+				receptorEvents.Stop()
+			})
+
+			It("emits events for the desired and actual LRP", func() {
+				Eventually(receptorEvents.EventTypesForPoller(processGuid)).Should(ContainElement(receptor.EventTypeDesiredLRPCreated))
+				Eventually(receptorEvents.EventTypesForPoller(processGuid)).Should(ContainElement(receptor.EventTypeActualLRPCreated))
+				Eventually(receptorEvents.EventTypesForPoller(processGuid)).Should(ContainElement(receptor.EventTypeActualLRPChanged))
+			})
+
+			Context("and the LRP is deleted", func() {
+				It("emits removal events", func() {
+					helpers.WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
+
+					err := receptorClient.DeleteDesiredLRP(processGuid)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(receptorEvents.EventTypesForPoller(processGuid)).Should(ContainElement(receptor.EventTypeDesiredLRPRemoved))
+					Eventually(receptorEvents.EventTypesForPoller(processGuid)).Should(ContainElement(receptor.EventTypeActualLRPRemoved))
+				})
+			})
+		})
+
+		Context("when the app speaks WebSocket", func() {
+			BeforeEach(func() {
+				archiveFiles = fixtures.WebsocketEchoLRP(componentMaker.Artifacts.Fixtures["websocket-echo"])
+
+				lrp.Action = &models.RunAction{
+					Path: "sh",
+					Args: []string{"-c", "chmod +x websocket-echo && exec ./websocket-echo"},
+					Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+				}
+			})
+
+			It("upgrades the connection through the router and keeps it open", func() {
+				var ws *helpers.WebsocketConn
+				Eventually(func() error {
+					var err error
+					ws, err = helpers.WebsocketDial(componentMaker.Addresses.Router, "lrp-route")
+					return err
+				}).ShouldNot(HaveOccurred())
+
+				defer ws.Close()
+
+				for _, message := range []string{"hello", "over", "websocket"} {
+					err := ws.Send(message)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Ω(ws.Receive()).Should(Equal(message))
+
+					time.Sleep(time.Second)
+				}
+			})
+		})
+
+		Context("when the app speaks gRPC", func() {
+			BeforeEach(func() {
+				archiveFiles = fixtures.GRPCEchoLRP(componentMaker.Artifacts.Fixtures["grpc-echo"])
+
+				lrp.Action = &models.RunAction{
+					Path: "sh",
+					Args: []string{"-c", "chmod +x grpc-echo && exec ./grpc-echo"},
+					Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+				}
+			})
+
+			It("serves HTTP/2 cleartext on the instance's port mapping", func() {
+				instance := helpers.WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
+				addr := helpers.MappedAddress(instance, 8080)
+
+				Eventually(func() error {
+					return helpers.SpeaksHTTP2(addr)
+				}).ShouldNot(HaveOccurred())
+
+				Ω(grpcecho.Echo(addr, "hello")).Should(Equal("hello"))
+			})
+
+			Context("when the router passes HTTP/2 through", func() {
+				BeforeEach(func() {
+					if os.Getenv("ROUTER_HTTP2") != "1" {
+						Skip("routing gRPC is only tested with ROUTER_HTTP2=1")
+					}
+				})
+
+				It("echoes through the router", func() {
+					Eventually(func() (string, error) {
+						return grpcecho.EchoThroughRouter(componentMaker.Addresses.Router, "lrp-route", "hello")
+					}).Should(Equal("hello"))
+				})
+			})
+		})
+
+		Context("when watching route registrations over NATS", func() {
+			var routeRegistrations *helpers.RouteRegistrationCollector
+
+			BeforeEach(func() {
+				routeRegistrations = helpers.RouteRegistrations(natsClient)
+			})
+
+			AfterEach(func() {
+				routeRegistrations.Stop()
+			})
+
+			It("registers the route with the instance's endpoint", func() {
+				Eventually(routeRegistrations.EndpointsForRoutePoller("lrp-route")).Should(HaveLen(1))
+				Ω(routeRegistrations.Registrations()[0].URIs).Should(ContainElement("lrp-route"))
+			})
+
+			Context("and the LRP is deleted", func() {
+				It("unregisters the route", func() {
+					Eventually(routeRegistrations.EndpointsForRoutePoller("lrp-route")).Should(HaveLen(1))
+
+					err := receptorClient.DeleteDesiredLRP(processGuid)
+					Ω(err).ShouldNot(HaveOccurred())
+
+					Eventually(routeRegistrations.Unregistrations).ShouldNot(BeEmpty())
+					Eventually(routeRegistrations.EndpointsForRoutePoller("lrp-route")).Should(BeEmpty())
+				})
+			})
 		})
 
 		Context("when it's unhealthy for longer than its start timeout", func() {
@@ -184,6 +330,12 @@ var _ = Describe("LRP", func() {
 					Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses.Router, "lrp-route-9080")).Should(Equal(http.StatusOK))
 					Consistently(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses.Router, "lrp-route-9080")).Should(Equal(http.StatusOK))
 				})
+
+				It("routes each hostname to the server listening on its port", func() {
+					Eventually(helpers.ContainerPortFromHostPoller(componentMaker.Addresses.Router, "lrp-route-8080")).Should(Equal("8080"))
+					Eventually(helpers.ContainerPortFromHostPoller(componentMaker.Addresses.Router, "lrp-route-9080")).Should(Equal("9080"))
+					Consistently(helpers.ContainerPortFromHostPoller(componentMaker.Addresses.Router, "lrp-route-8080")).Should(Equal("8080"))
+				})
 			})
 		})
 
@@ -266,18 +418,7 @@ var _ = Describe("LRP", func() {
 					})
 
 					It("can be scaled back up", func() {
-						newInstances := 1
-						err := receptorClient.UpdateDesiredLRP(processGuid, receptor.DesiredLRPUpdateRequest{
-							Instances: &newInstances,
-						})
-						Ω(err).ShouldNot(HaveOccurred())
-
-						Eventually(func() []receptor.ActualLRPResponse {
-							lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
-							Ω(err).ShouldNot(HaveOccurred())
-
-							return lrps
-						}).Should(HaveLen(1))
+						helpers.ScaleLRP(receptorClient, processGuid, 1)
 
 						Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses.Router, "lrp-route")).Should(ConsistOf([]string{"0"}))
 					})
@@ -300,6 +441,10 @@ var _ = Describe("LRP", func() {
 
 					Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses.Router, "lrp-route")).Should(BeEmpty())
 				})
+
+				It("prunes the route from the router's route table", func() {
+					Eventually(helpers.RouterRoutesPoller(componentMaker.Addresses.RouterStatus)).ShouldNot(HaveKey("lrp-route"))
+				})
 			})
 		})
 
@@ -383,14 +528,16 @@ var _ = Describe("LRP", func() {
 
 var _ = Describe("Crashing LRPs", func() {
 	var (
-		processGuid string
-		runtime     ifrit.Process
+		processGuid         string
+		fileServerStaticDir string
+		runtime             ifrit.Process
 	)
 
 	BeforeEach(func() {
-		fileServer, _ := componentMaker.FileServer()
+		var fileServer ifrit.Runner
+		fileServer, fileServerStaticDir = componentMaker.FileServer()
 
-		processGuid = factories.GenerateGuid()
+		processGuid = helpers.NewGuid("lrp")
 
 		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
 			{"router", componentMaker.Router()},
@@ -430,11 +577,7 @@ var _ = Describe("Crashing LRPs", func() {
 			})
 
 			It("imediately restarts the app 3 times", func() {
-				crashCount := func() int {
-					actual, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, 0)
-					Ω(err).ShouldNot(HaveOccurred())
-					return actual.CrashCount
-				}
+				crashCount := helpers.CrashCountPoller(receptorClient, processGuid, 0)
 				// the receptor immediately starts it 3 times
 				Eventually(crashCount).Should(Equal(3))
 				// then exponential backoff kicks in
@@ -443,5 +586,45 @@ var _ = Describe("Crashing LRPs", func() {
 				Eventually(crashCount, 30*time.Second).Should(Equal(4))
 			})
 		})
+
+		Context("when an app crashes after serving requests", func() {
+			BeforeEach(func() {
+				archive_helper.CreateZipArchive(
+					filepath.Join(fileServerStaticDir, "crashing-lrp.zip"),
+					fixtures.CrashingLRP(1),
+				)
+
+				lrp := receptor.DesiredLRPCreateRequest{
+					Domain:      INIGO_DOMAIN,
+					ProcessGuid: processGuid,
+					Instances:   1,
+					Stack:       componentMaker.Stack,
+
+					Routes: cfroutes.CFRoutes{{Port: 8080, Hostnames: []string{"crashing-route"}}}.RoutingInfo(),
+					Ports:  []uint16{8080},
+
+					Setup: &models.DownloadAction{
+						From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "crashing-lrp.zip"),
+						To:   ".",
+					},
+
+					Action: &models.RunAction{
+						Path: "bash",
+						Args: []string{"server.sh"},
+						Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+					},
+				}
+
+				err := receptorClient.CreateDesiredLRP(lrp)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("restarts the app once it exits", func() {
+				Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses.Router, "crashing-route")).Should(Equal(http.StatusOK))
+
+				Eventually(helpers.CrashCountPoller(receptorClient, processGuid, 0)).Should(BeNumerically(">=", 1))
+				helpers.WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
+			})
+		})
 	})
 })
@@ -0,0 +1,80 @@
+package cell_test
+
+import (
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/runtime-schema/diego_errors"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Max containers per cell", func() {
+	const (
+		cellCount        = 2
+		cellMemoryMB     = 1024
+		maxContainers    = 1
+		instanceMemoryMB = 128
+
+		// one more than the cells have containers for, but few enough that
+		// the first cell has the memory for every one of them
+		instances = cellCount*maxContainers + 1
+	)
+
+	var (
+		maker world.ComponentMaker
+
+		runtime ifrit.Process
+		cells   *helpers.Cells
+
+		processGuid string
+	)
+
+	BeforeEach(func() {
+		// packing instances onto the first cell with room would put them all
+		// there, and spreading them would put two on one cell; only the max
+		// leaves one on each
+		maker = componentMaker.
+			WithGardenCapacity(helpers.PinnedGardenCapacity).
+			WithMaxContainers(maxContainers).
+			WithAuctionWeights(1, 0)
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"fake-garden-capacity", maker.FakeGardenCapacity()},
+			{"auctioneer", maker.Auctioneer()},
+		}))
+
+		cells = helpers.StartCells(maker, receptorClient, cellCount, cellMemoryMB)
+
+		processGuid = helpers.NewGuid("lrp")
+	})
+
+	AfterEach(func() {
+		cells.Stop()
+		helpers.StopProcesses(runtime)
+	})
+
+	JustBeforeEach(func() {
+		helpers.DesireIdleLRP(receptorClient, maker, INIGO_DOMAIN, processGuid, instances, instanceMemoryMB)
+
+		helpers.PollUntilStableState(helpers.RunningLRPCountPoller(receptorClient, processGuid), Equal(cellCount*maxContainers), 5)
+	})
+
+	It("places instances on another cell once one has all the containers it can hold", func() {
+		placement := helpers.PlacementPoller(receptorClient, processGuid)()
+
+		Ω(placement).Should(HaveLen(cellCount))
+		for cellID, count := range placement {
+			Ω(count).Should(Equal(maxContainers), "cell %s got %d instances", cellID, count)
+		}
+	})
+
+	It("marks the instance that no cell has a container for as unplaceable", func() {
+		Eventually(helpers.PlacementErrorsPoller(receptorClient, processGuid)).Should(ConsistOf(diego_errors.INSUFFICIENT_RESOURCES_MESSAGE))
+	})
+})
@@ -8,7 +8,6 @@ import (
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/tedsuo/ifrit"
@@ -21,7 +20,7 @@ var _ = Describe("Privileges", func() {
 
 	BeforeEach(func() {
 		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
-			{"exec", componentMaker.Executor("-allowPrivileged")},
+			{"exec", componentMaker.WithPrivilegedContainers().Executor()},
 			{"rep", componentMaker.Rep()},
 			{"converger", componentMaker.Converger()},
 			{"auctioneer", componentMaker.Auctioneer()},
@@ -41,7 +40,7 @@ var _ = Describe("Privileges", func() {
 		BeforeEach(func() {
 			taskRequest = &receptor.TaskCreateRequest{
 				Domain:   INIGO_DOMAIN,
-				TaskGuid: factories.GenerateGuid(),
+				TaskGuid: helpers.NewGuid("task"),
 				Stack:    componentMaker.Stack,
 				Action: &models.RunAction{
 					Path: "sh",
@@ -97,7 +96,7 @@ var _ = Describe("Privileges", func() {
 
 			lrpRequest = &receptor.DesiredLRPCreateRequest{
 				Domain:      INIGO_DOMAIN,
-				ProcessGuid: factories.GenerateGuid(),
+				ProcessGuid: helpers.NewGuid("lrp"),
 				Instances:   1,
 				Stack:       componentMaker.Stack,
 
@@ -0,0 +1,61 @@
+package cell_test
+
+import (
+	"github.com/cloudfoundry-incubator/inigo/chaos"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rep presence", func() {
+	var (
+		executor ifrit.Process
+		rep      ifrit.Process
+	)
+
+	BeforeEach(func() {
+		executor = ginkgomon.Invoke(componentMaker.Executor())
+
+		// on its own, so that pausing it does not take the executor along
+		rep = ginkgomon.Invoke(helpers.RepWithShortPresence(componentMaker))
+
+		helpers.WaitForCellRegistration(receptorClient, componentMaker.CellID())
+	})
+
+	AfterEach(func() {
+		// a paused rep would never see the SIGTERM
+		chaos.ResumeProcess(rep)
+
+		helpers.StopProcesses(rep, executor)
+	})
+
+	Context("when the rep is paused across heartbeats, but for less than its presence lasts", func() {
+		It("keeps the cell's presence", func() {
+			chaos.PauseProcess(rep)
+
+			// long enough to miss a couple of heartbeats, so that only the
+			// presence outlasting them keeps the cell
+			helpers.ExpectCellPresenceKept(receptorClient, componentMaker.CellID(), 3*helpers.ShortRepHeartbeatInterval)
+
+			chaos.ResumeProcess(rep)
+			helpers.ExpectCellPresenceKept(receptorClient, componentMaker.CellID(), 10*helpers.ShortRepHeartbeatInterval)
+		})
+	})
+
+	Context("when the rep is paused for longer than its presence lasts", func() {
+		It("lets the cell's presence expire, and registers it again once resumed", func() {
+			chaos.PauseProcess(rep)
+
+			expiredAfter := helpers.WaitForCellPresenceToExpire(receptorClient, componentMaker.CellID())
+			Ω(expiredAfter).Should(BeNumerically("<", helpers.Timeouts.Short))
+
+			chaos.ResumeProcess(rep)
+
+			helpers.WaitForCellRegistration(receptorClient, componentMaker.CellID())
+			Consistently(rep.Wait()).ShouldNot(Receive(), "the rep gave up after losing its presence")
+		})
+	})
+})
@@ -0,0 +1,118 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = helpers.DescribeOnEachRootFS("Rootfs compatibility", &componentMaker, func(maker *world.ComponentMaker) {
+	var (
+		fileServerStaticDir string
+
+		runtime ifrit.Process
+	)
+
+	BeforeEach(func() {
+		var fileServer ifrit.Runner
+		fileServer, fileServerStaticDir = maker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", maker.Executor()},
+			{"rep", maker.Rep()},
+			{"auctioneer", maker.Auctioneer()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+
+		helpers.Copy(
+			maker.Artifacts.Lifecycles[maker.Stack],
+			filepath.Join(fileServerStaticDir, world.LifecycleFilename),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	It("extracts downloaded archives into the container", func() {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:     INIGO_DOMAIN,
+			TaskGuid:   taskGuid,
+			Stack:      maker.Stack,
+			ResultFile: "/tmp/result",
+			Action: models.Serial(
+				&models.DownloadAction{
+					From: fmt.Sprintf("http://%s/v1/static/%s", maker.Addresses.FileServer, "lrp.zip"),
+					To:   "/tmp/app",
+				},
+				&models.RunAction{
+					Path: "sh",
+					Args: []string{"-c", "ls /tmp/app > /tmp/result"},
+				},
+			),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		task := helpers.CompletedTask(receptorClient, taskGuid)
+		Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+		Ω(task.Result).Should(ContainSubstring("server.sh"))
+	})
+
+	It("runs the lifecycle's healthcheck", func() {
+		processGuid := helpers.NewGuid("lrp")
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   1,
+			Stack:       maker.Stack,
+
+			Ports: []uint16{8080},
+
+			Setup: models.Serial(
+				&models.DownloadAction{
+					From: fmt.Sprintf("http://%s/v1/static/%s", maker.Addresses.FileServer, "lrp.zip"),
+					To:   ".",
+				},
+				&models.DownloadAction{
+					From: fmt.Sprintf("http://%s/v1/static/%s", maker.Addresses.FileServer, world.LifecycleFilename),
+					To:   "/tmp/lifecycle",
+				},
+			),
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+
+			Monitor: &models.RunAction{
+				Path: "/tmp/lifecycle/healthcheck",
+				Args: []string{"-port=8080"},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		helpers.WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
+	})
+})
@@ -0,0 +1,171 @@
+package cell_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/chaos"
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Route emitter syncing", func() {
+	var (
+		syncInterval time.Duration
+		routingMaker world.ComponentMaker
+
+		nats    ifrit.Process
+		runtime ifrit.Process
+		routing ifrit.Process
+
+		processGuid string
+	)
+
+	desireLRP := func(hostname string) {
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: processGuid,
+			Instances:   1,
+			Stack:       routingMaker.Stack,
+
+			Routes: cfroutes.CFRoutes{{Port: 8080, Hostnames: []string{hostname}}}.RoutingInfo(),
+			Ports:  []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", routingMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		helpers.WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
+	}
+
+	// adds a route while NATS is down, so that the route emitter's event for
+	// it is lost
+	addRouteDuringNATSOutage := func() {
+		nats = chaos.NATSOutage(nats, func() ifrit.Runner { return routingMaker.NATS() }, func() {
+			helpers.UpdateDesiredRoutes(receptorClient, processGuid, cfroutes.CFRoutes{{Port: 8080, Hostnames: []string{"synced-route", "route-added-during-outage"}}})
+		})
+	}
+
+	BeforeEach(func() {
+		processGuid = helpers.NewGuid("lrp")
+	})
+
+	JustBeforeEach(func() {
+		routingMaker = componentMaker.WithSeparateNATS(500).WithRouteEmitterSyncInterval(syncInterval)
+
+		nats = ginkgomon.Invoke(routingMaker.NATS())
+
+		fileServer, fileServerStaticDir := routingMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServer},
+			{"exec", routingMaker.Executor()},
+			{"rep", routingMaker.Rep()},
+			{"auctioneer", routingMaker.Auctioneer()},
+		}))
+
+		routing = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", routingMaker.Router()},
+			{"route-emitter", routingMaker.RouteEmitter()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(routing, runtime, nats)
+	})
+
+	Context("when bulk syncs are too far apart to matter", func() {
+		BeforeEach(func() {
+			syncInterval = time.Hour
+		})
+
+		It("registers routes from events alone", func() {
+			desireLRP("event-route")
+
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "event-route"), helpers.Timeouts.Short).Should(Equal(http.StatusOK))
+		})
+
+		It("registers updated routes from events alone", func() {
+			desireLRP("event-route")
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "event-route"), helpers.Timeouts.Short).Should(Equal(http.StatusOK))
+
+			helpers.UpdateRoutes(receptorClient, routingMaker.Addresses.RouterStatus, processGuid, cfroutes.CFRoutes{{Port: 8080, Hostnames: []string{"event-route", "updated-event-route"}}})
+
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "updated-event-route"), helpers.Timeouts.Short).Should(Equal(http.StatusOK))
+		})
+
+		It("does not repair routes whose events were lost to a NATS outage", func() {
+			desireLRP("synced-route")
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "synced-route")).Should(Equal(http.StatusOK))
+
+			addRouteDuringNATSOutage()
+
+			Consistently(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "route-added-during-outage")).Should(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("when bulk syncs are frequent", func() {
+		BeforeEach(func() {
+			// long enough that a sync is unlikely to land between NATS coming
+			// back and the route being checked for
+			syncInterval = 10 * time.Second
+		})
+
+		It("repairs routes whose events were lost to a NATS outage", func() {
+			desireLRP("synced-route")
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "synced-route")).Should(Equal(http.StatusOK))
+
+			addRouteDuringNATSOutage()
+
+			Ω(helpers.RouterRoutes(routingMaker.Addresses.RouterStatus)).ShouldNot(HaveKey("route-added-during-outage"))
+
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "route-added-during-outage"), syncInterval+helpers.Timeouts.Short).Should(Equal(http.StatusOK))
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "synced-route")).Should(Equal(http.StatusOK))
+		})
+
+		It("re-registers routes the router pruned while NATS was down", func() {
+			desireLRP("synced-route")
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "synced-route")).Should(Equal(http.StatusOK))
+
+			monitor := helpers.MonitorRoute(routingMaker.Addresses.Router, "synced-route")
+			defer monitor.Stop()
+
+			chaos.StopNATS(nats)
+
+			// the router prunes routes that stop being re-registered
+			Eventually(helpers.ResponseCodeFromHostPoller(routingMaker.Addresses.Router, "synced-route")).Should(Equal(http.StatusNotFound))
+
+			nats = chaos.StartNATS(routingMaker.NATS())
+
+			Eventually(monitor.Samples).Should(helpers.DropOutThenRecoverWithin(helpers.Timeouts.Long))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package cell_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Router TLS termination", func() {
+	var (
+		tlsMaker  world.ComponentMaker
+		tlsConfig *tls.Config
+
+		runtime ifrit.Process
+	)
+
+	BeforeEach(func() {
+		tlsMaker = componentMaker.WithRouterTLS()
+		tlsConfig = tlsMaker.RouterTLS.ClientConfig()
+
+		fileServer, fileServerStaticDir := tlsMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", tlsMaker.Router()},
+			{"file-server", fileServer},
+			{"exec", tlsMaker.Executor()},
+			{"rep", tlsMaker.Rep()},
+			{"auctioneer", tlsMaker.Auctioneer()},
+			{"route-emitter", tlsMaker.RouteEmitter()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.ForwardedProtoLRP(),
+		)
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: helpers.NewGuid("lrp"),
+			Instances:   1,
+			Stack:       tlsMaker.Stack,
+
+			Routes: cfroutes.CFRoutes{{Port: 8080, Hostnames: []string{"tls-route"}}}.RoutingInfo(),
+			Ports:  []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", tlsMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(helpers.HTTPSResponseCodeFromHostPoller(tlsMaker.Addresses.RouterTLS, "tls-route", tlsConfig)).Should(Equal(http.StatusOK))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	It("tells the LRP that HTTPS requests were made over https", func() {
+		body, statusCode, err := helpers.HTTPSResponseBodyAndStatusCodeFromHost(tlsMaker.Addresses.RouterTLS, "tls-route", tlsConfig)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(statusCode).Should(Equal(http.StatusOK))
+		Ω(string(body)).Should(Equal("https"))
+	})
+
+	It("still serves plain HTTP, and says so", func() {
+		body, statusCode, err := helpers.ResponseBodyAndStatusCodeFromHost(tlsMaker.Addresses.Router, "tls-route")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(statusCode).Should(Equal(http.StatusOK))
+		Ω(string(body)).Should(Equal("http"))
+	})
+
+	It("presents a certificate that clients without the CA do not trust", func() {
+		_, err := helpers.HTTPSResponseCodeFromHostPoller(tlsMaker.Addresses.RouterTLS, "tls-route", &tls.Config{})()
+		Ω(err).Should(HaveOccurred())
+	})
+})
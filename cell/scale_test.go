@@ -0,0 +1,105 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scale", func() {
+	var (
+		lrpCount int
+		budget   time.Duration
+
+		runtime ifrit.Process
+	)
+
+	BeforeEach(func() {
+		if os.Getenv("SCALE") != "1" {
+			Skip("scale tests only run with SCALE=1")
+		}
+
+		lrpCount = 50
+		if count := os.Getenv("SCALE_LRPS"); count != "" {
+			var err error
+			lrpCount, err = strconv.Atoi(count)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		budget = 2 * time.Minute
+		if duration := os.Getenv("SCALE_BUDGET"); duration != "" {
+			var err error
+			budget, err = time.ParseDuration(duration)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", componentMaker.Router()},
+			{"file-server", fileServer},
+			{"exec", componentMaker.Executor("-memoryMB", strconv.Itoa(32*lrpCount))},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"route-emitter", componentMaker.RouteEmitter()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	It("starts many small LRPs on one cell within the budget", func() {
+		startedAt := time.Now()
+
+		starts := helpers.DesireNLRPs(receptorClient, lrpCount, receptor.DesiredLRPCreateRequest{
+			Domain:    INIGO_DOMAIN,
+			Instances: 1,
+			Stack:     componentMaker.Stack,
+			MemoryMB:  32,
+			DiskMB:    32,
+
+			Ports: []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env:  []models.EnvironmentVariable{{"PORT", "8080"}},
+			},
+		})
+
+		elapsed := time.Since(startedAt)
+		fmt.Fprintf(GinkgoWriter, "started %d LRPs in %s; the slowest took %s\n", lrpCount, elapsed, helpers.SlowestLRPStart(starts))
+
+		Ω(elapsed).Should(BeNumerically("<", budget))
+
+		By("routing to every one of them")
+		for _, start := range starts {
+			Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses.Router, start.Route)).Should(ConsistOf([]string{"0"}))
+		}
+	})
+})
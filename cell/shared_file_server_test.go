@@ -0,0 +1,65 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("The shared file-server", func() {
+	var runtime ifrit.Process
+
+	BeforeEach(func() {
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"exec", componentMaker.Executor()},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	It("serves the fixtures this node registered, whichever node runs it", func() {
+		shared := componentMaker.Artifacts.SharedFileServer
+		contents := fmt.Sprintf("registered by node %d", GinkgoParallelNode())
+
+		fixtureURL := shared.RegisterFixture("shared-fixture.zip", []archive_helper.ArchiveFile{
+			{Name: "contents", Body: contents},
+		})
+
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:     INIGO_DOMAIN,
+			TaskGuid:   taskGuid,
+			Stack:      componentMaker.Stack,
+			ResultFile: "/tmp/shared/contents",
+			Action: models.Serial(
+				&models.DownloadAction{
+					From: fixtureURL,
+					To:   "/tmp/shared",
+				},
+				&models.RunAction{
+					Path: "true",
+				},
+			),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		task := helpers.CompletedTask(receptorClient, taskGuid)
+		Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+		Ω(task.Result).Should(Equal(contents))
+	})
+})
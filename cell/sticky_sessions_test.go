@@ -0,0 +1,92 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/fixtures"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sticky sessions", func() {
+	const instances = 3
+
+	var runtime ifrit.Process
+
+	BeforeEach(func() {
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", componentMaker.Router()},
+			{"file-server", fileServer},
+			{"exec", componentMaker.Executor()},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"route-emitter", componentMaker.RouteEmitter()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.HelloWorldIndexLRP(),
+		)
+
+		err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+			Domain:      INIGO_DOMAIN,
+			ProcessGuid: helpers.NewGuid("lrp"),
+			Instances:   instances,
+			Stack:       componentMaker.Stack,
+
+			Routes: cfroutes.CFRoutes{{Port: 8080, Hostnames: []string{"sticky-route"}}}.RoutingInfo(),
+			Ports:  []uint16{8080},
+
+			Setup: &models.DownloadAction{
+				From: fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses.FileServer, "lrp.zip"),
+				To:   ".",
+			},
+
+			Action: &models.RunAction{
+				Path: "bash",
+				Args: []string{"server.sh"},
+				Env: []models.EnvironmentVariable{
+					{"PORT", "8080"},
+					{"STICKY_SESSIONS", "true"},
+				},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses.Router, "sticky-route")).Should(ConsistOf([]string{"0", "1", "2"}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	It("keeps each session on one instance", func() {
+		for i := 0; i < 5; i++ {
+			helpers.ExpectStickySession(componentMaker.Addresses.Router, "sticky-route", 20)
+		}
+	})
+
+	It("still spreads sessions across the instances", func() {
+		Eventually(func() map[string]bool {
+			pinnedTo := map[string]bool{}
+			for i := 0; i < 10; i++ {
+				pinnedTo[helpers.ExpectStickySession(componentMaker.Addresses.Router, "sticky-route", 2)] = true
+			}
+
+			return pinnedTo
+		}).Should(HaveLen(instances))
+	})
+})
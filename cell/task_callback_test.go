@@ -0,0 +1,143 @@
+package cell_test
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/chaos"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Task completion callbacks", func() {
+	var (
+		runtime ifrit.Process
+
+		callbackServer *helpers.TaskCallbackServer
+
+		taskGuid string
+	)
+
+	BeforeEach(func() {
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"exec", componentMaker.Executor()},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		callbackServer = helpers.NewTaskCallbackServer("127.0.0.1")
+
+		taskGuid = helpers.NewGuid("task")
+	})
+
+	JustBeforeEach(func() {
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:     INIGO_DOMAIN,
+			TaskGuid:   taskGuid,
+			Stack:      componentMaker.Stack,
+			Annotation: "the-annotation",
+			ResultFile: "/tmp/result",
+			Action: &models.RunAction{
+				Path: "sh",
+				Args: []string{"-c", "echo -n the-result > /tmp/result"},
+			},
+			CompletionCallbackURL: callbackServer.URL(),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		callbackServer.Close()
+		helpers.StopProcesses(runtime)
+	})
+
+	It("calls back exactly once with the completed Task, then resolves it", func() {
+		Eventually(callbackServer.CallbacksForPoller(taskGuid)).Should(Equal(1))
+
+		task := callbackServer.CallbacksFor(taskGuid)[0].Task
+		Ω(task.TaskGuid).Should(Equal(taskGuid))
+		Ω(task.Domain).Should(Equal(INIGO_DOMAIN))
+		Ω(task.Annotation).Should(Equal("the-annotation"))
+		Ω(task.State).Should(Equal(receptor.TaskStateCompleted))
+		Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+		Ω(task.Result).Should(Equal("the-result"))
+		Ω(task.CompletionCallbackURL).Should(Equal(callbackServer.URL()))
+
+		Eventually(func() error {
+			_, err := receptorClient.GetTask(taskGuid)
+			return err
+		}).Should(HaveOccurred(), "the Task was not resolved once called back")
+
+		Consistently(callbackServer.CallbacksForPoller(taskGuid)).Should(Equal(1))
+	})
+
+	Context("when the callback fails with a transient error", func() {
+		BeforeEach(func() {
+			callbackServer.FailNext(2, http.StatusServiceUnavailable)
+		})
+
+		It("retries until it gets through, and then stops", func() {
+			Eventually(callbackServer.CallbacksForPoller(taskGuid)).Should(Equal(3))
+			Consistently(callbackServer.CallbacksForPoller(taskGuid)).Should(Equal(3))
+
+			callbacks := callbackServer.CallbacksFor(taskGuid)
+			Ω(callbacks[0].RespondedWith).Should(Equal(http.StatusServiceUnavailable))
+			Ω(callbacks[1].RespondedWith).Should(Equal(http.StatusServiceUnavailable))
+			Ω(callbacks[2].RespondedWith).Should(Equal(http.StatusOK))
+
+			for _, callback := range callbacks {
+				Ω(callback.Task).Should(Equal(callbacks[0].Task))
+			}
+		})
+	})
+
+	Context("when the receptor's task handler is unreachable while the Task completes", func() {
+		var (
+			converger        ifrit.Process
+			taskHandlerBlock *chaos.PortBlock
+		)
+
+		BeforeEach(func() {
+			// kicks the completed Task at the task handler again until it is
+			// resolved, well before it would expire
+			converger = ginkgomon.Invoke(componentMaker.Converger(
+				"-convergeRepeatInterval", "1s",
+				"-expireCompletedTaskDuration", "1m",
+			))
+
+			taskHandlerBlock = chaos.BlockPort(componentMaker.Addresses.ReceptorTaskHandler)
+		})
+
+		AfterEach(func() {
+			taskHandlerBlock.Release()
+			helpers.StopProcesses(converger)
+		})
+
+		It("calls back exactly once, after the task handler is back", func() {
+			helpers.EventuallyTask(receptorClient, taskGuid, receptor.TaskStateCompleted)
+
+			Consistently(callbackServer.CallbacksForPoller(taskGuid), helpers.Timeouts.Consistently).Should(BeZero())
+
+			taskHandlerBlock.Release()
+
+			Eventually(callbackServer.CallbacksForPoller(taskGuid)).Should(Equal(1))
+			Consistently(callbackServer.CallbacksForPoller(taskGuid)).Should(Equal(1))
+
+			task := callbackServer.CallbacksFor(taskGuid)[0].Task
+			Ω(task.State).Should(Equal(receptor.TaskStateCompleted))
+			Ω(task.Result).Should(Equal("the-result"))
+
+			Eventually(func() error {
+				_, err := receptorClient.GetTask(taskGuid)
+				return err
+			}).Should(HaveOccurred(), "the Task was not resolved once called back")
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package cell_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tasks on a failing cell", func() {
+	var (
+		runtime ifrit.Process
+		cell    *helpers.TaskCell
+
+		taskGuid string
+		cellID   string
+	)
+
+	BeforeEach(func() {
+		taskGuid = helpers.NewGuid("task")
+
+		runtime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"converger", componentMaker.Converger(
+				"-convergeRepeatInterval", "1s",
+				"-kickPendingTaskDuration", "1s",
+			)},
+		}))
+
+		cell = helpers.StartTaskCell(componentMaker, receptorClient, 0)
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:   INIGO_DOMAIN,
+			TaskGuid: taskGuid,
+			Stack:    componentMaker.Stack,
+			MemoryMB: 128,
+			Action: &models.RunAction{
+				Path: "sh",
+				Args: []string{"-c", "sleep 5"},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		cellID = helpers.WaitForTaskToRunOnCell(receptorClient, taskGuid)
+		Ω(cellID).Should(Equal(cell.ID))
+	})
+
+	AfterEach(func() {
+		cell.Stop()
+		helpers.StopProcesses(runtime)
+	})
+
+	expectOutcome := func(failure helpers.CellFailure, policy helpers.TaskFailurePolicy, description string) {
+		Context(fmt.Sprintf("on a %s", failure), func() {
+			It(description, func() {
+				cell.Fail(failure)
+
+				helpers.ExpectTaskOutcome(receptorClient, taskGuid, cellID, policy)
+			})
+		})
+	}
+
+	expectOutcome(helpers.CellCrash, helpers.TaskFailsWithCell, "fails the task once the cell is gone")
+	expectOutcome(helpers.RepCrash, helpers.TaskFailsWithCell, "fails the task, even though its container finished")
+	expectOutcome(helpers.CellEvacuation, helpers.TaskCompletesInPlace, "lets the task finish before the cell goes")
+})
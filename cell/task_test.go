@@ -10,7 +10,6 @@ import (
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/runtime-schema/diego_errors"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -62,7 +61,7 @@ var _ = Describe("Task", func() {
 
 			BeforeEach(func() {
 				taskSleepSeconds = 10
-				taskGuid = factories.GenerateGuid()
+				taskGuid = helpers.NewGuid("task")
 				stack = componentMaker.Stack
 				memory = 512
 			})
@@ -95,7 +94,7 @@ var _ = Describe("Task", func() {
 						Args: []string{
 							"-c",
 							// sleep a bit so that we can make assertions around behavior as it's running
-							fmt.Sprintf("curl %s; sleep %d", inigo_announcement_server.AnnounceURL(taskGuid), taskSleepSeconds),
+							fmt.Sprintf("curl %s; sleep %d", announcementClient.AnnounceURL(taskGuid), taskSleepSeconds),
 						},
 					},
 				})
@@ -104,7 +103,7 @@ var _ = Describe("Task", func() {
 
 			Context("when there is a matching stack", func() {
 				It("eventually runs the Task", func() {
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+					Eventually(announcementClient.Announcements).Should(ContainElement(taskGuid))
 				})
 			})
 
@@ -134,7 +133,7 @@ var _ = Describe("Task", func() {
 				})
 
 				JustBeforeEach(func() {
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+					Eventually(announcementClient.Announcements).Should(ContainElement(taskGuid))
 
 					err := receptorClient.CancelTask(taskGuid)
 					Ω(err).ShouldNot(HaveOccurred())
@@ -167,7 +166,7 @@ var _ = Describe("Task", func() {
 
 				Context("after the task starts", func() {
 					JustBeforeEach(func() {
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+						Eventually(announcementClient.Announcements).Should(ContainElement(taskGuid))
 					})
 
 					Context("when the cellProcess disappears", func() {
@@ -194,6 +193,48 @@ var _ = Describe("Task", func() {
 			})
 		})
 
+		Context("and a Task with serial and parallel actions is desired", func() {
+			var taskGuid string
+
+			announce := func(suffix string, delaySeconds int) models.Action {
+				return &models.RunAction{
+					Path: "sh",
+					Args: []string{
+						"-c",
+						fmt.Sprintf("sleep %d; curl %s", delaySeconds, announcementClient.AnnounceURL(taskGuid+"-"+suffix)),
+					},
+				}
+			}
+
+			BeforeEach(func() {
+				taskGuid = helpers.NewGuid("task")
+
+				err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+					Domain:   INIGO_DOMAIN,
+					TaskGuid: taskGuid,
+					Stack:    componentMaker.Stack,
+					Action: models.Serial(
+						announce("first", 0),
+						models.Parallel(
+							announce("slow", 2),
+							announce("fast", 0),
+						),
+						announce("last", 0),
+					),
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("runs serial actions in sequence and parallel actions concurrently", func() {
+				Eventually(announcementClient.Announcements).Should(ContainElement(taskGuid + "-last"))
+
+				announcements := announcementClient.AnnouncementsInOrder()
+				Ω(announcements).Should(inigo_announcement_server.AnnouncedBefore(taskGuid+"-first", taskGuid+"-fast"))
+				Ω(announcements).Should(inigo_announcement_server.AnnouncedBefore(taskGuid+"-fast", taskGuid+"-slow"))
+				Ω(announcements).Should(inigo_announcement_server.AnnouncedBefore(taskGuid+"-slow", taskGuid+"-last"))
+			})
+		})
+
 		Context("Egress Rules", func() {
 			var (
 				taskGuid          string
@@ -203,7 +244,7 @@ var _ = Describe("Task", func() {
 			)
 
 			BeforeEach(func() {
-				taskGuid = factories.GenerateGuid()
+				taskGuid = helpers.NewGuid("task")
 				announcement = fmt.Sprintf("%s-0", taskGuid)
 				taskSleepSeconds = 10
 				taskCreateRequest = receptor.TaskCreateRequest{
@@ -281,7 +322,7 @@ exit 0
 			var taskGuid string
 
 			BeforeEach(func() {
-				taskGuid = factories.GenerateGuid()
+				taskGuid = helpers.NewGuid("task")
 
 				err := receptorClient.CreateTask(receptor.TaskCreateRequest{
 					Domain:   INIGO_DOMAIN,
@@ -289,7 +330,7 @@ exit 0
 					Stack:    componentMaker.Stack,
 					Action: &models.RunAction{
 						Path: "curl",
-						Args: []string{inigo_announcement_server.AnnounceURL(taskGuid)},
+						Args: []string{announcementClient.AnnounceURL(taskGuid)},
 					},
 				})
 				Ω(err).ShouldNot(HaveOccurred())
@@ -305,7 +346,7 @@ exit 0
 				})
 
 				It("eventually runs the Task", func() {
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+					Eventually(announcementClient.Announcements).Should(ContainElement(taskGuid))
 				})
 			})
 		})
@@ -323,7 +364,7 @@ exit 0
 			var taskGuid string
 
 			BeforeEach(func() {
-				taskGuid = factories.GenerateGuid()
+				taskGuid = helpers.NewGuid("task")
 
 				err := receptorClient.CreateTask(receptor.TaskCreateRequest{
 					Domain:   INIGO_DOMAIN,
@@ -331,7 +372,7 @@ exit 0
 					Stack:    componentMaker.Stack,
 					Action: &models.RunAction{
 						Path: "curl",
-						Args: []string{inigo_announcement_server.AnnounceURL(taskGuid)},
+						Args: []string{announcementClient.AnnounceURL(taskGuid)},
 					},
 				})
 				Ω(err).ShouldNot(HaveOccurred())
@@ -351,7 +392,7 @@ exit 0
 				Ω(completedTask.Failed).Should(BeTrue())
 				Ω(completedTask.FailureReason).Should(ContainSubstring("not started within time limit"))
 
-				Ω(inigo_announcement_server.Announcements()).Should(BeEmpty())
+				Ω(announcementClient.Announcements()).Should(BeEmpty())
 			})
 		})
 	})
@@ -0,0 +1,86 @@
+package cell_test
+
+import (
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rolling upgrades", func() {
+	const oldVersion = "v0"
+
+	var (
+		brain   ifrit.Process
+		oldCell ifrit.Process
+		newCell ifrit.Process
+	)
+
+	BeforeEach(func() {
+		brain = nil
+		oldCell = nil
+		newCell = nil
+
+		if _, found := componentMaker.Artifacts.Versions[oldVersion]; !found {
+			Skip("no " + oldVersion + " executables were built or fetched; set COMPONENT_VERSIONS and the *_GOPATH_V0 env vars, or PINNED_COMPONENT_VERSIONS")
+		}
+
+		brain = ginkgomon.Invoke(componentMaker.Auctioneer())
+
+		oldMaker := componentMaker.WithVersion(oldVersion)
+		oldCell = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"exec", oldMaker.Executor()},
+			{"rep", oldMaker.Rep()},
+		}))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(brain, oldCell, newCell)
+	})
+
+	runTask := func() string {
+		taskGuid := helpers.NewGuid("task")
+
+		err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+			Domain:   INIGO_DOMAIN,
+			TaskGuid: taskGuid,
+			Stack:    componentMaker.Stack,
+			Action: &models.RunAction{
+				Path: "curl",
+				Args: []string{announcementClient.AnnounceURL(taskGuid)},
+			},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return taskGuid
+	}
+
+	It("runs tasks on an old cell against the new receptor", func() {
+		taskGuid := runTask()
+		Eventually(announcementClient.Announcements).Should(ContainElement(taskGuid))
+	})
+
+	Context("when the cell is upgraded in place", func() {
+		BeforeEach(func() {
+			helpers.StopProcesses(oldCell)
+			oldCell = nil
+
+			newCell = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+				{"exec", componentMaker.Executor()},
+				{"rep", componentMaker.Rep()},
+			}))
+		})
+
+		It("continues to run tasks", func() {
+			taskGuid := runTask()
+			Eventually(announcementClient.Announcements).Should(ContainElement(taskGuid))
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package chaos
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/helpers/gardenx"
+	. "github.com/onsi/gomega"
+)
+
+// DestroyContainersMatching destroys every Garden container with the given
+// properties behind the executor's back, returning the destroyed handles.
+func DestroyContainersMatching(gardenClient garden.Client, propertyFilter garden.Properties) []string {
+	containers, err := gardenClient.Containers(propertyFilter)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	handles := []string{}
+	for _, container := range containers {
+		err := gardenClient.Destroy(container.Handle())
+		Ω(err).ShouldNot(HaveOccurred())
+
+		handles = append(handles, container.Handle())
+	}
+
+	return handles
+}
+
+// KillContainerProcesses SIGKILLs every process in the container, leaving
+// the container itself in place.
+func KillContainerProcesses(gardenClient garden.Client, handle string) {
+	signalContainerProcesses(gardenClient, handle, "KILL")
+}
+
+// PauseContainerProcesses SIGSTOPs every process in the container so that it
+// stops responding without exiting.
+func PauseContainerProcesses(gardenClient garden.Client, handle string) {
+	signalContainerProcesses(gardenClient, handle, "STOP")
+}
+
+func ResumeContainerProcesses(gardenClient garden.Client, handle string) {
+	signalContainerProcesses(gardenClient, handle, "CONT")
+}
+
+func signalContainerProcesses(gardenClient garden.Client, handle string, signal string) {
+	container, err := gardenClient.Lookup(handle)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	// kill -1 signals everything but the container's init and the shell itself
+	result := gardenx.RunSpec(container, garden.ProcessSpec{
+		Path:       "sh",
+		Args:       []string{"-c", "kill -" + signal + " -1"},
+		Privileged: true,
+	})
+	Ω(result.ExitStatus).Should(Equal(0), "failed to send SIG%s: %s", signal, result.Stderr)
+}
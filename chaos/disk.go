@@ -0,0 +1,79 @@
+package chaos
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+
+	. "github.com/onsi/gomega"
+)
+
+// DiskPressure is space taken up on a filesystem behind everyone's back,
+// held by a single preallocated file. (A sparse file would not do, as it
+// takes up no space until written to.)
+type DiskPressure struct {
+	path string
+}
+
+// FillDisk takes up space on the filesystem holding dir, e.g. Garden's graph
+// or the executor's cache, until it is at least usedFraction full.
+func FillDisk(dir string, usedFraction float64) *DiskPressure {
+	total, free := diskSpace(dir)
+
+	targetFree := uint64((1 - usedFraction) * float64(total))
+
+	return fillDiskTo(dir, free, targetFree)
+}
+
+// FillDiskLeaving takes up space on the filesystem holding dir until only
+// freeBytes are left.
+func FillDiskLeaving(dir string, freeBytes uint64) *DiskPressure {
+	_, free := diskSpace(dir)
+
+	return fillDiskTo(dir, free, freeBytes)
+}
+
+// Release gives the space back.
+func (pressure *DiskPressure) Release() {
+	if pressure.path == "" {
+		return
+	}
+
+	err := os.Remove(pressure.path)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	pressure.path = ""
+}
+
+// DiskFree is how many bytes are available on the filesystem holding dir.
+func DiskFree(dir string) uint64 {
+	_, free := diskSpace(dir)
+	return free
+}
+
+func fillDiskTo(dir string, free uint64, targetFree uint64) *DiskPressure {
+	if free <= targetFree {
+		return &DiskPressure{}
+	}
+
+	file, err := ioutil.TempFile(dir, "disk-pressure")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	defer file.Close()
+
+	err = syscall.Fallocate(int(file.Fd()), 0, 0, int64(free-targetFree))
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	Ω(err).ShouldNot(HaveOccurred(), "failed to take up %d bytes under %s", free-targetFree, dir)
+
+	return &DiskPressure{path: file.Name()}
+}
+
+func diskSpace(dir string) (total uint64, free uint64) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(dir, &stat)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return stat.Blocks * uint64(stat.Bsize), stat.Bavail * uint64(stat.Bsize)
+}
@@ -0,0 +1,35 @@
+package chaos
+
+import (
+	"syscall"
+
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// StopNATS kills gnatsd outright, so that everything connected to it loses
+// its connection and anything published until it is back is lost.
+//
+// The gnatsd must not be part of a group, or stopping it takes the rest of
+// the group down too.
+func StopNATS(natsProcess ifrit.Process) {
+	natsProcess.Signal(syscall.SIGKILL)
+	Eventually(natsProcess.Wait()).Should(Receive())
+}
+
+// StartNATS brings gnatsd back, on the address it had before if natsRunner
+// comes from the same ComponentMaker; clients reconnect on their own.
+func StartNATS(natsRunner ifrit.Runner) ifrit.Process {
+	return ginkgomon.Invoke(natsRunner)
+}
+
+// NATSOutage stops gnatsd, runs duringOutage while it is down, then starts
+// a fresh one from newNATS and returns it.
+func NATSOutage(natsProcess ifrit.Process, newNATS func() ifrit.Runner, duringOutage func()) ifrit.Process {
+	StopNATS(natsProcess)
+
+	duringOutage()
+
+	return StartNATS(newNATS())
+}
@@ -0,0 +1,54 @@
+package chaos
+
+import (
+	"net"
+	"os/exec"
+
+	. "github.com/onsi/gomega"
+)
+
+// PortBlock is a TCP address that connections are refused on, whatever is
+// listening there, until it is released.
+type PortBlock struct {
+	rule []string
+}
+
+// BlockPort has iptables reset every new connection to address, e.g. the
+// receptor's task handler, so that it looks down to everything talking to
+// it while the process behind it carries on. Connections already open are
+// left alone. It needs root, as Garden does.
+func BlockPort(address string) *PortBlock {
+	host, port, err := net.SplitHostPort(address)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	block := &PortBlock{
+		rule: []string{
+			"INPUT",
+			"-p", "tcp",
+			"-d", host,
+			"--dport", port,
+			"--syn",
+			"-j", "REJECT", "--reject-with", "tcp-reset",
+		},
+	}
+
+	iptables(append([]string{"-I"}, block.rule...)...)
+
+	return block
+}
+
+// Release lets connections through again. Releasing it twice is harmless.
+func (block *PortBlock) Release() {
+	if block.rule == nil {
+		return
+	}
+
+	iptables(append([]string{"-D"}, block.rule...)...)
+
+	block.rule = nil
+}
+
+func iptables(args ...string) {
+	output, err := exec.Command("iptables", args...).CombinedOutput()
+	Ω(err).ShouldNot(HaveOccurred(), "iptables %v: %s", args, output)
+}
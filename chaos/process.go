@@ -0,0 +1,31 @@
+package chaos
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/tedsuo/ifrit"
+)
+
+// PauseProcess SIGSTOPs the process, e.g. a rep, so that it hangs without
+// exiting: it keeps its connections open but stops heartbeating.
+//
+// The process must be invoked on its own rather than as part of a group, so
+// that the signal reaches it, and must be resumed before it is stopped, or it
+// never sees the SIGTERM.
+func PauseProcess(process ifrit.Process) {
+	process.Signal(syscall.SIGSTOP)
+}
+
+// ResumeProcess SIGCONTs a process paused with PauseProcess.
+func ResumeProcess(process ifrit.Process) {
+	process.Signal(syscall.SIGCONT)
+}
+
+// PauseProcessFor pauses the process, and resumes it once the duration has
+// passed.
+func PauseProcessFor(process ifrit.Process, duration time.Duration) {
+	PauseProcess(process)
+	time.Sleep(duration)
+	ResumeProcess(process)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/inigo_announcement_server"
+)
+
+var listenAddr = flag.String(
+	"listenAddr",
+	"0.0.0.0:8080",
+	"address to listen for announcements on",
+)
+
+var stateFile = flag.String(
+	"stateFile",
+	"",
+	"file to persist announcements to",
+)
+
+func main() {
+	flag.Parse()
+
+	if *stateFile == "" {
+		fmt.Fprintln(os.Stderr, "-stateFile is required")
+		os.Exit(1)
+	}
+
+	server, err := inigo_announcement_server.NewServer(*stateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load state: %s\n", err)
+		os.Exit(1)
+	}
+
+	listener, err := net.Listen("tcp", *listenAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to listen: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("announcement-server.started")
+
+	err = http.Serve(listener, server)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to serve: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+	"github.com/tedsuo/ifrit"
+)
+
+var builtArtifactsPath = flag.String(
+	"builtArtifacts",
+	"",
+	"path to a JSON-encoded set of built artifacts; if not given, everything is compiled from the *_GOPATH environment variables",
+)
+
+var configPath = flag.String(
+	"config",
+	"",
+	"path to an inigo.yml to load before the environment is read; variables already set take precedence",
+)
+
+var domain = flag.String(
+	"domain",
+	"inigo",
+	"domain to upsert once the world is up",
+)
+
+func main() {
+	flag.Parse()
+
+	gomega.RegisterFailHandler(func(message string, callerSkip ...int) {
+		fmt.Fprintln(os.Stderr, message)
+		os.Exit(1)
+	})
+
+	if *configPath != "" {
+		world.LoadConfig(*configPath)
+	}
+
+	helpers.RegisterDefaultTimeouts()
+
+	defer gexec.CleanupBuildArtifacts()
+
+	componentMaker := helpers.MakeComponentMaker(loadBuiltArtifacts())
+
+	env := world.Bootstrap(world.BootstrapConfig{
+		Maker: componentMaker,
+	})
+
+	err := env.ReceptorClient.UpsertDomain(*domain, 0)
+	gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+
+	addresses, err := json.MarshalIndent(componentMaker.Addresses, "", "  ")
+	gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+
+	fmt.Printf("world is up; file-server static dir is %s\n", env.FileServerStaticDir)
+	fmt.Printf("%s\n", addresses)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	exited := make(chan string, len(env.Processes))
+	for name, process := range env.Processes {
+		go func(name string, process ifrit.Process) {
+			err := <-process.Wait()
+			exited <- fmt.Sprintf("%s exited: %v", name, err)
+		}(name, process)
+	}
+
+	select {
+	case <-signals:
+		helpers.Teardown(env.Processes)
+	case message := <-exited:
+		fmt.Fprintf(os.Stderr, "world exited: %s\n", message)
+		os.Exit(1)
+	}
+}
+
+func loadBuiltArtifacts() world.BuiltArtifacts {
+	if *builtArtifactsPath == "" {
+		return world.BuiltArtifacts{
+			Executables: world.CompileTestedExecutables(),
+			Lifecycles:  world.BuildLifecycles(helpers.Stack()),
+		}
+	}
+
+	payload, err := ioutil.ReadFile(*builtArtifactsPath)
+	gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+
+	var builtArtifacts world.BuiltArtifacts
+	err = json.Unmarshal(payload, &builtArtifacts)
+	gomega.Ω(err).ShouldNot(gomega.HaveOccurred())
+
+	return builtArtifacts
+}
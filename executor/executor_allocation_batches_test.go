@@ -0,0 +1,173 @@
+package executor_test
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Allocating containers in batches", func() {
+	const (
+		capacityMemoryMB = 256
+		capacityDiskMB   = 512
+	)
+
+	var (
+		executorClient executor.Client
+		process        ifrit.Process
+
+		accountant *helpers.ResourceAccountant
+	)
+
+	BeforeEach(func() {
+		process = ginkgomon.Invoke(componentMaker.WithExecutorCapacity(strconv.Itoa(capacityMemoryMB), strconv.Itoa(capacityDiskMB)).Executor())
+		executorClient = componentMaker.ExecutorClient()
+
+		accountant = helpers.NewResourceAccountant(executorClient)
+	})
+
+	AfterEach(func() {
+		accountant.ExpectNoLeaks()
+
+		helpers.StopProcesses(process)
+	})
+
+	allocateAlone := func(memoryMB, diskMB int) executor.Container {
+		batch := helpers.NewAllocationBatch()
+		container := batch.Add(memoryMB, diskMB)
+
+		remaining, err := executorClient.RemainingResources()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		batch.ExpectOutcome(executorClient, batch.Submit(executorClient), remaining)
+
+		return container
+	}
+
+	Context("with a batch mixing containers that fit with ones that are invalid", func() {
+		var (
+			batch     *helpers.AllocationBatch
+			remaining executor.ExecutorResources
+		)
+
+		BeforeEach(func() {
+			original := allocateAlone(16, 16)
+
+			batch = helpers.NewAllocationBatch()
+			batch.Add(32, 32)
+			batch.AddInvalidLimits()
+			batch.AddRefused(capacityMemoryMB+1, 1, executor.ErrInsufficientResourcesAvailable)
+			batch.AddTaken(original)
+			batch.Add(32, 64)
+
+			var err error
+			remaining, err = executorClient.RemainingResources()
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("allocates the ones that fit, and refuses each of the others under its own guid", func() {
+			batch.ExpectOutcome(executorClient, batch.Submit(executorClient), remaining)
+		})
+
+		It("reserves nothing for the refused ones, so deleting the allocated ones gives everything back", func() {
+			batch.Submit(executorClient)
+
+			helpers.DeleteExecutorContainers(executorClient)
+			accountant.ExpectBaseline()
+		})
+	})
+
+	Context("with a batch bigger than the capacity", func() {
+		It("allocates in the order of the batch, refusing each container that no longer fits", func() {
+			batch := helpers.NewAllocationBatch()
+			batch.Add(100, 100)
+			batch.Add(100, 100)
+			batch.AddRefused(100, 100, executor.ErrInsufficientResourcesAvailable)
+
+			// smaller, and still fits after the refused one
+			batch.Add(56, 56)
+
+			batch.AddRefused(1, 1, executor.ErrInsufficientResourcesAvailable)
+
+			batch.ExpectOutcome(executorClient, batch.Submit(executorClient), accountant.Baseline)
+		})
+	})
+
+	Context("with batches from several clients at once", func() {
+		const (
+			clients          = 4
+			validPerBatch    = 3
+			eachMemoryDiskMB = 32
+		)
+
+		It("allocates no more than fits between them, refuses each container under its own guid, and leaks nothing", func() {
+			batches := make([]*helpers.AllocationBatch, clients)
+			outcomes := make([]map[string]string, clients)
+
+			for i := range batches {
+				batches[i] = helpers.NewAllocationBatch()
+				for j := 0; j < validPerBatch; j++ {
+					batches[i].Add(eachMemoryDiskMB, eachMemoryDiskMB)
+				}
+				batches[i].AddInvalidLimits()
+			}
+
+			wg := new(sync.WaitGroup)
+			for i := range batches {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					// a client of its own, as if from another rep
+					outcomes[i] = batches[i].Submit(componentMaker.ExecutorClient())
+				}(i)
+			}
+			wg.Wait()
+
+			allocated := executor.ExecutorResources{}
+			for i, batch := range batches {
+				for _, container := range batch.Containers {
+					expected, invalid := batch.ExpectedErrors[container.Guid]
+					if invalid {
+						Ω(outcomes[i]).Should(HaveKeyWithValue(container.Guid, expected.Error()))
+						continue
+					}
+
+					if message, refused := outcomes[i][container.Guid]; refused {
+						Ω(message).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+					}
+				}
+
+				guids := map[string]bool{}
+				for _, container := range batch.Containers {
+					guids[container.Guid] = true
+				}
+
+				for guid := range outcomes[i] {
+					Ω(guids).Should(HaveKey(guid), "an error for a guid that was never in the batch")
+				}
+
+				fromBatch := helpers.AllocatedFrom(batch.Containers, outcomes[i])
+				allocated.MemoryMB += fromBatch.MemoryMB
+				allocated.DiskMB += fromBatch.DiskMB
+				allocated.Containers += fromBatch.Containers
+			}
+
+			Ω(allocated.MemoryMB).Should(BeNumerically("<=", capacityMemoryMB))
+			Ω(allocated.MemoryMB).Should(BeNumerically(">", capacityMemoryMB-eachMemoryDiskMB), "refused containers that would have fit")
+
+			accountant.ExpectReserved(allocated.MemoryMB, allocated.DiskMB, allocated.Containers)
+
+			helpers.DeleteExecutorContainers(executorClient)
+			accountant.ExpectBaseline()
+		})
+	})
+})
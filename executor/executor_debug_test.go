@@ -0,0 +1,77 @@
+package executor_test
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("The executor's own resource usage", func() {
+	const containers = 5
+
+	var (
+		debugAddr string
+
+		executorClient executor.Client
+		process        ifrit.Process
+	)
+
+	BeforeEach(func() {
+		maker := componentMaker.WithDebugServers()
+		debugAddr = maker.Addresses.Debug["executor"]
+
+		process = ginkgomon.Invoke(maker.Executor())
+		executorClient = maker.ExecutorClient()
+	})
+
+	AfterEach(func() {
+		helpers.DeleteExecutorContainers(executorClient)
+		helpers.StopProcesses(process)
+	})
+
+	It("gives back the goroutines it used to run containers once they are deleted", func() {
+		before := helpers.ScrapeDebugEndpoint(debugAddr)
+		Ω(before).Should(HaveKey("goroutines"))
+		Ω(before).Should(HaveKey("HeapAlloc"))
+
+		guids := []string{}
+		for i := 0; i < containers; i++ {
+			guid := helpers.NewGuid("container")
+			guids = append(guids, guid)
+
+			allocationErrors, err := executorClient.AllocateContainers([]executor.Container{{
+				Guid: guid,
+				Action: &models.RunAction{
+					Path: "sh",
+					Args: []string{"-c", "while true; do sleep 1; done"},
+				},
+			}})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(allocationErrors).Should(BeEmpty())
+
+			err = executorClient.RunContainer(guid)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		for _, guid := range guids {
+			Eventually(func() executor.State {
+				container, err := executorClient.GetContainer(guid)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				return container.State
+			}).Should(Equal(executor.StateRunning))
+		}
+
+		Ω(helpers.ScrapeDebugEndpoint(debugAddr)["goroutines"]).Should(BeNumerically(">", before["goroutines"]))
+
+		helpers.DeleteExecutorContainers(executorClient)
+
+		// some slack for connections that have not been reaped yet
+		Eventually(helpers.ScrapeDebugEndpointPoller(debugAddr, "goroutines"), helpers.Timeouts.Long).Should(BeNumerically("<=", before["goroutines"]+containers/2))
+	})
+})
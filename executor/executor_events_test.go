@@ -0,0 +1,100 @@
+package executor_test
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// containerEvent is what the spec looks for in the executor's events
+type containerEvent struct {
+	Guid string
+	Type executor.EventType
+}
+
+var _ = Describe("Following the executor's events across a restart", func() {
+	var (
+		process ifrit.Process
+		runner  *world.TimedRunner
+
+		executorClient executor.Client
+		events         *helpers.ReconnectingEventSource
+
+		// every container event, pumped from events, as Next blocks
+		containerEvents chan containerEvent
+	)
+
+	runContainer := func(script string) string {
+		guid := helpers.NewGuid("container")
+
+		allocationErrors, err := executorClient.AllocateContainers([]executor.Container{{
+			Guid: guid,
+			Action: &models.RunAction{
+				Path: "sh",
+				Args: []string{"-c", script},
+			},
+		}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(allocationErrors).Should(BeEmpty())
+
+		err = executorClient.RunContainer(guid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return guid
+	}
+
+	BeforeEach(func() {
+		runner = componentMaker.Executor()
+		process = ginkgomon.Invoke(runner)
+
+		executorClient = componentMaker.ExecutorClient()
+		events = helpers.SubscribeToExecutorEvents(executorClient)
+
+		containerEvents = make(chan containerEvent, 100)
+
+		// given its own, as the next spec's BeforeEach replaces them
+		go func(events *helpers.ReconnectingEventSource, containerEvents chan<- containerEvent) {
+			defer GinkgoRecover()
+			defer close(containerEvents)
+
+			for {
+				event, err := events.Next()
+				if err != nil {
+					// closed by the AfterEach
+					return
+				}
+
+				if e, ok := event.(interface {
+					Container() executor.Container
+				}); ok {
+					containerEvents <- containerEvent{Guid: e.Container().Guid, Type: event.EventType()}
+				}
+			}
+		}(events, containerEvents)
+	})
+
+	AfterEach(func() {
+		events.Close()
+		helpers.DeleteExecutorContainers(executorClient)
+		helpers.StopProcesses(process)
+	})
+
+	It("keeps delivering events once the executor is back", func() {
+		before := runContainer("while true; do sleep 1; done")
+		Eventually(containerEvents).Should(Receive(Equal(containerEvent{Guid: before, Type: executor.EventTypeContainerRunning})))
+
+		process, runner = componentMaker.RestartExecutor(process, runner)
+
+		// likely done before the source reconnects, so only caught up on
+		after := runContainer("exit 0")
+		Eventually(containerEvents, helpers.Timeouts.Long).Should(Receive(Equal(containerEvent{Guid: after, Type: executor.EventTypeContainerComplete})))
+
+		Ω(events.Lost()).Should(ConsistOf(before))
+	})
+})
@@ -13,8 +13,10 @@ import (
 	"time"
 
 	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/helpers/gardenx"
+	"github.com/cloudfoundry-incubator/inigo/world"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	uuid "github.com/nu7hatch/gouuid"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -27,18 +29,23 @@ import (
 
 var _ = Describe("Executor/Garden", func() {
 	const pruningInterval = 500 * time.Millisecond
-	const ownerName = "executor"
+	const shutdownTimeout = 2 * time.Second
 
 	var (
+		maker                world.ComponentMaker
+		ownerName            string
 		executorClient       executor.Client
 		process              ifrit.Process
-		runner               *ginkgomon.Runner
+		runner               *world.TimedRunner
 		gardenCapacity       garden.Capacity
 		exportNetworkEnvVars bool
 		cachePath            string
 	)
 
 	BeforeEach(func() {
+		maker = componentMaker
+		ownerName = componentMaker.ContainerOwnerName
+
 		var err error
 		cachePath, err = ioutil.TempDir("", "executor-tmp")
 		Ω(err).ShouldNot(HaveOccurred())
@@ -47,7 +54,7 @@ var _ = Describe("Executor/Garden", func() {
 	JustBeforeEach(func() {
 		var err error
 
-		runner = componentMaker.Executor(
+		runner = maker.Executor(
 			"-pruneInterval", pruningInterval.String(),
 			"-healthyMonitoringInterval", "1s",
 			"-unhealthyMonitoringInterval", "100ms",
@@ -70,10 +77,7 @@ var _ = Describe("Executor/Garden", func() {
 	})
 
 	generateGuid := func() string {
-		id, err := uuid.NewV4()
-		Ω(err).ShouldNot(HaveOccurred())
-
-		return id.String()
+		return helpers.NewGuid("container")
 	}
 
 	allocNewContainer := func(request executor.Container) string {
@@ -152,7 +156,7 @@ var _ = Describe("Executor/Garden", func() {
 					}
 
 					return filenames
-				}, 10*time.Second).Should(BeEmpty())
+				}, helpers.Timeouts.Short).Should(BeEmpty())
 			})
 		})
 
@@ -165,48 +169,36 @@ var _ = Describe("Executor/Garden", func() {
 					}
 
 					return dirInfo.IsDir()
-				}, 10*time.Second).Should(BeTrue())
+				}, helpers.Timeouts.Short).Should(BeTrue())
 			})
 		})
 
 		Context("when there are containers that are owned by the executor", func() {
-			var container1, container2 garden.Container
+			var ownedHandles, unownedHandles []string
 
 			BeforeEach(func() {
-				var err error
-
-				container1, err = gardenClient.Create(garden.ContainerSpec{
-					Properties: garden.Properties{
-						"executor:owner": ownerName,
-					},
-				})
-				Ω(err).ShouldNot(HaveOccurred())
-
-				container2, err = gardenClient.Create(garden.ContainerSpec{
-					Properties: garden.Properties{
-						"executor:owner": ownerName,
-					},
-				})
-				Ω(err).ShouldNot(HaveOccurred())
+				ownedHandles = helpers.CreateGardenContainers(gardenClient, ownerName, 2)
+				unownedHandles = helpers.CreateGardenContainers(gardenClient, "", 1)
 			})
 
 			It("deletes those containers (and only those containers)", func() {
-				Eventually(func() error {
-					_, err := gardenClient.Lookup(container1.Handle())
-					return err
-				}).Should(HaveOccurred())
-
-				Eventually(func() error {
-					_, err := gardenClient.Lookup(container2.Handle())
-					return err
-				}).Should(HaveOccurred())
+				Eventually(helpers.SurvivingContainersPoller(gardenClient, ownedHandles)).Should(BeEmpty())
+				Ω(helpers.SurvivingContainersPoller(gardenClient, unownedHandles)()).Should(Equal(unownedHandles))
 			})
 		})
 	})
 
 	Describe("when started", func() {
+		var accountant *helpers.ResourceAccountant
+
 		JustBeforeEach(func() {
 			process = ginkgomon.Invoke(runner)
+
+			accountant = helpers.NewResourceAccountant(executorClient)
+		})
+
+		AfterEach(func() {
+			accountant.ExpectNoLeaks()
 		})
 
 		Describe("pinging the server", func() {
@@ -336,11 +328,7 @@ var _ = Describe("Executor/Garden", func() {
 				})
 
 				It("reduces the capacity by the amount reserved", func() {
-					Ω(executorClient.RemainingResources()).Should(Equal(executor.ExecutorResources{
-						MemoryMB:   int(gardenCapacity.MemoryInBytes/1024/1024) - 256,
-						DiskMB:     int(gardenCapacity.DiskInBytes/1024/1024) - 256,
-						Containers: int(gardenCapacity.MaxContainers) - 1,
-					}))
+					accountant.ExpectReserved(256, 256, 1)
 				})
 			})
 
@@ -380,14 +368,51 @@ var _ = Describe("Executor/Garden", func() {
 			})
 
 			Context("when there is no room", func() {
+				const (
+					capacityMemoryMB = 256
+					capacityDiskMB   = 512
+				)
+
 				BeforeEach(func() {
-					container.MemoryMB = 999999999999999
-					container.DiskMB = 999999999999999
+					// whatever the host has, so that the container only just
+					// fails to fit
+					maker = componentMaker.WithExecutorCapacity(strconv.Itoa(capacityMemoryMB), strconv.Itoa(capacityDiskMB))
 				})
 
-				It("returns an error", func() {
-					Ω(allocErr).ShouldNot(HaveOccurred())
-					Ω(allocationErrorMap[container.Guid]).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+				Context("for its memory", func() {
+					BeforeEach(func() {
+						container.MemoryMB = capacityMemoryMB + 1
+						container.DiskMB = 1
+					})
+
+					It("returns an error", func() {
+						Ω(allocErr).ShouldNot(HaveOccurred())
+						Ω(allocationErrorMap[container.Guid]).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+					})
+				})
+
+				Context("for its disk", func() {
+					BeforeEach(func() {
+						container.MemoryMB = 1
+						container.DiskMB = capacityDiskMB + 1
+					})
+
+					It("returns an error", func() {
+						Ω(allocErr).ShouldNot(HaveOccurred())
+						Ω(allocationErrorMap[container.Guid]).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+					})
+				})
+
+				Context("when it only just fits", func() {
+					BeforeEach(func() {
+						container.MemoryMB = capacityMemoryMB
+						container.DiskMB = capacityDiskMB
+					})
+
+					It("allocates it", func() {
+						Ω(allocErr).ShouldNot(HaveOccurred())
+						Ω(allocationErrorMap).Should(BeEmpty())
+					})
 				})
 			})
 
@@ -420,29 +445,28 @@ var _ = Describe("Executor/Garden", func() {
 					})
 
 					It("creates it with the configured owner", func() {
-						info, err := gardenContainer.Info()
-						Ω(err).ShouldNot(HaveOccurred())
+						Ω(gardenx.Properties(gardenContainer)["executor:owner"]).Should(Equal(ownerName))
 
-						Ω(info.Properties["executor:owner"]).Should(Equal(ownerName))
+						owned := helpers.ContainersWithProperty(gardenClient, helpers.ContainerOwnerProperty, ownerName)
+						Ω(helpers.ContainerHandles(owned)).Should(ContainElement(guid))
 					})
 
-					It("sets global environment variables on the container", func() {
-						output := gbytes.NewBuffer()
+					It("keeps the container's state in its properties", func() {
+						helpers.WaitForContainerState(executorClient, guid, executor.StateCompleted)
 
-						process, err := gardenContainer.Run(garden.ProcessSpec{
-							Path: "env",
-						}, garden.ProcessIO{
-							Stdout: output,
-						})
-						Ω(err).ShouldNot(HaveOccurred())
-						Ω(process.Wait()).Should(Equal(0))
+						completed := helpers.ContainersWithProperty(gardenClient, helpers.ContainerStateProperty, string(executor.StateCompleted))
+						Ω(helpers.ContainerWithHandle(completed, guid).Owner()).Should(Equal(ownerName))
+					})
 
-						Ω(output.Contents()).Should(ContainSubstring("ENV1=val1"))
-						Ω(output.Contents()).Should(ContainSubstring("ENV2=val2"))
+					It("sets global environment variables on the container", func() {
+						output := gardenx.MustRun(gardenContainer, "env")
+
+						Ω(output).Should(ContainSubstring("ENV1=val1"))
+						Ω(output).Should(ContainSubstring("ENV2=val2"))
 					})
 
 					It("saves the succeeded run result", func() {
-						Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+						helpers.WaitForContainerState(executorClient, guid, executor.StateCompleted)
 
 						container := getContainer(guid)
 						Ω(container.RunResult.Failed).Should(BeFalse())
@@ -452,7 +476,7 @@ var _ = Describe("Executor/Garden", func() {
 					Context("when listening for events", func() {
 						It("emits a completed container event on completion", func() {
 							var event executor.Event
-							Eventually(containerEventPoller(eventSource, &event), 5).Should(Equal(executor.EventTypeContainerComplete))
+							Eventually(containerEventPoller(eventSource, &event), helpers.Timeouts.Short).Should(Equal(executor.EventTypeContainerComplete))
 
 							completeEvent := event.(executor.ContainerCompleteEvent)
 							Ω(completeEvent.Container().State).Should(Equal(executor.StateCompleted))
@@ -468,7 +492,7 @@ var _ = Describe("Executor/Garden", func() {
 									return err
 								}).Should(Equal(io.EOF))
 
-								Eventually(process.Wait(), 5).Should(Receive(BeNil()))
+								Eventually(process.Wait(), helpers.Timeouts.Short).Should(Receive(BeNil()))
 							})
 						})
 					})
@@ -482,7 +506,7 @@ var _ = Describe("Executor/Garden", func() {
 						})
 
 						It("reports the state as 'running'", func() {
-							Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+							helpers.WaitForContainerState(executorClient, guid, executor.StateRunning)
 							Consistently(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 						})
 					})
@@ -498,11 +522,11 @@ var _ = Describe("Executor/Garden", func() {
 
 								It("emits a running container event", func() {
 									var event executor.Event
-									Eventually(containerEventPoller(eventSource, &event), 5).Should(Equal(executor.EventTypeContainerRunning))
+									Eventually(containerEventPoller(eventSource, &event), helpers.Timeouts.Short).Should(Equal(executor.EventTypeContainerRunning))
 								})
 
 								It("reports the state as 'running'", func() {
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+									helpers.WaitForContainerState(executorClient, guid, executor.StateRunning)
 									Consistently(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 								})
 
@@ -542,8 +566,8 @@ var _ = Describe("Executor/Garden", func() {
 								})
 
 								It("reports the container as 'running' and then as 'completed'", func() {
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+									helpers.WaitForContainerState(executorClient, guid, executor.StateRunning)
+									helpers.WaitForContainerState(executorClient, guid, executor.StateCompleted)
 								})
 							})
 						}
@@ -584,7 +608,7 @@ var _ = Describe("Executor/Garden", func() {
 								})
 
 								It("stops the container", func() {
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+									helpers.WaitForContainerState(executorClient, guid, executor.StateCompleted)
 								})
 							})
 						})
@@ -592,14 +616,12 @@ var _ = Describe("Executor/Garden", func() {
 
 					Context("after running succeeds", func() {
 						Describe("deleting the container", func() {
-							It("works", func(done Done) {
-								defer close(done)
-
-								Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+							It("works", func() {
+								helpers.WaitForContainerState(executorClient, guid, executor.StateCompleted)
 
 								err := executorClient.DeleteContainer(guid)
 								Ω(err).ShouldNot(HaveOccurred())
-							}, 5)
+							})
 						})
 					})
 
@@ -611,7 +633,7 @@ var _ = Describe("Executor/Garden", func() {
 						})
 
 						It("saves the failed result and reason", func() {
-							Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+							helpers.WaitForContainerState(executorClient, guid, executor.StateCompleted)
 
 							container := getContainer(guid)
 							Ω(container.RunResult.Failed).Should(BeTrue())
@@ -621,7 +643,7 @@ var _ = Describe("Executor/Garden", func() {
 						Context("when listening for events", func() {
 							It("emits a completed container event", func() {
 								var event executor.Event
-								Eventually(containerEventPoller(eventSource, &event), 5).Should(Equal(executor.EventTypeContainerComplete))
+								Eventually(containerEventPoller(eventSource, &event), helpers.Timeouts.Short).Should(Equal(executor.EventTypeContainerComplete))
 
 								completeEvent := event.(executor.ContainerCompleteEvent)
 								Ω(completeEvent.Container().State).Should(Equal(executor.StateCompleted))
@@ -643,7 +665,7 @@ var _ = Describe("Executor/Garden", func() {
 
 					Context("when listening for events", func() {
 						It("eventually completes with failure", func() {
-							Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+							helpers.WaitForContainerState(executorClient, guid, executor.StateCompleted)
 
 							container := getContainer(guid)
 							Ω(container.RunResult.Failed).Should(BeTrue())
@@ -676,11 +698,7 @@ var _ = Describe("Executor/Garden", func() {
 					err := executorClient.DeleteContainer(guid)
 					Ω(err).ShouldNot(HaveOccurred())
 
-					Eventually(executorClient.RemainingResources).Should(Equal(executor.ExecutorResources{
-						MemoryMB:   int(gardenCapacity.MemoryInBytes / 1024 / 1024),
-						DiskMB:     int(gardenCapacity.DiskInBytes / 1024 / 1024),
-						Containers: int(gardenCapacity.MaxContainers),
-					}))
+					accountant.ExpectBaseline()
 				})
 			})
 
@@ -712,7 +730,7 @@ var _ = Describe("Executor/Garden", func() {
 				err := executorClient.RunContainer(guid)
 				Ω(err).ShouldNot(HaveOccurred())
 
-				Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+				helpers.WaitForContainerState(executorClient, guid, executor.StateRunning)
 			})
 
 			Describe("StopContainer", func() {
@@ -763,11 +781,7 @@ var _ = Describe("Executor/Garden", func() {
 
 			Describe("remaining resources", func() {
 				It("has the container's reservation subtracted", func() {
-					remaining, err := executorClient.RemainingResources()
-					Ω(err).ShouldNot(HaveOccurred())
-
-					Ω(remaining.MemoryMB).Should(Equal(int(gardenCapacity.MemoryInBytes/1024/1024) - 64))
-					Ω(remaining.DiskMB).Should(Equal(int(gardenCapacity.DiskInBytes/1024/1024) - 64))
+					accountant.ExpectReserved(64, 64, 1)
 				})
 
 				Context("when the container disappears", func() {
@@ -779,11 +793,7 @@ var _ = Describe("Executor/Garden", func() {
 						err := gardenClient.Destroy(guid)
 						Ω(err).ShouldNot(HaveOccurred())
 
-						Eventually(executorClient.RemainingResources).Should(Equal(executor.ExecutorResources{
-							MemoryMB:   int(gardenCapacity.MemoryInBytes / 1024 / 1024),
-							DiskMB:     int(gardenCapacity.DiskInBytes / 1024 / 1024),
-							Containers: int(gardenCapacity.MaxContainers),
-						}))
+						accountant.ExpectBaseline()
 					})
 				})
 			})
@@ -830,12 +840,7 @@ var _ = Describe("Executor/Garden", func() {
 
 					container = findGardenContainer(guid)
 
-					process, err := container.Run(garden.ProcessSpec{
-						Path: "sh",
-						Args: []string{"-c", "mkdir some; echo hello > some/path"},
-					}, garden.ProcessIO{})
-					Ω(err).ShouldNot(HaveOccurred())
-					Ω(process.Wait()).Should(Equal(0))
+					gardenx.MustRun(container, "sh", "-c", "mkdir some; echo hello > some/path")
 
 					stream, streamErr = executorClient.GetFiles(guid, "some/path")
 				})
@@ -875,21 +880,72 @@ var _ = Describe("Executor/Garden", func() {
 					Ω(err).ShouldNot(HaveOccurred())
 
 					return containers
-				}, pruningInterval*3).Should(BeEmpty())
+				}, helpers.Timeouts.Short).Should(BeEmpty())
+			})
+		})
+
+		Describe("garbage collecting orphaned containers", func() {
+			var unownedHandles, otherOwnerHandles []string
+
+			JustBeforeEach(func() {
+				unownedHandles = helpers.CreateGardenContainers(gardenClient, "", 2)
+				otherOwnerHandles = helpers.CreateGardenContainers(gardenClient, "some-other-executor", 2)
+			})
+
+			AfterEach(func() {
+				helpers.ExpectNoOrphanContainers(gardenClient, ownerName)
+			})
+
+			It("continuously prunes containers it owns but is not tracking", func() {
+				orphanedHandles := helpers.CreateGardenContainers(gardenClient, ownerName, 2)
+
+				Eventually(helpers.SurvivingContainersPoller(gardenClient, orphanedHandles), helpers.Timeouts.Short).Should(BeEmpty())
+
+				By("pruning containers that appear later, too")
+				orphanedHandles = helpers.CreateGardenContainers(gardenClient, ownerName, 1)
+
+				Eventually(helpers.SurvivingContainersPoller(gardenClient, orphanedHandles), helpers.Timeouts.Short).Should(BeEmpty())
+			})
+
+			It("leaves containers it does not own alone", func() {
+				Consistently(helpers.SurvivingContainersPoller(gardenClient, unownedHandles), 4*pruningInterval).Should(Equal(unownedHandles))
+				Ω(helpers.SurvivingContainersPoller(gardenClient, otherOwnerHandles)()).Should(Equal(otherOwnerHandles))
+			})
+
+			It("does not prune the containers it has allocated", func() {
+				guid := allocNewContainer(executor.Container{
+					MemoryMB: 64,
+					DiskMB:   64,
+
+					Action: &models.RunAction{
+						Path: "sh",
+						Args: []string{"-c", "while true; do sleep 1; done"},
+					},
+				})
+
+				err := executorClient.RunContainer(guid)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				helpers.WaitForContainerState(executorClient, guid, executor.StateRunning)
+
+				Consistently(helpers.OwnedContainersPoller(gardenClient, ownerName), 4*pruningInterval).Should(Equal([]string{guid}))
+
+				err = executorClient.DeleteContainer(guid)
+				Ω(err).ShouldNot(HaveOccurred())
 			})
 		})
 
 		Describe("when the executor receives the TERM signal", func() {
 			It("exits successfully", func() {
 				process.Signal(syscall.SIGTERM)
-				Eventually(runner, 2).Should(gexec.Exit())
+				Eventually(runner, shutdownTimeout).Should(gexec.Exit())
 			})
 		})
 
 		Describe("when the executor receives the INT signal", func() {
 			It("exits successfully", func() {
 				process.Signal(syscall.SIGINT)
-				Eventually(runner, 2).Should(gexec.Exit())
+				Eventually(runner, shutdownTimeout).Should(gexec.Exit())
 			})
 		})
 
@@ -977,7 +1033,7 @@ var _ = Describe("Executor/Garden", func() {
 					err := executorClient.RunContainer(guid)
 					Ω(err).ShouldNot(HaveOccurred())
 
-					Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+					helpers.WaitForContainerState(executorClient, guid, executor.StateRunning)
 
 					container := getContainer(guid)
 
@@ -1031,7 +1087,7 @@ var _ = Describe("Executor/Garden", func() {
 			})
 
 			It("should connect", func() {
-				Eventually(runner.Buffer(), 5*time.Second).Should(gbytes.Say("started"))
+				Eventually(runner.Buffer(), helpers.Timeouts.Short).Should(gbytes.Say("started"))
 			})
 		})
 
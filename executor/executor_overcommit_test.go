@@ -0,0 +1,168 @@
+package executor_test
+
+import (
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Executor capacity", func() {
+	var (
+		maker world.ComponentMaker
+
+		memoryMB string
+		diskMB   string
+
+		executorClient executor.Client
+		process        ifrit.Process
+
+		physical executor.ExecutorResources
+	)
+
+	allocate := func(memoryMB, diskMB int) string {
+		guid := helpers.NewGuid("container")
+
+		allocationErrors, err := executorClient.AllocateContainers([]executor.Container{{
+			Guid:     guid,
+			MemoryMB: memoryMB,
+			DiskMB:   diskMB,
+		}})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return allocationErrors[guid]
+	}
+
+	BeforeEach(func() {
+		maker = componentMaker
+
+		capacity, err := gardenClient.Capacity()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		physical = executor.ExecutorResources{
+			MemoryMB:   int(capacity.MemoryInBytes / 1024 / 1024),
+			DiskMB:     int(capacity.DiskInBytes / 1024 / 1024),
+			Containers: int(capacity.MaxContainers),
+		}
+	})
+
+	JustBeforeEach(func() {
+		process = ginkgomon.Invoke(maker.WithExecutorCapacity(memoryMB, diskMB).Executor())
+		executorClient = maker.ExecutorClient()
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(process)
+	})
+
+	Context("with automatic capacity", func() {
+		BeforeEach(func() {
+			memoryMB = world.AutoCapacity
+			diskMB = world.AutoCapacity
+		})
+
+		It("advertises what Garden has", func() {
+			Ω(executorClient.TotalResources()).Should(Equal(physical))
+		})
+	})
+
+	Context("when overcommitted", func() {
+		var overcommitted executor.ExecutorResources
+
+		BeforeEach(func() {
+			overcommitted = executor.ExecutorResources{
+				MemoryMB:   physical.MemoryMB * 2,
+				DiskMB:     physical.DiskMB * 3,
+				Containers: physical.Containers,
+			}
+
+			memoryMB = strconv.Itoa(overcommitted.MemoryMB)
+			diskMB = strconv.Itoa(overcommitted.DiskMB)
+		})
+
+		It("advertises the configured capacity, not the physical one", func() {
+			Ω(executorClient.TotalResources()).Should(Equal(overcommitted))
+		})
+
+		It("allocates beyond the physical capacity, from the overcommitted pool", func() {
+			accountant := helpers.NewResourceAccountant(executorClient)
+
+			Ω(allocate(physical.MemoryMB+1, physical.DiskMB+1)).Should(BeEmpty())
+			accountant.ExpectReserved(physical.MemoryMB+1, physical.DiskMB+1, 1)
+
+			Ω(allocate(physical.MemoryMB-2, physical.DiskMB-2)).Should(BeEmpty())
+			accountant.ExpectReserved(2*physical.MemoryMB-1, 2*physical.DiskMB-1, 2)
+
+			accountant.ExpectNoLeaks()
+		})
+
+		It("still refuses what the overcommitted pool cannot fit", func() {
+			Ω(allocate(overcommitted.MemoryMB+1, 1)).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+			Ω(allocate(1, overcommitted.DiskMB+1)).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+
+			Ω(allocate(overcommitted.MemoryMB, overcommitted.DiskMB)).Should(BeEmpty())
+			Ω(allocate(1, 1)).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+		})
+	})
+
+	Context("with an explicit capacity below the physical one", func() {
+		BeforeEach(func() {
+			memoryMB = "256"
+			diskMB = "512"
+		})
+
+		It("advertises and enforces it", func() {
+			Ω(executorClient.TotalResources()).Should(Equal(executor.ExecutorResources{
+				MemoryMB:   256,
+				DiskMB:     512,
+				Containers: physical.Containers,
+			}))
+
+			Ω(allocate(257, 1)).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+			Ω(allocate(256, 512)).Should(BeEmpty())
+		})
+	})
+
+	Context("when Garden's capacity is pinned", func() {
+		var pinned ifrit.Process
+
+		BeforeEach(func() {
+			memoryMB = world.AutoCapacity
+			diskMB = world.AutoCapacity
+
+			maker = maker.WithGardenCapacity(helpers.PinnedGardenCapacity)
+			pinned = ginkgomon.Invoke(maker.FakeGardenCapacity())
+		})
+
+		AfterEach(func() {
+			helpers.StopProcesses(pinned)
+		})
+
+		It("advertises the pinned capacity, whatever the machine has", func() {
+			Ω(executorClient.TotalResources()).Should(Equal(executor.ExecutorResources{
+				MemoryMB:   1024,
+				DiskMB:     2048,
+				Containers: 4,
+			}))
+		})
+
+		It("runs out of room for containers at the pinned limit", func() {
+			for i := 0; i < 4; i++ {
+				Ω(allocate(1, 1)).Should(BeEmpty())
+			}
+
+			Ω(allocate(1, 1)).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+		})
+
+		It("runs out of room for memory at the pinned limit", func() {
+			Ω(allocate(1025, 1)).Should(Equal(executor.ErrInsufficientResourcesAvailable.Error()))
+			Ω(allocate(1024, 1)).Should(BeEmpty())
+		})
+	})
+})
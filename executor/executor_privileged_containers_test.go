@@ -2,8 +2,9 @@ package executor_test
 
 import (
 	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
-	"github.com/nu7hatch/gouuid"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 
@@ -13,15 +14,13 @@ import (
 
 var _ = Describe("Privileged Containers", func() {
 	var process ifrit.Process
-	var runner *ginkgomon.Runner
+	var runner *world.TimedRunner
 
 	Context("when trying to run a container with a privileged run action", func() {
 		var runResult executor.ContainerRunResult
 
 		JustBeforeEach(func() {
-			uuid, err := uuid.NewV4()
-			Ω(err).ShouldNot(HaveOccurred())
-			containerGuid := uuid.String()
+			containerGuid := helpers.NewGuid("container")
 
 			container := executor.Container{
 				Guid: containerGuid,
@@ -34,7 +33,7 @@ var _ = Describe("Privileged Containers", func() {
 
 			executorClient := componentMaker.ExecutorClient()
 
-			_, err = executorClient.AllocateContainers([]executor.Container{container})
+			_, err := executorClient.AllocateContainers([]executor.Container{container})
 			Ω(err).ShouldNot(HaveOccurred())
 
 			err = executorClient.RunContainer(containerGuid)
@@ -58,6 +57,7 @@ var _ = Describe("Privileged Containers", func() {
 			})
 
 			AfterEach(func() {
+				helpers.DeleteExecutorContainers(componentMaker.ExecutorClient())
 				ginkgomon.Kill(process)
 			})
 
@@ -73,6 +73,7 @@ var _ = Describe("Privileged Containers", func() {
 			})
 
 			AfterEach(func() {
+				helpers.DeleteExecutorContainers(componentMaker.ExecutorClient())
 				ginkgomon.Kill(process)
 			})
 
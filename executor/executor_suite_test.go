@@ -10,8 +10,6 @@ import (
 	"github.com/onsi/gomega/gexec"
 	"github.com/pivotal-golang/lager/ginkgoreporter"
 	"github.com/tedsuo/ifrit"
-	"github.com/tedsuo/ifrit/ginkgomon"
-	"github.com/tedsuo/ifrit/grouper"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/inigo/helpers"
@@ -23,6 +21,8 @@ var (
 
 	gardenProcess ifrit.Process
 	gardenClient  garden.Client
+
+	leakDetector *helpers.ContainerLeakDetector
 )
 
 var _ = SynchronizedBeforeSuite(func() []byte {
@@ -41,27 +41,48 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 	componentMaker = helpers.MakeComponentMaker(builtArtifacts)
 })
 
+var _ = AfterSuite(func() {
+	componentMaker.Timings.WriteReport("executor")
+	componentMaker.TempDirs.RemoveAll()
+})
+
 var _ = BeforeEach(func() {
-	gardenProcess = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
-		{"garden-linux", componentMaker.GardenLinux()},
-	}))
+	componentMaker.Timings.StartSpec()
+	helpers.NameSpecGuids(componentMaker.Timings.SpecID())
+
+	environment := world.BootstrapPlumbing(world.BootstrapConfig{
+		Maker:    componentMaker,
+		Plumbing: []string{"garden-linux"},
+	})
 
-	gardenClient = componentMaker.GardenClient()
+	gardenProcess = environment.Processes["garden-linux"]
+	gardenClient = environment.GardenClient
+
+	leakDetector = helpers.NewContainerLeakDetector(gardenClient)
+	componentMaker = componentMaker.WithContainerOwner(leakDetector.Owner)
 })
 
 var _ = AfterEach(func() {
+	defer componentMaker.Timings.FinishSpec()
+
+	leaks := leakDetector.Leaks()
+
 	destroyContainerErrors := helpers.CleanupGarden(gardenClient)
 
-	helpers.StopProcesses(gardenProcess)
+	// specs that restart garden leave the new one here
+	helpers.Teardown(map[string]ifrit.Process{"garden-linux": gardenProcess})
 
 	Ω(destroyContainerErrors).Should(
 		BeEmpty(),
 		"%d containers failed to be destroyed!",
 		len(destroyContainerErrors),
 	)
+
+	Ω(leaks).Should(BeEmpty(), "the spec left %d of its containers behind: %v", len(leaks), leaks)
 })
 
 func TestExecutor(t *testing.T) {
+	helpers.LoadConfig()
 	helpers.RegisterDefaultTimeouts()
 
 	RegisterFailHandler(Fail)
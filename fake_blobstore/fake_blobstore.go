@@ -0,0 +1,344 @@
+package fake_blobstore
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+// FakeBlobstore serves blobs over HTTP the way a real blobstore would, with
+// ETags, Last-Modified and Content-MD5 headers, and can be told to misbehave per blob.
+type FakeBlobstore struct {
+	address string
+
+	blobs    map[string]*blob
+	requests []Request
+	lock     *sync.RWMutex
+}
+
+type Request struct {
+	Name       string
+	Method     string
+	Header     http.Header
+	ReceivedAt time.Time
+
+	// the status FakeBlobstore responded with
+	RespondedWith int
+
+	// whether the connection was reset before the whole blob was written
+	Reset bool
+}
+
+type blob struct {
+	body       []byte
+	etag       string
+	modifiedAt time.Time
+
+	omitETag bool
+
+	bytesPerSecond int
+
+	resetAfterBytes int
+	resetsRemaining int
+
+	corruptChecksum bool
+}
+
+func New(address string) *FakeBlobstore {
+	return &FakeBlobstore{
+		address: address,
+
+		blobs:    map[string]*blob{},
+		requests: []Request{},
+		lock:     new(sync.RWMutex),
+	}
+}
+
+func (f *FakeBlobstore) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	err := http_server.New(f.address, f).Run(signals, ready)
+
+	f.Reset()
+
+	return err
+}
+
+func (f *FakeBlobstore) Address() string {
+	return "http://" + f.address
+}
+
+func (f *FakeBlobstore) URL(name string) string {
+	return f.Address() + "/blobs/" + name
+}
+
+// SetBlob stores the blob under the given name, replacing its content, ETag
+// and Last-Modified but keeping any misbehavior configured for it.
+func (f *FakeBlobstore) SetBlob(name string, body []byte) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	checksum := md5.Sum(body)
+
+	b := f.blob(name)
+	b.body = body
+	b.etag = `"` + hex.EncodeToString(checksum[:]) + `"`
+
+	// Last-Modified only has seconds, so make sure it changes even when the
+	// blob does twice in the same one
+	modifiedAt := time.Now().UTC().Truncate(time.Second)
+	if !modifiedAt.After(b.modifiedAt) {
+		modifiedAt = b.modifiedAt.Add(time.Second)
+	}
+	b.modifiedAt = modifiedAt
+}
+
+// ETag returns the ETag the blob is currently served with.
+func (f *FakeBlobstore) ETag(name string) string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if b, found := f.blobs[name]; found {
+		return b.etag
+	}
+
+	return ""
+}
+
+// LastModified returns the time the blob is currently served as last
+// modified at.
+func (f *FakeBlobstore) LastModified(name string) time.Time {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if b, found := f.blobs[name]; found {
+		return b.modifiedAt
+	}
+
+	return time.Time{}
+}
+
+// OmitETag serves the blob without an ETag, so that it can only be
+// revalidated by its Last-Modified.
+func (f *FakeBlobstore) OmitETag(name string, omit bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.blob(name).omitETag = omit
+}
+
+// Throttle limits how fast the blob is written; 0 removes the limit.
+func (f *FakeBlobstore) Throttle(name string, bytesPerSecond int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.blob(name).bytesPerSecond = bytesPerSecond
+}
+
+// ResetConnectionsAfter makes the next times downloads of the blob drop the
+// connection after writing the given number of bytes.
+func (f *FakeBlobstore) ResetConnectionsAfter(name string, bytes int, times int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	b := f.blob(name)
+	b.resetAfterBytes = bytes
+	b.resetsRemaining = times
+}
+
+// CorruptChecksum makes the blob's Content-MD5 header disagree with its
+// content.
+func (f *FakeBlobstore) CorruptChecksum(name string, corrupt bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.blob(name).corruptChecksum = corrupt
+}
+
+// Requests returns every request made for the blob, in order.
+func (f *FakeBlobstore) Requests(name string) []Request {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	requests := []Request{}
+	for _, request := range f.requests {
+		if request.Name == name {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+func (f *FakeBlobstore) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.blobs = map[string]*blob{}
+	f.requests = []Request{}
+}
+
+func (f *FakeBlobstore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE BLOBSTORE] Handling request: %s %s\n", r.Method, r.URL.Path)
+
+	request := Request{
+		Name:       strings.TrimPrefix(r.URL.Path, "/blobs/"),
+		Method:     r.Method,
+		Header:     r.Header,
+		ReceivedAt: time.Now(),
+	}
+
+	request.RespondedWith, request.Reset = f.serve(w, r, request.Name)
+
+	f.lock.Lock()
+	f.requests = append(f.requests, request)
+	f.lock.Unlock()
+}
+
+func (f *FakeBlobstore) serve(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return http.StatusMethodNotAllowed, false
+	}
+
+	f.lock.Lock()
+	b, found := f.blobs[name]
+	if !found || b.body == nil {
+		f.lock.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+		return http.StatusNotFound, false
+	}
+
+	body := b.body
+	etag := b.etag
+	if b.omitETag {
+		etag = ""
+	}
+	modifiedAt := b.modifiedAt
+	bytesPerSecond := b.bytesPerSecond
+	corruptChecksum := b.corruptChecksum
+
+	resetAfterBytes := -1
+	if b.resetsRemaining > 0 {
+		b.resetsRemaining--
+		resetAfterBytes = b.resetAfterBytes
+	}
+	f.lock.Unlock()
+
+	if notModified(r, etag, modifiedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return http.StatusNotModified, false
+	}
+
+	checksum := md5.Sum(body)
+	if corruptChecksum {
+		checksum[0] ^= 0xff
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Last-Modified", modifiedAt.Format(http.TimeFormat))
+	w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(checksum[:]))
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == "HEAD" {
+		return http.StatusOK, false
+	}
+
+	if resetAfterBytes >= 0 && resetAfterBytes < len(body) {
+		writeThrottled(w, body[:resetAfterBytes], bytesPerSecond)
+		resetConnection(w)
+		return http.StatusOK, true
+	}
+
+	writeThrottled(w, body, bytesPerSecond)
+
+	return http.StatusOK, false
+}
+
+// notModified follows RFC 7232: If-None-Match wins over If-Modified-Since
+// when a request has both.
+func notModified(r *http.Request, etag string, modifiedAt time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etag != "" && ifNoneMatch == etag
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !modifiedAt.After(ifModifiedSince)
+}
+
+func writeThrottled(w http.ResponseWriter, body []byte, bytesPerSecond int) {
+	if bytesPerSecond <= 0 {
+		w.Write(body)
+		return
+	}
+
+	// write in 10 chunks a second
+	chunkSize := bytesPerSecond / 10
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+
+	for len(body) > 0 {
+		n := chunkSize
+		if n > len(body) {
+			n = len(body)
+		}
+
+		_, err := w.Write(body[:n])
+		if err != nil {
+			return
+		}
+
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		body = body[n:]
+
+		time.Sleep(time.Duration(n) * time.Second / time.Duration(bytesPerSecond))
+	}
+}
+
+func resetConnection(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	conn.Close()
+}
+
+// blob must be called with the lock held.
+func (f *FakeBlobstore) blob(name string) *blob {
+	b, found := f.blobs[name]
+	if !found {
+		b = &blob{}
+		f.blobs[name] = b
+	}
+
+	return b
+}
@@ -2,16 +2,22 @@ package fake_cc
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
 	"github.com/onsi/ginkgo"
@@ -34,43 +40,177 @@ const (
             }
         }
     `
+	jobResponseBody = `
+        {
+            "metadata":{
+                "guid": "%[1]s",
+                "url": "/v2/jobs/%[1]s"
+            },
+            "entity": {
+                "status": "%[2]s"
+            }
+        }
+    `
 )
 
+type StagingCallback struct {
+	StagingGuid string
+	Header      http.Header
+	Body        []byte
+	Response    cc_messages.StagingResponseForCC
+	ReceivedAt  time.Time
+
+	// the connection's TLS state, if FakeCC is serving HTTPS
+	TLS *tls.ConnectionState
+
+	// the status code FakeCC responded to the callback with
+	RespondedWith int
+}
+
+// AppCrashedRequest is what the watcher tells CC about a crashed instance.
+type AppCrashedRequest struct {
+	Instance        string `json:"instance"`
+	Index           int    `json:"index"`
+	Reason          string `json:"reason"`
+	ExitStatus      int    `json:"exit_status"`
+	ExitDescription string `json:"exit_description"`
+	CrashCount      int    `json:"crash_count"`
+	CrashTimestamp  int64  `json:"crash_timestamp"`
+}
+
+type AppCrash struct {
+	AppGuid    string
+	Request    AppCrashedRequest
+	ReceivedAt time.Time
+
+	// the status code FakeCC responded to the report with
+	RespondedWith int
+}
+
+// Request is a request FakeCC received, as recorded in its journal.
+type Request struct {
+	Method     string
+	Path       string
+	Query      url.Values
+	Header     http.Header
+	ReceivedAt time.Time
+
+	// the status code FakeCC responded with
+	RespondedWith int
+}
+
+// MaxKeptUploadBytes is the most of an uploaded droplet FakeCC keeps; of
+// bigger ones, it only keeps a digest, so that large droplets do not have to
+// fit in the test's memory.
+const MaxKeptUploadBytes = 1024 * 1024
+
+// UploadDigest is how big an upload was and its hex SHA-256.
+type UploadDigest struct {
+	Size   int64
+	SHA256 string
+
+	// whether it was bigger than MaxKeptUploadBytes, so that FakeCC only
+	// kept the start of it
+	Truncated bool
+}
+
+// FakeCC keeps all of its state to itself, so that each parallel node can
+// run its own on its own address (see ComponentMaker.Addresses.FakeCC).
 type FakeCC struct {
-	address string
+	address   string
+	tlsConfig *tls.Config
 
-	UploadedDroplets             map[string][]byte
-	UploadedBuildArtifactsCaches map[string][]byte
+	uploadedDroplets             map[string][]byte
+	uploadedDropletDigests       map[string]UploadDigest
+	maxDropletUploadBytes        int64
+	uploadedBuildArtifactsCaches map[string][]byte
 	stagingGuids                 []string
 	stagingResponses             []cc_messages.StagingResponseForCC
+	stagingCallbacks             []StagingCallback
 	stagingResponseStatusCode    int
 	stagingResponseBody          string
+	expectedStagingResponses     map[string]cc_messages.StagingResponseForCC
+	appCrashes                   []AppCrash
+	appCrashedResponseStatusCode int
+	dropletJobPolls              int
+	jobPolls                     map[string]int
+	requests                     []Request
 	lock                         *sync.RWMutex
 }
 
+// New returns a FakeCC that will listen on the given address once run. The
+// address must be the parallel node's own, as handed out by ComponentMaker,
+// so that nodes never see each other's requests.
 func New(address string) *FakeCC {
+	Ω(address).ShouldNot(BeEmpty(), "FakeCC needs an address of its own")
+
 	return &FakeCC{
 		address: address,
 
-		UploadedDroplets:             map[string][]byte{},
-		UploadedBuildArtifactsCaches: map[string][]byte{},
+		uploadedDroplets:             map[string][]byte{},
+		uploadedDropletDigests:       map[string]UploadDigest{},
+		uploadedBuildArtifactsCaches: map[string][]byte{},
 		stagingGuids:                 []string{},
 		stagingResponses:             []cc_messages.StagingResponseForCC{},
+		stagingCallbacks:             []StagingCallback{},
 		stagingResponseStatusCode:    http.StatusOK,
 		stagingResponseBody:          "{}",
+		expectedStagingResponses:     map[string]cc_messages.StagingResponseForCC{},
+		appCrashes:                   []AppCrash{},
+		appCrashedResponseStatusCode: http.StatusOK,
+		jobPolls:                     map[string]int{},
+		requests:                     []Request{},
 		lock:                         new(sync.RWMutex),
 	}
 }
 
+// NewTLS returns a FakeCC serving HTTPS with the given configuration, e.g.
+// one from tlsfixtures.Credentials.ServerConfig.
+func NewTLS(address string, tlsConfig *tls.Config) *FakeCC {
+	f := New(address)
+	f.tlsConfig = tlsConfig
+	return f
+}
+
 func (f *FakeCC) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
-	err := http_server.New(f.address, f).Run(signals, ready)
+	var err error
+	if f.tlsConfig == nil {
+		err = http_server.New(f.address, f).Run(signals, ready)
+	} else {
+		err = f.runTLS(signals, ready)
+	}
 
 	f.Reset()
 
 	return err
 }
 
+func (f *FakeCC) runTLS(signals <-chan os.Signal, ready chan<- struct{}) error {
+	listener, err := net.Listen("tcp", f.address)
+	if err != nil {
+		return err
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- http.Serve(tls.NewListener(listener, f.tlsConfig), f)
+	}()
+
+	close(ready)
+
+	select {
+	case <-signals:
+		return listener.Close()
+	case err := <-serveErr:
+		return err
+	}
+}
+
 func (f *FakeCC) Address() string {
+	if f.tlsConfig != nil {
+		return "https://" + f.address
+	}
+
 	return "http://" + f.address
 }
 
@@ -85,22 +225,180 @@ func (f *FakeCC) Password() string {
 func (f *FakeCC) Reset() {
 	f.lock.Lock()
 	defer f.lock.Unlock()
-	f.UploadedDroplets = map[string][]byte{}
-	f.UploadedBuildArtifactsCaches = map[string][]byte{}
+	f.uploadedDroplets = map[string][]byte{}
+	f.uploadedDropletDigests = map[string]UploadDigest{}
+	f.maxDropletUploadBytes = 0
+	f.uploadedBuildArtifactsCaches = map[string][]byte{}
 	f.stagingGuids = []string{}
 	f.stagingResponses = []cc_messages.StagingResponseForCC{}
+	f.stagingCallbacks = []StagingCallback{}
 	f.stagingResponseStatusCode = http.StatusOK
 	f.stagingResponseBody = "{}"
+	f.expectedStagingResponses = map[string]cc_messages.StagingResponseForCC{}
+	f.appCrashes = []AppCrash{}
+	f.appCrashedResponseStatusCode = http.StatusOK
+	f.dropletJobPolls = 0
+	f.jobPolls = map[string]int{}
+	f.requests = []Request{}
+}
+
+// Requests returns every request in the journal, in order.
+func (f *FakeCC) Requests() []Request {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return append([]Request{}, f.requests...)
+}
+
+// RequestsTo returns the requests in the journal whose path matches the
+// pattern, in order.
+func (f *FakeCC) RequestsTo(pathPattern string) []Request {
+	re := regexp.MustCompile(pathPattern)
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	requests := []Request{}
+	for _, request := range f.requests {
+		if re.MatchString(request.Path) {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+// ClearRequests empties the journal, leaving everything else, e.g. uploaded
+// droplets and configured responses, as it is.
+func (f *FakeCC) ClearRequests() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests = []Request{}
 }
 
 func (f *FakeCC) SetStagingResponseStatusCode(statusCode int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
 	f.stagingResponseStatusCode = statusCode
 }
 
 func (f *FakeCC) SetStagingResponseBody(body string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
 	f.stagingResponseBody = body
 }
 
+// SetExpectedStagingResponse makes FakeCC reject, with a 400, any staging
+// callback for the guid that does not report the given response. The
+// rejection shows up in the callback's RespondedWith.
+func (f *FakeCC) SetExpectedStagingResponse(stagingGuid string, response cc_messages.StagingResponseForCC) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.expectedStagingResponses[stagingGuid] = response
+}
+
+// SetDropletJobPolls makes FakeCC answer asynchronous droplet uploads the
+// way CC does, with a queued job that finishes once it has been polled the
+// given number of times. By default such uploads finish straight away.
+func (f *FakeCC) SetDropletJobPolls(polls int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.dropletJobPolls = polls
+}
+
+// DropletJobPolls returns how many times the job for the app's asynchronous
+// droplet upload has been polled.
+func (f *FakeCC) DropletJobPolls(appGuid string) int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.jobPolls[dropletJobGuid(appGuid)]
+}
+
+// UploadedDroplet returns the droplet uploaded for the app, or nil if none
+// was. It fails if the droplet was bigger than MaxKeptUploadBytes, rather
+// than pass off the start of it as all of it; check those with
+// UploadedDropletDigest.
+func (f *FakeCC) UploadedDroplet(appGuid string) []byte {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	digest := f.uploadedDropletDigests[appGuid]
+	Ω(digest.Truncated).Should(BeFalse(), "the droplet for %s was %d bytes, too big to keep; see UploadedDropletDigest", appGuid, digest.Size)
+
+	return f.uploadedDroplets[appGuid]
+}
+
+// WasUploaded says whether a droplet was uploaded for the app, however big.
+// Droplets FakeCC refused, e.g. past SetMaxDropletUploadBytes, were not.
+func (f *FakeCC) WasUploaded(appGuid string) bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	_, found := f.uploadedDropletDigests[appGuid]
+	return found
+}
+
+// UploadedDropletSize returns how big the droplet uploaded for the app was,
+// however big, or 0 if none was.
+func (f *FakeCC) UploadedDropletSize(appGuid string) int64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.uploadedDropletDigests[appGuid].Size
+}
+
+// UploadedDropletDigest returns the digest of the droplet uploaded for the
+// app, however big it was.
+func (f *FakeCC) UploadedDropletDigest(appGuid string) (UploadDigest, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	digest, found := f.uploadedDropletDigests[appGuid]
+	return digest, found
+}
+
+// SetMaxDropletUploadBytes makes FakeCC refuse droplets bigger than bytes
+// with 413 Request Entity Too Large, as CC does past its upload limit; 0
+// removes the limit.
+func (f *FakeCC) SetMaxDropletUploadBytes(bytes int64) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.maxDropletUploadBytes = bytes
+}
+
+// UploadedBuildArtifactsCache returns the build artifacts cache uploaded
+// for the app, if any.
+func (f *FakeCC) UploadedBuildArtifactsCache(appGuid string) []byte {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.uploadedBuildArtifactsCaches[appGuid]
+}
+
+// DropletUploadURI is where the app's droplet is uploaded to,
+// asynchronously, credentials included.
+func (f *FakeCC) DropletUploadURI(appGuid string) string {
+	return f.stagingURI("/staging/droplets/"+appGuid+"/upload", "async=true")
+}
+
+// BuildArtifactsCacheUploadURI is where the app's build artifacts cache is
+// uploaded to, credentials included.
+func (f *FakeCC) BuildArtifactsCacheUploadURI(appGuid string) string {
+	return f.stagingURI("/staging/buildpack_cache/"+appGuid+"/upload", "")
+}
+
+// BuildArtifactsCacheDownloadURI is where the app's build artifacts cache,
+// as last uploaded, is downloaded from, credentials included.
+func (f *FakeCC) BuildArtifactsCacheDownloadURI(appGuid string) string {
+	return f.stagingURI("/staging/buildpack_cache/"+appGuid+"/download", "")
+}
+
+func (f *FakeCC) stagingURI(path string, query string) string {
+	u, err := url.Parse(f.Address())
+	Ω(err).ShouldNot(HaveOccurred())
+
+	u.User = url.UserPassword(CC_USERNAME, CC_PASSWORD)
+	u.Path = path
+	u.RawQuery = query
+
+	return u.String()
+}
+
 func (f *FakeCC) StagingGuids() []string {
 	f.lock.RLock()
 	defer f.lock.RUnlock()
@@ -113,14 +411,68 @@ func (f *FakeCC) StagingResponses() []cc_messages.StagingResponseForCC {
 	return f.stagingResponses
 }
 
+// StagingCallbacks returns every staging completion callback received, in
+// order, including those that were responded to with an error.
+func (f *FakeCC) StagingCallbacks() []StagingCallback {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return append([]StagingCallback{}, f.stagingCallbacks...)
+}
+
+// SetAppCrashedResponseStatusCode makes FakeCC answer crash reports with
+// the given status code, e.g. 503 to play a CC that is down. The reports
+// are still recorded, with the code in their RespondedWith.
+func (f *FakeCC) SetAppCrashedResponseStatusCode(statusCode int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.appCrashedResponseStatusCode = statusCode
+}
+
+// AppCrashes returns every crash reported for an app, in order, including
+// those that were responded to with an error.
+func (f *FakeCC) AppCrashes(appGuid string) []AppCrash {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	crashes := []AppCrash{}
+	for _, crash := range f.appCrashes {
+		if crash.AppGuid == appGuid {
+			crashes = append(crashes, crash)
+		}
+	}
+
+	return crashes
+}
+
 func (f *FakeCC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Handling request: %s\n", r.URL.Path)
 
+	request := Request{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Query:      r.URL.Query(),
+		Header:     r.Header,
+		ReceivedAt: time.Now(),
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		request.RespondedWith = recorder.status
+
+		f.lock.Lock()
+		f.requests = append(f.requests, request)
+		f.lock.Unlock()
+	}()
+
+	w = recorder
+
 	endpoints := map[string]func(http.ResponseWriter, *http.Request){
 		"/staging/droplets/.*/upload":          f.handleDropletUploadRequest,
 		"/staging/buildpack_cache/.*/upload":   f.handleBuildArtifactsCacheUploadRequest,
 		"/staging/buildpack_cache/.*/download": f.handleBuildArtifactsCacheDownloadRequest,
 		"/internal/staging/.*/completed":       f.newHandleStagingRequest(),
+		"/internal/apps/.*/crashed":            f.newHandleAppCrashedRequest(),
+		"/v2/jobs/.*":                          f.handleJobRequest,
 	}
 
 	for pattern, handler := range endpoints {
@@ -135,6 +487,18 @@ func (f *FakeCC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ginkgo.Fail(fmt.Sprintf("[FAKE CC] No matching endpoint handler for %s", r.URL.Path))
 }
 
+// statusRecorder remembers the status code written through it, for the
+// journal.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
 func (f *FakeCC) handleDropletUploadRequest(w http.ResponseWriter, r *http.Request) {
 	basicAuthVerifier := ghttp.VerifyBasicAuth(CC_USERNAME, CC_PASSWORD)
 	basicAuthVerifier(w, r)
@@ -143,19 +507,80 @@ func (f *FakeCC) handleDropletUploadRequest(w http.ResponseWriter, r *http.Reque
 	file, _, err := r.FormFile(key)
 	Ω(err).ShouldNot(HaveOccurred())
 
-	uploadedBytes, err := ioutil.ReadAll(file)
+	// keep the start of it, and hash all of it as it goes by
+	kept := new(bytes.Buffer)
+	hash := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(hash, &limitedWriter{kept, MaxKeptUploadBytes}), file)
 	Ω(err).ShouldNot(HaveOccurred())
 
 	re := regexp.MustCompile("/staging/droplets/(.*)/upload")
 	appGuid := re.FindStringSubmatch(r.URL.Path)[1]
 
-	f.UploadedDroplets[appGuid] = uploadedBytes
-	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Received %d bytes for droplet for app-guid %s\n", len(uploadedBytes), appGuid)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Received %d bytes for droplet for app-guid %s\n", size, appGuid)
+
+	if f.maxDropletUploadBytes > 0 && size > f.maxDropletUploadBytes {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	f.uploadedDroplets[appGuid] = kept.Bytes()
+	f.uploadedDropletDigests[appGuid] = UploadDigest{
+		Size:   size,
+		SHA256: fmt.Sprintf("%x", hash.Sum(nil)),
+
+		Truncated: size > MaxKeptUploadBytes,
+	}
+
+	if r.URL.Query().Get("async") == "true" && f.dropletJobPolls > 0 {
+		jobGuid := dropletJobGuid(appGuid)
+		f.jobPolls[jobGuid] = 0
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, jobResponseBody, jobGuid, "queued")
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(finishedResponseBody))
 }
 
+func (f *FakeCC) handleJobRequest(w http.ResponseWriter, r *http.Request) {
+	basicAuthVerifier := ghttp.VerifyBasicAuth(CC_USERNAME, CC_PASSWORD)
+	basicAuthVerifier(w, r)
+
+	jobGuid := strings.TrimPrefix(r.URL.Path, "/v2/jobs/")
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	polls, ok := f.jobPolls[jobGuid]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	polls++
+	f.jobPolls[jobGuid] = polls
+
+	status := "running"
+	if polls >= f.dropletJobPolls {
+		status = "finished"
+	}
+
+	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Job %s polled %d times: %s\n", jobGuid, polls, status)
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, jobResponseBody, jobGuid, status)
+}
+
+func dropletJobGuid(appGuid string) string {
+	return "droplet-" + appGuid
+}
+
 func (f *FakeCC) handleBuildArtifactsCacheUploadRequest(w http.ResponseWriter, r *http.Request) {
 	basicAuthVerifier := ghttp.VerifyBasicAuth(CC_USERNAME, CC_PASSWORD)
 	basicAuthVerifier(w, r)
@@ -170,7 +595,9 @@ func (f *FakeCC) handleBuildArtifactsCacheUploadRequest(w http.ResponseWriter, r
 	re := regexp.MustCompile("/staging/buildpack_cache/(.*)/upload")
 	appGuid := re.FindStringSubmatch(r.URL.Path)[1]
 
-	f.UploadedBuildArtifactsCaches[appGuid] = uploadedBytes
+	f.lock.Lock()
+	f.uploadedBuildArtifactsCaches[appGuid] = uploadedBytes
+	f.lock.Unlock()
 	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Received %d bytes for build artifacts cache for app-guid %s\n", len(uploadedBytes), appGuid)
 
 	w.WriteHeader(http.StatusOK)
@@ -185,7 +612,10 @@ func (f *FakeCC) handleBuildArtifactsCacheDownloadRequest(w http.ResponseWriter,
 
 	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Received request to download build artifacts cache for app-guid %s\n", appGuid)
 
-	buildArtifactsCache := f.UploadedBuildArtifactsCaches[appGuid]
+	f.lock.RLock()
+	buildArtifactsCache := f.uploadedBuildArtifactsCaches[appGuid]
+	f.lock.RUnlock()
+
 	if buildArtifactsCache == nil {
 		fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] No matching build artifacts cache for app-guid %s\n", appGuid)
 
@@ -209,20 +639,109 @@ func (f *FakeCC) newHandleStagingRequest() http.HandlerFunc {
 		ghttp.VerifyRequest("POST", MatchRegexp("/internal/staging/(.*)/completed")),
 		ghttp.VerifyBasicAuth(CC_USERNAME, CC_PASSWORD),
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var msg cc_messages.StagingResponseForCC
-			err := json.NewDecoder(r.Body).Decode(&msg)
+			body, err := ioutil.ReadAll(r.Body)
 			Ω(err).ShouldNot(HaveOccurred())
 			r.Body.Close()
+
+			var msg cc_messages.StagingResponseForCC
+			err = json.Unmarshal(body, &msg)
+			Ω(err).ShouldNot(HaveOccurred())
+
 			f.lock.Lock()
 			defer f.lock.Unlock()
 			guid := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/internal/staging/"), "/completed")
+
+			statusCode, responseBody := f.stagingResponseStatusCode, f.stagingResponseBody
+			if expected, ok := f.expectedStagingResponses[guid]; ok && !sameStagingResponse(expected, msg) {
+				fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Unexpected staging response for %s: %s\n", guid, body)
+				statusCode, responseBody = http.StatusBadRequest, `{"error": "unexpected staging response"}`
+			}
+
 			f.stagingGuids = append(f.stagingGuids, guid)
 			f.stagingResponses = append(f.stagingResponses, msg)
+			f.stagingCallbacks = append(f.stagingCallbacks, StagingCallback{
+				StagingGuid:   guid,
+				Header:        r.Header,
+				Body:          body,
+				Response:      msg,
+				ReceivedAt:    time.Now(),
+				TLS:           r.TLS,
+				RespondedWith: statusCode,
+			})
+
+			w.WriteHeader(statusCode)
+			w.Write([]byte(responseBody))
+		}),
+	)
+}
+
+// sameStagingResponse compares staging responses as JSON documents, so that
+// e.g. whitespace in the lifecycle data does not matter.
+func sameStagingResponse(a, b cc_messages.StagingResponseForCC) bool {
+	return reflect.DeepEqual(normalizedJSON(a), normalizedJSON(b))
+}
+
+func normalizedJSON(v interface{}) interface{} {
+	encoded, err := json.Marshal(v)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	var normalized interface{}
+	err = json.Unmarshal(encoded, &normalized)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return normalized
+}
+
+func (f *FakeCC) newHandleAppCrashedRequest() http.HandlerFunc {
+	return ghttp.CombineHandlers(
+		ghttp.VerifyRequest("POST", MatchRegexp("/internal/apps/(.*)/crashed")),
+		ghttp.VerifyBasicAuth(CC_USERNAME, CC_PASSWORD),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var crashed AppCrashedRequest
+			err := json.NewDecoder(r.Body).Decode(&crashed)
+			Ω(err).ShouldNot(HaveOccurred())
+			r.Body.Close()
+
+			appGuid := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/internal/apps/"), "/crashed")
+			fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE CC] Received crash of instance %d of app-guid %s: %s\n", crashed.Index, appGuid, crashed.ExitDescription)
+
+			f.lock.Lock()
+			defer f.lock.Unlock()
+
+			statusCode := f.appCrashedResponseStatusCode
+
+			f.appCrashes = append(f.appCrashes, AppCrash{
+				AppGuid:       appGuid,
+				Request:       crashed,
+				ReceivedAt:    time.Now(),
+				RespondedWith: statusCode,
+			})
+
+			w.WriteHeader(statusCode)
+			w.Write([]byte("{}"))
 		}),
-		ghttp.RespondWithPtr(&f.stagingResponseStatusCode, &f.stagingResponseBody),
 	)
 }
 
+// limitedWriter keeps up to limit bytes of what it is given, and drops the
+// rest without failing the write.
+type limitedWriter struct {
+	buffer *bytes.Buffer
+	limit  int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buffer.Len(); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+
+		w.buffer.Write(p[:room])
+	}
+
+	return len(p), nil
+}
+
 func getFileUploadKey(r *http.Request) string {
 	err := r.ParseMultipartForm(1024)
 	Ω(err).ShouldNot(HaveOccurred())
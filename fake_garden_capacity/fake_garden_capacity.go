@@ -0,0 +1,179 @@
+package fake_garden_capacity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/onsi/ginkgo"
+)
+
+// Proxy sits in front of a Garden and passes everything through to it,
+// except that it reports a fixed capacity, so that resource limits do not
+// depend on the machine the suite runs on.
+type Proxy struct {
+	address       string
+	gardenAddress string
+	capacity      garden.Capacity
+}
+
+func New(address string, gardenAddress string, capacity garden.Capacity) *Proxy {
+	return &Proxy{
+		address:       address,
+		gardenAddress: gardenAddress,
+		capacity:      capacity,
+	}
+}
+
+func (p *Proxy) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	listener, err := net.Listen("tcp", p.address)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go p.proxy(conn)
+		}
+	}()
+
+	close(ready)
+
+	<-signals
+
+	return listener.Close()
+}
+
+func (p *Proxy) Address() string {
+	return p.address
+}
+
+func (p *Proxy) Capacity() garden.Capacity {
+	return p.capacity
+}
+
+// processStreams are the requests after which Garden hijacks the
+// connection to stream a process's input and output over it.
+var processStreams = regexp.MustCompile(`^/containers/[^/]+/processes(/[^/]+)?$`)
+
+// proxy relays the connection's requests to Garden one at a time, answering
+// capacity requests itself. Once Garden takes over the connection to stream
+// a process, it relays bytes both ways until either side hangs up.
+func (p *Proxy) proxy(client net.Conn) {
+	defer client.Close()
+
+	backend, err := net.Dial("tcp", p.gardenAddress)
+	if err != nil {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE GARDEN CAPACITY] Failed to reach Garden: %s\n", err)
+		return
+	}
+
+	defer backend.Close()
+
+	clientReader := bufio.NewReader(client)
+	backendReader := bufio.NewReader(backend)
+
+	for {
+		request, err := http.ReadRequest(clientReader)
+		if err != nil {
+			return
+		}
+
+		if request.Method == "GET" && request.URL.Path == "/capacity" {
+			err := p.writeCapacity(client, request)
+			if err != nil {
+				return
+			}
+
+			continue
+		}
+
+		err = request.Write(backend)
+		if err != nil {
+			return
+		}
+
+		response, err := http.ReadResponse(backendReader, request)
+		if err != nil {
+			return
+		}
+
+		if processStreams.MatchString(request.URL.Path) {
+			// the body, if any, is the raw stream, whatever the headers say
+			err := writeHeader(client, response)
+			if err != nil {
+				return
+			}
+
+			go io.Copy(backend, clientReader)
+			io.Copy(client, backendReader)
+
+			return
+		}
+
+		err = response.Write(client)
+		if err != nil || response.Close {
+			return
+		}
+	}
+}
+
+func (p *Proxy) writeCapacity(client net.Conn, request *http.Request) error {
+	body, err := json.Marshal(p.capacity)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE GARDEN CAPACITY] Reporting capacity: %s\n", body)
+
+	response := &http.Response{
+		StatusCode:    http.StatusOK,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Request:       request,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		ContentLength: int64(len(body)),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+	}
+
+	return response.Write(client)
+}
+
+func writeHeader(client net.Conn, response *http.Response) error {
+	header := response.Header
+	if len(response.TransferEncoding) > 0 {
+		header = http.Header{}
+		for key, values := range response.Header {
+			header[key] = values
+		}
+
+		header.Set("Transfer-Encoding", strings.Join(response.TransferEncoding, ", "))
+	}
+
+	_, err := fmt.Fprintf(client, "HTTP/%d.%d %s\r\n", response.ProtoMajor, response.ProtoMinor, response.Status)
+	if err != nil {
+		return err
+	}
+
+	err = header.Write(client)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(client, "\r\n")
+	return err
+}
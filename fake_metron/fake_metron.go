@@ -0,0 +1,130 @@
+package fake_metron
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/dropsonde/events"
+	"github.com/gogo/protobuf/proto"
+	"github.com/onsi/ginkgo"
+)
+
+// FakeMetron listens where components send their dropsonde envelopes, and
+// keeps the log messages among them.
+type FakeMetron struct {
+	address string
+
+	logMessages []LogMessage
+	lock        *sync.RWMutex
+}
+
+type LogMessage struct {
+	AppId          string
+	SourceType     string
+	SourceInstance string
+	MessageType    events.LogMessage_MessageType
+	Message        string
+	Timestamp      time.Time
+}
+
+func New(address string) *FakeMetron {
+	return &FakeMetron{
+		address: address,
+
+		logMessages: []LogMessage{},
+		lock:        new(sync.RWMutex),
+	}
+}
+
+func (f *FakeMetron) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	udpAddr, err := net.ResolveUDPAddr("udp", f.address)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.receive(conn)
+	}()
+
+	close(ready)
+
+	<-signals
+
+	conn.Close()
+	<-done
+
+	f.Reset()
+
+	return nil
+}
+
+func (f *FakeMetron) Address() string {
+	return f.address
+}
+
+// LogMessages returns every log message received for the app, in order.
+func (f *FakeMetron) LogMessages(appId string) []LogMessage {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	messages := []LogMessage{}
+	for _, message := range f.logMessages {
+		if message.AppId == appId {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
+
+func (f *FakeMetron) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.logMessages = []LogMessage{}
+}
+
+func (f *FakeMetron) receive(conn *net.UDPConn) {
+	buffer := make([]byte, 64*1024)
+
+	for {
+		n, _, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			return
+		}
+
+		envelope := &events.Envelope{}
+		err = proto.Unmarshal(buffer[:n], envelope)
+		if err != nil {
+			fmt.Fprintf(ginkgo.GinkgoWriter, "[FAKE METRON] Dropping undecodable envelope: %s\n", err)
+			continue
+		}
+
+		if envelope.GetEventType() != events.Envelope_LogMessage {
+			continue
+		}
+
+		logMessage := envelope.GetLogMessage()
+
+		f.lock.Lock()
+		f.logMessages = append(f.logMessages, LogMessage{
+			AppId:          logMessage.GetAppId(),
+			SourceType:     logMessage.GetSourceType(),
+			SourceInstance: logMessage.GetSourceInstance(),
+			MessageType:    logMessage.GetMessageType(),
+			Message:        string(logMessage.GetMessage()),
+			Timestamp:      time.Unix(0, logMessage.GetTimestamp()),
+		})
+		f.lock.Unlock()
+	}
+}
@@ -0,0 +1,51 @@
+package file_server_proxy
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+)
+
+// Proxy sits in front of a file-server, which does not speak TLS, and serves
+// it over HTTPS with the TLS configuration.
+type Proxy struct {
+	address           string
+	fileServerAddress string
+	tlsConfig         *tls.Config
+}
+
+func New(address string, fileServerAddress string, tlsConfig *tls.Config) *Proxy {
+	return &Proxy{
+		address:           address,
+		fileServerAddress: fileServerAddress,
+		tlsConfig:         tlsConfig,
+	}
+}
+
+func (p *Proxy) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	listener, err := net.Listen("tcp", p.address)
+	if err != nil {
+		return err
+	}
+
+	listener = tls.NewListener(listener, p.tlsConfig)
+
+	reverseProxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: p.fileServerAddress})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- http.Serve(listener, reverseProxy)
+	}()
+
+	close(ready)
+
+	select {
+	case <-signals:
+		return listener.Close()
+	case err := <-serveErr:
+		return err
+	}
+}
@@ -1,6 +1,13 @@
 package fixtures
 
-import archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+import (
+	"fmt"
+	"io/ioutil"
+
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+
+	. "github.com/onsi/gomega"
+)
 
 func HelloWorldIndexApp() []archive_helper.ArchiveFile {
 	return []archive_helper.ArchiveFile{
@@ -34,6 +41,10 @@ start_command: bash ./server.sh`,
 	}
 }
 
+// HelloWorldIndexLRP serves its instance index on every port in $PORT, and
+// says which port served the request in the X-Container-Port header. With
+// $STICKY_SESSIONS set it also hands out a JSESSIONID cookie, which has the
+// router pin the session to the instance; see helpers.ExpectStickySession.
 func HelloWorldIndexLRP() []archive_helper.ArchiveFile {
 	return []archive_helper.ArchiveFile{
 		{
@@ -54,6 +65,10 @@ server() {
 			read < request$1
 
 			echo -n -e "HTTP/1.1 200 OK\r\n"
+			echo -n -e "X-Container-Port: $1\r\n"
+			if [ -n "${STICKY_SESSIONS}" ]; then
+				echo -n -e "Set-Cookie: JSESSIONID=session-from-index-${index}\r\n"
+			fi
 			echo -n -e "Content-Length: ${#index}\r\n\r\n"
 			echo -n -e "${index}"
 		} | nc -l 0.0.0.0 $1 > request$1;
@@ -70,6 +85,151 @@ wait
 	}
 }
 
+// InstanceEnvLRP serves its CF_INSTANCE_* and VCAP_APPLICATION environment
+// as a JSON object on $PORT; see helpers.FetchInstanceEnv.
+func InstanceEnvLRP() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "server.sh",
+			Body: `#!/bin/bash
+
+set -e
+
+# the JSON ones are embedded as they are; the rest are strings
+body=$(printf '{"CF_INSTANCE_ADDR":"%s","CF_INSTANCE_IP":"%s","CF_INSTANCE_PORT":"%s","CF_INSTANCE_PORTS":%s,"VCAP_APPLICATION":%s}' \
+	"${CF_INSTANCE_ADDR}" \
+	"${CF_INSTANCE_IP}" \
+	"${CF_INSTANCE_PORT}" \
+	"${CF_INSTANCE_PORTS:-null}" \
+	"${VCAP_APPLICATION:-null}")
+
+mkfifo request
+
+while true; do
+	{
+		read < request
+
+		echo -n -e "HTTP/1.1 200 OK\r\n"
+		echo -n -e "Content-Type: application/json\r\n"
+		echo -n -e "Content-Length: ${#body}\r\n\r\n"
+		printf "%s" "${body}"
+	} | nc -l 0.0.0.0 $PORT > request;
+done
+`,
+		},
+	}
+}
+
+// ForwardedProtoLRP responds on $PORT with the X-Forwarded-Proto header of
+// each request it is sent, or nothing if there was none.
+func ForwardedProtoLRP() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "server.sh",
+			Body: `#!/bin/bash
+
+set -e
+
+mkfifo request
+
+while true; do
+	{
+		proto=""
+
+		while read -r line; do
+			line=${line%$'\r'}
+			if [ -z "${line}" ]; then
+				break
+			fi
+
+			case "${line}" in
+				[Xx]-[Ff]orwarded-[Pp]roto:*)
+					proto=${line#*:}
+					proto=${proto# }
+					;;
+			esac
+		done < request
+
+		echo -n -e "HTTP/1.1 200 OK\r\n"
+		echo -n -e "Content-Length: ${#proto}\r\n\r\n"
+		printf "%s" "${proto}"
+	} | nc -l 0.0.0.0 $PORT > request;
+done
+`,
+		},
+	}
+}
+
+// GracefulShutdownLRP curls $STARTED_URL once it is up. When sent SIGTERM it
+// curls $DRAINING_URL, sleeps for $DRAIN_SECONDS, curls $DRAINED_URL and
+// exits; see helpers.DrainAnnouncements.
+func GracefulShutdownLRP() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "drain.sh",
+			Body: `#!/bin/bash
+
+drain() {
+	curl -s "${DRAINING_URL}"
+	sleep ${DRAIN_SECONDS}
+	curl -s "${DRAINED_URL}"
+	exit 0
+}
+
+trap drain TERM
+
+curl -s "${STARTED_URL}"
+
+# sleep in the background so that the trap runs as soon as the signal does
+while true; do
+	sleep 1 &
+	wait $!
+done
+`,
+		},
+	}
+}
+
+// SlowStartingLRP serves its instance index on $PORT, but only starts
+// listening once it has slept for $STARTUP_DELAY_SECONDS. Its healthcheck.sh
+// passes once the server answers over HTTP, so an LRP monitored with it
+// stays starting until then.
+func SlowStartingLRP() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "server.sh",
+			Body: `#!/bin/bash
+
+set -e
+
+index=${INSTANCE_INDEX}
+
+echo "Starting index '${index}' in ${STARTUP_DELAY_SECONDS:-0}s"
+sleep ${STARTUP_DELAY_SECONDS:-0}
+echo "Hello World from index '${index}'"
+
+mkfifo request
+
+while true; do
+	{
+		read < request
+
+		echo -n -e "HTTP/1.1 200 OK\r\n"
+		echo -n -e "Content-Length: ${#index}\r\n\r\n"
+		echo -n -e "${index}"
+	} | nc -l 0.0.0.0 $PORT > request;
+done
+`,
+		}, {
+			Name: "healthcheck.sh",
+			Body: `#!/bin/bash
+
+curl -s -f --max-time 1 "http://127.0.0.1:${PORT}/" > /dev/null
+`,
+		},
+	}
+}
+
 func CurlLRP() []archive_helper.ArchiveFile {
 	return []archive_helper.ArchiveFile{
 		{
@@ -91,3 +251,137 @@ done
 		},
 	}
 }
+
+func CrashingLRP(requestsBeforeCrash int) []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "server.sh",
+			Body: fmt.Sprintf(`#!/bin/bash
+
+set -e
+
+echo "Crashing after serving %[1]d requests"
+
+mkfifo request
+
+for i in $(seq %[1]d); do
+	{
+		read < request
+
+		echo -n -e "HTTP/1.1 200 OK\r\n"
+		echo -n -e "Content-Length: 0\r\n\r\n"
+	} | nc -l 0.0.0.0 $PORT > request;
+done
+
+exit 1
+`, requestsBeforeCrash),
+		},
+	}
+}
+
+// DiskFiller writes as many MB as its argument says, e.g. `fill.sh 128`,
+// and exits 1 if the disk runs out first, printing the error dd got. Given
+// `hold` as well it keeps running once it has written them, so that the
+// container's disk usage can be looked at.
+func DiskFiller() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "fill.sh",
+			Body: `#!/bin/bash
+
+for written in $(seq 0 $(($1-1))); do
+	# kept in memory, as there is no disk left to put it on
+	if ! error=$(dd if=/dev/zero of=filler-${written} bs=1M count=1 2>&1); then
+		echo "Wrote ${written}MB before running out of disk: ${error}"
+		exit 1
+	fi
+done
+
+echo "Wrote $1MB"
+
+if [ "$2" = "hold" ]; then
+	sleep 3600
+fi
+`,
+		},
+	}
+}
+
+func BusyLoop() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "busy.sh",
+			Body: `#!/bin/bash
+
+# saturate every core so that competing containers are actually contending
+for i in $(seq $(nproc)); do
+	while true; do :; done &
+done
+
+wait
+`,
+		},
+	}
+}
+
+// ResultFileWriter writes a result file of exactly the given size:
+//
+//	bash write-result.sh <path> <bytes>
+func ResultFileWriter() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "write-result.sh",
+			Body: `#!/bin/bash
+
+set -e
+
+head -c $2 /dev/zero | tr '\0' 'x' > $1
+`,
+		},
+	}
+}
+
+// NoisyLogger logs the given number of numbered lines as fast as it can:
+//
+//	bash noisy.sh <lines>
+func NoisyLogger() []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "noisy.sh",
+			Body: `#!/bin/bash
+
+for i in $(seq $1); do
+	echo "noisy line ${i}"
+done
+`,
+		},
+	}
+}
+
+// WebsocketEchoLRP echoes WebSocket frames on $PORT; websocketEchoPath is the
+// binary built by world.CompileFixtures.
+func WebsocketEchoLRP(websocketEchoPath string) []archive_helper.ArchiveFile {
+	binary, err := ioutil.ReadFile(websocketEchoPath)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "websocket-echo",
+			Body: string(binary),
+		},
+	}
+}
+
+// GRPCEchoLRP echoes gRPC messages on $PORT; grpcEchoPath is the binary built
+// by world.CompileFixtures.
+func GRPCEchoLRP(grpcEchoPath string) []archive_helper.ArchiveFile {
+	binary, err := ioutil.ReadFile(grpcEchoPath)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "grpc-echo",
+			Body: string(binary),
+		},
+	}
+}
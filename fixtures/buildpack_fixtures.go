@@ -0,0 +1,163 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+
+	. "github.com/onsi/gomega"
+)
+
+// Buildpack describes a fake buildpack whose bin/detect, bin/compile and
+// bin/release scripts are generated from its fields.
+type Buildpack struct {
+	// printed by bin/detect; if empty, detection fails
+	DetectedName string
+
+	// if nonzero, how long bin/detect sleeps before answering, e.g. to hang
+	// staging past its timeout
+	DetectSeconds int
+
+	// the default_process_types printed by bin/release
+	ProcessTypes map[string]string
+
+	// files bin/compile creates in the app directory
+	CompiledFiles []string
+
+	// files bin/compile creates in the build artifacts cache
+	CachedFiles []string
+
+	// if set, bin/compile lists what it found in the build artifacts cache,
+	// before adding CachedFiles, into this file in the app directory
+	CacheListingFile string
+
+	// if nonzero, how long bin/compile sleeps when the build artifacts cache
+	// is empty, as if fetching dependencies it could otherwise have reused
+	ColdCompileSeconds int
+
+	// what bin/compile prints, and the status it exits with
+	CompileStdoutLines []string
+	CompileExitStatus  int
+
+	// if nonzero, how long bin/compile sleeps before exiting, e.g. to stop
+	// staging while it is still compiling
+	CompileSeconds int
+}
+
+// Files returns the buildpack's scripts, ready to be archived.
+func (b Buildpack) Files() []archive_helper.ArchiveFile {
+	detect := "#!/bin/sh\n"
+	if b.DetectSeconds != 0 {
+		detect += fmt.Sprintf("sleep %d\n", b.DetectSeconds)
+	}
+	if b.DetectedName != "" {
+		detect += "cat <<'EOF'\n" + b.DetectedName + "\nEOF\n"
+	} else {
+		detect += "exit 1\n"
+	}
+
+	compile := []string{"#!/bin/sh", "set -e"}
+	for _, line := range b.CompileStdoutLines {
+		compile = append(compile, "echo "+shellQuote(line))
+	}
+	if b.CacheListingFile != "" {
+		compile = append(compile, `(ls -A "$2" 2>/dev/null || true) > "$1"/`+shellQuote(b.CacheListingFile))
+	}
+	if b.ColdCompileSeconds != 0 {
+		compile = append(compile, fmt.Sprintf(`if [ -z "$(ls -A "$2" 2>/dev/null)" ]; then sleep %d; fi`, b.ColdCompileSeconds))
+	}
+	for _, file := range b.CompiledFiles {
+		compile = append(compile, `touch "$1"/`+shellQuote(file))
+	}
+	for _, file := range b.CachedFiles {
+		compile = append(compile, `touch "$2"/`+shellQuote(file))
+	}
+	if b.CompileSeconds != 0 {
+		compile = append(compile, fmt.Sprintf("sleep %d", b.CompileSeconds))
+	}
+	compile = append(compile, fmt.Sprintf("exit %d", b.CompileExitStatus))
+
+	release := "#!/bin/sh\ncat <<'EOF'\n---\ndefault_process_types:\n" + yamlMap(b.ProcessTypes, "  ") + "EOF\n"
+
+	return []archive_helper.ArchiveFile{
+		{Name: "bin/detect", Body: detect},
+		{Name: "bin/compile", Body: strings.Join(compile, "\n") + "\n"},
+		{Name: "bin/release", Body: release},
+	}
+}
+
+// StagingResponse is what CC should be told once an app has been staged
+// with the buildpack under the given key.
+func (b Buildpack) StagingResponse(buildpackKey string) cc_messages.StagingResponseForCC {
+	return BuildpackStagingResponse(buildpackKey, b.DetectedName, b.ProcessTypes["web"])
+}
+
+// StagingResponseWithProcfile is what CC should be told once an app with
+// the given Procfile has been staged with the buildpack; the Procfile's web
+// process wins over the buildpack's.
+func (b Buildpack) StagingResponseWithProcfile(buildpackKey string, procfile map[string]string) cc_messages.StagingResponseForCC {
+	startCommand := b.ProcessTypes["web"]
+	if web, ok := procfile["web"]; ok {
+		startCommand = web
+	}
+
+	return BuildpackStagingResponse(buildpackKey, b.DetectedName, startCommand)
+}
+
+// ProcfileApp is an app declaring the given process types in a Procfile,
+// e.g. a web and a worker process.
+func ProcfileApp(processTypes map[string]string) []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{Name: "Procfile", Body: yamlMap(processTypes, "")},
+		{Name: "my-app", Body: "scooby-doo"},
+	}
+}
+
+// BuildpackStagingResponse is the staging response CC expects when a
+// buildpack app is detected as detectedBuildpack and starts with
+// startCommand.
+func BuildpackStagingResponse(buildpackKey, detectedBuildpack, startCommand string) cc_messages.StagingResponseForCC {
+	lifecycleDataJSON, err := json.Marshal(cc_messages.BuildpackStagingResponse{
+		BuildpackKey:      buildpackKey,
+		DetectedBuildpack: detectedBuildpack,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	executionMetadata, err := json.Marshal(map[string]string{"start_command": startCommand})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	lifecycleData := json.RawMessage(lifecycleDataJSON)
+
+	return cc_messages.StagingResponseForCC{
+		ExecutionMetadata:    string(executionMetadata),
+		DetectedStartCommand: map[string]string{"web": startCommand},
+		LifecycleData:        &lifecycleData,
+	}
+}
+
+func yamlMap(values map[string]string, indent string) string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	yaml := ""
+	for _, key := range keys {
+		// JSON strings are valid YAML scalars, and take care of quoting
+		value, err := json.Marshal(values[key])
+		Ω(err).ShouldNot(HaveOccurred())
+
+		yaml += fmt.Sprintf("%s%s: %s\n", indent, key, value)
+	}
+
+	return yaml
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
@@ -0,0 +1,11 @@
+package fixtures
+
+import archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+
+// VersionedDownload is a blob with nothing but a contents file saying
+// version, so that a task downloading it can tell which one it got.
+func VersionedDownload(version string) []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{Name: "contents", Body: version},
+	}
+}
@@ -0,0 +1,93 @@
+// grpc_echo serves gRPC on $PORT, which is HTTP/2 over cleartext with prior
+// knowledge, and echoes back every message sent on the bidirectional
+// /inigo.Echo/Echo stream until the client closes its side.
+//
+// Messages are raw bytes rather than protobufs, so that neither side needs
+// generated code; grpcecho.Echo is the client.
+//
+// It is built statically and shipped to containers by fixtures.GRPCEchoLRP.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+)
+
+var echoService = grpc.ServiceDesc{
+	ServiceName: "inigo.Echo",
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Echo",
+			Handler:       echo,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+func main() {
+	listener, err := net.Listen("tcp", ":"+os.Getenv("PORT"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := grpc.NewServer(grpc.CustomCodec(rawCodec{}))
+	server.RegisterService(&echoService, struct{}{})
+
+	log.Println("grpc echo server listening on " + os.Getenv("PORT"))
+	log.Fatal(server.Serve(listener))
+}
+
+func echo(srv interface{}, stream grpc.ServerStream) error {
+	for {
+		var message []byte
+
+		err := stream.RecvMsg(&message)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = stream.SendMsg(message)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rawCodec sends []byte messages as they are.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch message := v.(type) {
+	case []byte:
+		return message, nil
+	case *[]byte:
+		return *message, nil
+	default:
+		return nil, fmt.Errorf("cannot send a %T", v)
+	}
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("cannot receive into a %T", v)
+	}
+
+	*message = append([]byte{}, data...)
+	return nil
+}
+
+func (rawCodec) String() string {
+	return "raw"
+}
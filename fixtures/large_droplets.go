@@ -0,0 +1,88 @@
+package fixtures
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+
+	. "github.com/onsi/gomega"
+)
+
+// LargeDropletMarker starts every large droplet, so that whoever gets one
+// can tell it from a run of zeros that was truncated or padded.
+const LargeDropletMarker = "inigo-large-droplet"
+
+// LargeDropletName is what a large archive extracts its droplet to.
+const LargeDropletName = "droplet"
+
+// LargeDropletBytes is how big a droplet of the given megabytes is.
+func LargeDropletBytes(megabytes int) int64 {
+	return int64(megabytes) * 1024 * 1024
+}
+
+// LargeDropletScript makes a droplet of the given megabytes at path inside a
+// container: the marker and then zeros, which truncate leaves sparse so
+// that the container's disk is barely touched.
+func LargeDropletScript(path string, megabytes int) string {
+	return fmt.Sprintf("printf '%%s' '%s' > %s && truncate -s %dM %s", LargeDropletMarker, path, megabytes, path)
+}
+
+// WriteLargeArchive writes a .tgz with a droplet of the given megabytes in
+// it, named LargeDropletName, to path. The archive is not compressed, so
+// that it is as many bytes on the wire as the droplet, and it is written as
+// it is made so that it is never all in memory.
+func WriteLargeArchive(path string, megabytes int) {
+	file, err := os.Create(path)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer file.Close()
+
+	gzipWriter, err := gzip.NewWriterLevel(file, gzip.NoCompression)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name: LargeDropletName,
+		Mode: 0644,
+		Size: LargeDropletBytes(megabytes),
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	writeLargeDroplet(tarWriter, megabytes)
+
+	err = tarWriter.Close()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = gzipWriter.Close()
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+// LargeDropletDigest is the hex SHA-256 of a droplet of the given
+// megabytes.
+func LargeDropletDigest(megabytes int) string {
+	hash := sha256.New()
+	writeLargeDroplet(hash, megabytes)
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func writeLargeDroplet(w io.Writer, megabytes int) {
+	_, err := io.WriteString(w, LargeDropletMarker)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	_, err = io.CopyN(w, zeros{}, LargeDropletBytes(megabytes)-int64(len(LargeDropletMarker)))
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
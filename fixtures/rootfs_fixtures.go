@@ -0,0 +1,32 @@
+package fixtures
+
+import (
+	"strings"
+
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+)
+
+// TinyRootFS is a rootfs layer with little more than a root user and
+// fillerBytes of padding, so that layers made with different names are
+// distinct and take up a known amount of room in a Garden graph. Nothing
+// can run in it; it is only for creating containers.
+func TinyRootFS(name string, fillerBytes int) []archive_helper.ArchiveFile {
+	return []archive_helper.ArchiveFile{
+		{
+			Name: "etc/passwd",
+			Body: "root:x:0:0:root:/root:/bin/sh\nvcap:x:2000:2000::/home/vcap:/bin/sh\n",
+		},
+		{
+			Name: "etc/group",
+			Body: "root:x:0:\nvcap:x:2000:\n",
+		},
+		{
+			Name: "etc/inigo-rootfs",
+			Body: name + "\n",
+		},
+		{
+			Name: "filler",
+			Body: strings.Repeat("x", fillerBytes),
+		},
+	}
+}
@@ -0,0 +1,141 @@
+// websocket_echo upgrades every request on $PORT to a WebSocket and echoes
+// back each text frame it receives, until the client closes the connection.
+//
+// It is built statically and shipped to containers by
+// fixtures.WebsocketEchoLRP, so it sticks to the standard library.
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"log"
+	"net/http"
+	"os"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+func main() {
+	http.HandleFunc("/", echo)
+
+	log.Println("websocket echo server listening on " + os.Getenv("PORT"))
+	log.Fatal(http.ListenAndServe(":"+os.Getenv("PORT"), nil))
+}
+
+func echo(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Header.Get("Upgrade") != "websocket" || key == "" {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return
+	}
+
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		log.Println("failed to hijack:", err)
+		return
+	}
+	defer conn.Close()
+
+	accept := sha1.Sum([]byte(key + websocketGUID))
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\n")
+	rw.WriteString("Connection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n")
+	rw.Flush()
+
+	for {
+		opcode, payload, err := readFrame(rw.Reader)
+		if err != nil {
+			return
+		}
+
+		switch opcode {
+		case opClose:
+			writeFrame(rw.Writer, opClose, payload)
+			return
+		case opPing:
+			writeFrame(rw.Writer, opPong, payload)
+		default:
+			writeFrame(rw.Writer, opcode, payload)
+		}
+	}
+}
+
+// readFrame reads one unfragmented frame, unmasking it if the client masked
+// it (which clients must).
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(r, extended); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(r, extended); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	mask := make([]byte, 4)
+	if masked {
+		if _, err := io.ReadFull(r, mask); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return opcode, payload, nil
+}
+
+// writeFrame writes one unmasked, final frame, as servers must.
+func writeFrame(w *bufio.Writer, opcode byte, payload []byte) error {
+	w.WriteByte(0x80 | opcode)
+
+	switch {
+	case len(payload) < 126:
+		w.WriteByte(byte(len(payload)))
+	case len(payload) <= 0xffff:
+		w.WriteByte(126)
+		binary.Write(w, binary.BigEndian, uint16(len(payload)))
+	default:
+		w.WriteByte(127)
+		binary.Write(w, binary.BigEndian, uint64(len(payload)))
+	}
+
+	w.Write(payload)
+
+	return w.Flush()
+}
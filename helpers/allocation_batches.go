@@ -0,0 +1,158 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	. "github.com/onsi/gomega"
+)
+
+// AllocationBatch is a batch of containers for AllocateContainers, each with
+// the error it should be refused with, if any, so that one submission can
+// mix containers that should be allocated with ones that should not, in any
+// order.
+type AllocationBatch struct {
+	Containers []executor.Container
+
+	// expected errors by guid; containers missing from it should be
+	// allocated
+	ExpectedErrors map[string]error
+
+	// guids that were already allocated before the batch, and whose
+	// containers the batch must leave as they were
+	taken map[string]executor.Container
+}
+
+func NewAllocationBatch() *AllocationBatch {
+	return &AllocationBatch{
+		ExpectedErrors: map[string]error{},
+		taken:          map[string]executor.Container{},
+	}
+}
+
+// Add appends a container that should be allocated.
+func (batch *AllocationBatch) Add(memoryMB, diskMB int) executor.Container {
+	container := executor.Container{
+		Guid:     NewGuid("container"),
+		MemoryMB: memoryMB,
+		DiskMB:   diskMB,
+	}
+
+	batch.Containers = append(batch.Containers, container)
+
+	return container
+}
+
+// AddRefused appends a container that should be refused with err, e.g.
+// ErrInsufficientResourcesAvailable for one that does not fit.
+func (batch *AllocationBatch) AddRefused(memoryMB, diskMB int, err error) executor.Container {
+	container := batch.Add(memoryMB, diskMB)
+	batch.ExpectedErrors[container.Guid] = err
+
+	return container
+}
+
+// AddInvalidLimits appends a container whose CPU weight is out of range.
+func (batch *AllocationBatch) AddInvalidLimits() executor.Container {
+	batch.AddRefused(1, 1, executor.ErrLimitsInvalid)
+
+	last := &batch.Containers[len(batch.Containers)-1]
+	last.CPUWeight = 101
+
+	return *last
+}
+
+// AddTaken appends a container reusing the guid of one allocated before the
+// batch, which should be refused without the original being touched.
+func (batch *AllocationBatch) AddTaken(original executor.Container) executor.Container {
+	container := executor.Container{
+		Guid:     original.Guid,
+		MemoryMB: original.MemoryMB + 1,
+		DiskMB:   original.DiskMB + 1,
+	}
+
+	batch.Containers = append(batch.Containers, container)
+	batch.ExpectedErrors[container.Guid] = executor.ErrContainerGuidNotAvailable
+	batch.taken[container.Guid] = original
+
+	return container
+}
+
+// Submit allocates the batch, and returns the errors by guid.
+func (batch *AllocationBatch) Submit(client executor.Client) map[string]string {
+	allocationErrors, err := client.AllocateContainers(batch.Containers)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return allocationErrors
+}
+
+// ExpectOutcome asserts that the batch's allocation errors are exactly the
+// expected ones, guid for guid; that every container that was not refused
+// is reserved with the resources it asked for; that the refused ones are
+// not there at all, besides the originals of taken guids, untouched; and
+// that the executor has reserved, out of what remained before the batch,
+// what the allocated containers add up to and nothing for the refused ones.
+func (batch *AllocationBatch) ExpectOutcome(client executor.Client, allocationErrors map[string]string, remainingBefore executor.ExecutorResources) {
+	Ω(allocationErrors).Should(Equal(batch.expectedErrorMessages()))
+
+	for _, container := range batch.Containers {
+		actual, err := client.GetContainer(container.Guid)
+
+		if original, taken := batch.taken[container.Guid]; taken {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(actual.MemoryMB).Should(Equal(original.MemoryMB), "the batch changed the container already allocated as %s", container.Guid)
+			Ω(actual.DiskMB).Should(Equal(original.DiskMB), "the batch changed the container already allocated as %s", container.Guid)
+			continue
+		}
+
+		if _, refused := batch.ExpectedErrors[container.Guid]; refused {
+			Ω(err).Should(Equal(executor.ErrContainerNotFound), "refused container %s was allocated anyway", container.Guid)
+			continue
+		}
+
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(actual.State).Should(Equal(executor.StateReserved))
+		Ω(actual.MemoryMB).Should(Equal(container.MemoryMB))
+		Ω(actual.DiskMB).Should(Equal(container.DiskMB))
+	}
+
+	allocated := batch.Allocated()
+
+	Ω(client.RemainingResources()).Should(Equal(executor.ExecutorResources{
+		MemoryMB:   remainingBefore.MemoryMB - allocated.MemoryMB,
+		DiskMB:     remainingBefore.DiskMB - allocated.DiskMB,
+		Containers: remainingBefore.Containers - allocated.Containers,
+	}), "the executor's reservations do not add up to what the batch allocated")
+}
+
+// Allocated is what the containers in the batch that should be allocated
+// add up to.
+func (batch *AllocationBatch) Allocated() executor.ExecutorResources {
+	return AllocatedFrom(batch.Containers, batch.expectedErrorMessages())
+}
+
+func (batch *AllocationBatch) expectedErrorMessages() map[string]string {
+	messages := map[string]string{}
+	for guid, err := range batch.ExpectedErrors {
+		messages[guid] = err.Error()
+	}
+
+	return messages
+}
+
+// AllocatedFrom is what the containers in the batch that were allocated,
+// going by the errors AllocateContainers returned, add up to, e.g. when
+// concurrent batches leave which of them fit up to the executor.
+func AllocatedFrom(containers []executor.Container, allocationErrors map[string]string) executor.ExecutorResources {
+	allocated := executor.ExecutorResources{}
+
+	for _, container := range containers {
+		if _, refused := allocationErrors[container.Guid]; refused {
+			continue
+		}
+
+		allocated.MemoryMB += container.MemoryMB
+		allocated.DiskMB += container.DiskMB
+		allocated.Containers++
+	}
+
+	return allocated
+}
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cloudfoundry-incubator/inigo/inigo_announcement_server"
+	. "github.com/onsi/gomega"
+)
+
+// AnnouncementClient talks to an announcement server, e.g. the one at
+// ComponentMaker.Addresses.AnnouncementServer. Any parallel node can point
+// one at any other node's server.
+type AnnouncementClient struct {
+	address string
+}
+
+func NewAnnouncementClient(address string) *AnnouncementClient {
+	return &AnnouncementClient{address: address}
+}
+
+// AnnounceURL is what a container should curl to announce something.
+func (client *AnnouncementClient) AnnounceURL(announcement string) string {
+	return fmt.Sprintf("http://%s/announce?announcement=%s", client.address, url.QueryEscape(announcement))
+}
+
+// Announce announces something from the test itself, e.g. to mark a point
+// in time between announcements made by containers.
+func (client *AnnouncementClient) Announce(announcement string) {
+	response, err := http.Get(client.AnnounceURL(announcement))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	defer response.Body.Close()
+
+	Ω(response.StatusCode).Should(Equal(http.StatusOK))
+}
+
+func (client *AnnouncementClient) Announcements() []string {
+	var announcements []string
+	client.get("/announcements", &announcements)
+	return announcements
+}
+
+// AnnouncementsInOrder returns every announcement in the order the server
+// received them; the sequence numbers are authoritative even when the
+// timestamps are too close to tell apart.
+func (client *AnnouncementClient) AnnouncementsInOrder() []inigo_announcement_server.Announcement {
+	var announcements []inigo_announcement_server.Announcement
+	client.get("/announcements-in-order", &announcements)
+	return announcements
+}
+
+func (client *AnnouncementClient) get(path string, result interface{}) {
+	response, err := http.Get(fmt.Sprintf("http://%s%s", client.address, path))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	defer response.Body.Close()
+
+	Ω(response.StatusCode).Should(Equal(http.StatusOK))
+
+	err = json.NewDecoder(response.Body).Decode(result)
+	Ω(err).ShouldNot(HaveOccurred())
+}
@@ -0,0 +1,19 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// ExpectNilOrReceptorError asserts that a receptor client call either
+// succeeded or failed with an error the receptor itself answered with, as
+// opposed to e.g. a response the client could not make sense of, which is
+// how a mismatched API version shows up when it is not handled gracefully.
+func ExpectNilOrReceptorError(err error) {
+	if err == nil {
+		return
+	}
+
+	_, isReceptorError := err.(receptor.Error)
+	Ω(isReceptorError).Should(BeTrue(), "not an error from the receptor: %s", err)
+}
@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+)
+
+// AcceptedCrashCountsPoller polls the crash counts of the app's crash
+// reports that CC accepted, in the order they arrived, so that a crash
+// reported twice shows up as its count twice.
+func AcceptedCrashCountsPoller(fakeCC *fake_cc.FakeCC, appGuid string) func() []int {
+	return func() []int {
+		counts := []int{}
+		for _, crash := range fakeCC.AppCrashes(appGuid) {
+			if crash.RespondedWith == http.StatusOK {
+				counts = append(counts, crash.Request.CrashCount)
+			}
+		}
+
+		return counts
+	}
+}
+
+// CrashReportGaps returns how long passed between each attempt at reporting
+// the crash with the given count and the next, e.g. while CC was refusing
+// them.
+func CrashReportGaps(crashes []fake_cc.AppCrash, crashCount int) []time.Duration {
+	gaps := []time.Duration{}
+
+	var last time.Time
+	for _, crash := range crashes {
+		if crash.Request.CrashCount != crashCount {
+			continue
+		}
+
+		if !last.IsZero() {
+			gaps = append(gaps, crash.ReceivedAt.Sub(last))
+		}
+
+		last = crash.ReceivedAt
+	}
+
+	return gaps
+}
@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	. "github.com/onsi/gomega"
+)
+
+// BuildOrImportArtifacts builds the suite's artifacts, unless
+// $INIGO_IMPORT_ARTIFACTS names a tarball exported by another run to use
+// instead. Whatever was built is exported to $INIGO_EXPORT_ARTIFACTS, if
+// it is set, for other suites to import. Imported artifacts are extracted
+// into a temp dir, which world.BuiltArtifacts.RemoveImported removes.
+func BuildOrImportArtifacts(suite string, build func() world.BuiltArtifacts) world.BuiltArtifacts {
+	if tarballPath := os.Getenv("INIGO_IMPORT_ARTIFACTS"); tarballPath != "" {
+		dir, err := ioutil.TempDir("", "imported-artifacts")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		artifacts, _ := world.ImportArtifacts(tarballPath, dir)
+
+		return artifacts
+	}
+
+	artifacts := build()
+
+	if dir := os.Getenv("INIGO_EXPORT_ARTIFACTS"); dir != "" {
+		world.ExportArtifacts(artifacts, dir, suite)
+	}
+
+	return artifacts
+}
@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+// Cells are several executor/rep pairs sharing one Garden, each registered
+// under its own ID, for exercising the auction.
+type Cells struct {
+	IDs []string
+
+	process ifrit.Process
+}
+
+// StartCells starts count cells with memoryMB each, and waits for all of
+// them to register.
+func StartCells(maker world.ComponentMaker, receptorClient receptor.Client, count int, memoryMB int) *Cells {
+	cells := &Cells{}
+	members := grouper.Members{}
+
+	for i := 0; i < count; i++ {
+		cellMaker := maker.Cell(fmt.Sprintf("auction-cell-%d", i), i)
+		cellID := cellMaker.CellID()
+
+		members = append(members,
+			grouper.Member{cellID + "-executor", cellMaker.Executor("-memoryMB", strconv.Itoa(memoryMB))},
+			grouper.Member{cellID + "-rep", cellMaker.Rep()},
+		)
+
+		cells.IDs = append(cells.IDs, cellID)
+	}
+
+	cells.process = ginkgomon.Invoke(grouper.NewParallel(os.Kill, members))
+
+	for _, cellID := range cells.IDs {
+		WaitForCellRegistration(receptorClient, cellID)
+	}
+
+	return cells
+}
+
+func (cells *Cells) Stop() {
+	StopProcesses(cells.process)
+}
+
+// PlacementPoller returns how many running instances of the LRP each cell
+// has; cells with none are left out.
+func PlacementPoller(receptorClient receptor.Client, processGuid string) func() map[string]int {
+	return func() map[string]int {
+		lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		placement := map[string]int{}
+		for _, lrp := range lrps {
+			if lrp.State == receptor.ActualLRPStateRunning {
+				placement[lrp.CellID]++
+			}
+		}
+
+		return placement
+	}
+}
+
+// DesireAndPlace desires instances of an idle LRP taking memoryMB each,
+// waits for all of them to be running, and returns where the auction put
+// them.
+func DesireAndPlace(receptorClient receptor.Client, maker world.ComponentMaker, domain, processGuid string, instances int, memoryMB int) map[string]int {
+	DesireIdleLRP(receptorClient, maker, domain, processGuid, instances, memoryMB)
+
+	Eventually(RunningLRPCountPoller(receptorClient, processGuid), Timeouts.Long).Should(Equal(instances))
+
+	return PlacementPoller(receptorClient, processGuid)()
+}
+
+// DesireIdleLRP desires instances of an LRP that does nothing but take up
+// memoryMB each.
+func DesireIdleLRP(receptorClient receptor.Client, maker world.ComponentMaker, domain, processGuid string, instances int, memoryMB int) {
+	err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+		Domain:      domain,
+		ProcessGuid: processGuid,
+		Instances:   instances,
+		Stack:       maker.Stack,
+		MemoryMB:    memoryMB,
+
+		Action: &models.RunAction{
+			Path: "sh",
+			Args: []string{"-c", "while true; do sleep 1; done"},
+		},
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+// PlacementErrorsPoller returns the placement errors of the LRP's instances
+// that the auction could not place, by index.
+func PlacementErrorsPoller(receptorClient receptor.Client, processGuid string) func() map[int]string {
+	return func() map[int]string {
+		lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		placementErrors := map[int]string{}
+		for _, lrp := range lrps {
+			if lrp.PlacementError != "" {
+				placementErrors[lrp.Index] = lrp.PlacementError
+			}
+		}
+
+		return placementErrors
+	}
+}
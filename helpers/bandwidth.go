@@ -0,0 +1,156 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	. "github.com/onsi/gomega"
+)
+
+// the ports a ThroughputServer takes traffic in on, and sends it out on,
+// in DesireThroughputLRP's instances
+const (
+	ThroughputSinkPort   uint16 = 8080
+	ThroughputSourcePort uint16 = 9090
+)
+
+// ThroughputServer is a bash script that, for every connection on sinkPort,
+// reads byteCount bytes and answers "received", and for every connection on
+// sourcePort, sends byteCount zeroes; the other end times the transfer,
+// through the container's ingress or egress respectively.
+func ThroughputServer(sinkPort, sourcePort uint16, byteCount int) string {
+	return fmt.Sprintf(`
+		mkfifo incoming
+
+		while true; do
+			dd if=/dev/zero bs=1024 count=%d 2>/dev/null | nc -l 0.0.0.0 %d > /dev/null;
+		done &
+
+		while true; do
+		{
+			head -c %d < incoming > /dev/null
+			echo received
+		} | nc -l 0.0.0.0 %d > incoming;
+		done
+	`, byteCount/1024, sourcePort, byteCount, sinkPort)
+}
+
+// DesireThroughputLRP desires a single instance running a ThroughputServer
+// moving byteCount bytes, a multiple of 1024, on the throughput ports, and
+// waits for it to run.
+func DesireThroughputLRP(receptorClient receptor.Client, domain string, stack string, processGuid string, byteCount int) receptor.ActualLRPResponse {
+	Ω(byteCount%1024).Should(BeZero(), "the source sends whole kilobytes")
+
+	err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+		Domain:      domain,
+		ProcessGuid: processGuid,
+		Instances:   1,
+		Stack:       stack,
+		MemoryMB:    128,
+
+		Ports: []uint16{ThroughputSinkPort, ThroughputSourcePort},
+
+		Action: &models.RunAction{
+			Path: "bash",
+			Args: []string{"-c", ThroughputServer(ThroughputSinkPort, ThroughputSourcePort, byteCount)},
+		},
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return WaitForLRPInstanceState(receptorClient, processGuid, 0, receptor.ActualLRPStateRunning)
+}
+
+// MeasureThroughputIn sends byteCount bytes from the host to the instance's
+// ThroughputServer, and returns how many bytes per second got through,
+// timed until the server has read them all.
+func MeasureThroughputIn(lrp receptor.ActualLRPResponse, byteCount int) float64 {
+	conn := dialThroughputServer(lrp, ThroughputSinkPort)
+	defer conn.Close()
+
+	started := time.Now()
+
+	sent, err := io.CopyN(conn, zeroes{}, int64(byteCount))
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(sent).Should(BeEquivalentTo(byteCount))
+
+	answer, err := bufio.NewReader(conn).ReadString('\n')
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(strings.TrimSpace(answer)).Should(Equal("received"))
+
+	return float64(byteCount) / time.Since(started).Seconds()
+}
+
+// MeasureThroughputOut receives byteCount bytes on the host from the
+// instance's ThroughputServer, and returns how many bytes per second got
+// through.
+func MeasureThroughputOut(lrp receptor.ActualLRPResponse, byteCount int) float64 {
+	conn := dialThroughputServer(lrp, ThroughputSourcePort)
+	defer conn.Close()
+
+	started := time.Now()
+
+	received, err := io.CopyN(ioutil.Discard, conn, int64(byteCount))
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(received).Should(BeEquivalentTo(byteCount))
+
+	return float64(byteCount) / time.Since(started).Seconds()
+}
+
+// LimitContainerBandwidth has Garden limit the container's network traffic,
+// in and out, to bytesPerSecond, allowing bursts of up to burstBytes. The
+// executor never asks for a limit itself, so specs set one on its
+// containers directly.
+func LimitContainerBandwidth(gardenClient garden.Client, handle string, bytesPerSecond, burstBytes uint64) {
+	container, err := gardenClient.Lookup(handle)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = container.LimitBandwidth(garden.BandwidthLimits{
+		RateInBytesPerSecond:      bytesPerSecond,
+		BurstRateInBytesPerSecond: burstBytes,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+// ContainerBandwidthLimits is what Garden says the container's network
+// traffic is limited to.
+func ContainerBandwidthLimits(gardenClient garden.Client, handle string) garden.BandwidthLimits {
+	container, err := gardenClient.Lookup(handle)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	limits, err := container.CurrentBandwidthLimits()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return limits
+}
+
+// the server may not be listening yet, or again after the last connection
+func dialThroughputServer(lrp receptor.ActualLRPResponse, containerPort uint16) net.Conn {
+	address := MappedAddress(lrp, containerPort)
+
+	var conn net.Conn
+	Eventually(func() error {
+		var err error
+		conn, err = net.Dial("tcp", address)
+		return err
+	}).ShouldNot(HaveOccurred())
+
+	return conn
+}
+
+type zeroes struct{}
+
+func (zeroes) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
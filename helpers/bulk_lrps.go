@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
+	. "github.com/onsi/gomega"
+)
+
+// LRPStart is how long one of the LRPs desired by DesireNLRPs took to run.
+type LRPStart struct {
+	ProcessGuid string
+
+	// the hostname routed to the LRP's first port, if it has one
+	Route string
+
+	Latency time.Duration
+}
+
+// DesireNLRPs desires n copies of template, each with its own process guid
+// and a route named after it, and waits for all of their instances to be
+// running.
+//
+// Latency is measured from when each LRP was desired until it was first seen
+// running, so it is only as fine as the polling interval.
+func DesireNLRPs(receptorClient receptor.Client, n int, template receptor.DesiredLRPCreateRequest) []LRPStart {
+	starts := make([]LRPStart, n)
+	desiredAt := make(map[string]time.Time, n)
+	indices := make(map[string]int, n)
+
+	for i := range starts {
+		lrp := template
+		lrp.ProcessGuid = NewGuidWithSuffix("lrp", strconv.Itoa(i))
+
+		starts[i].ProcessGuid = lrp.ProcessGuid
+
+		if len(lrp.Ports) > 0 {
+			starts[i].Route = lrp.ProcessGuid
+			lrp.Routes = cfroutes.CFRoutes{{Port: lrp.Ports[0], Hostnames: []string{starts[i].Route}}}.RoutingInfo()
+		}
+
+		desiredAt[lrp.ProcessGuid] = time.Now()
+		indices[lrp.ProcessGuid] = i
+
+		err := receptorClient.CreateDesiredLRP(lrp)
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	Eventually(func() int {
+		lrps, err := receptorClient.ActualLRPs()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		running := map[string]int{}
+		for _, lrp := range lrps {
+			if lrp.State == receptor.ActualLRPStateRunning {
+				running[lrp.ProcessGuid]++
+			}
+		}
+
+		for guid, count := range running {
+			i, ok := indices[guid]
+			if !ok || count < template.Instances || starts[i].Latency != 0 {
+				continue
+			}
+
+			starts[i].Latency = time.Since(desiredAt[guid])
+		}
+
+		started := 0
+		for _, start := range starts {
+			if start.Latency != 0 {
+				started++
+			}
+		}
+
+		return started
+	}, Timeouts.Crazy).Should(Equal(n), "not all of the LRPs started")
+
+	return starts
+}
+
+// SlowestLRPStart is the longest latency of any of the given starts.
+func SlowestLRPStart(starts []LRPStart) time.Duration {
+	slowest := time.Duration(0)
+	for _, start := range starts {
+		if start.Latency > slowest {
+			slowest = start.Latency
+		}
+	}
+
+	return slowest
+}
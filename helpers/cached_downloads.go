@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/inigo/fake_blobstore"
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
+	. "github.com/onsi/gomega"
+)
+
+// DesireDownloadTask desires a task that downloads from into /tmp/download,
+// cached under cacheKey unless it is empty, and results in the downloaded
+// contents file, e.g. of a fixtures.VersionedDownload.
+func DesireDownloadTask(receptorClient receptor.Client, maker world.ComponentMaker, domain string, from string, cacheKey string) string {
+	taskGuid := NewGuid("task")
+
+	err := receptorClient.CreateTask(receptor.TaskCreateRequest{
+		Domain:     domain,
+		TaskGuid:   taskGuid,
+		Stack:      maker.Stack,
+		ResultFile: "/tmp/download/contents",
+		Action: models.Serial(
+			&models.DownloadAction{
+				From:     from,
+				To:       "/tmp/download",
+				CacheKey: cacheKey,
+			},
+			&models.RunAction{
+				Path: "true",
+			},
+		),
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return taskGuid
+}
+
+// CacheBustingKey is a cache key that nothing has downloaded under yet,
+// for tasks that must not share a cached copy with any before them.
+func CacheBustingKey(cacheKey string) string {
+	return cacheKey + "-" + factories.GenerateGuid()
+}
+
+// ExpectRevalidated asserts that the last download of the blob asked
+// whether the cached copy was still fresh, and was told it was.
+func ExpectRevalidated(blobstore *fake_blobstore.FakeBlobstore, name string) {
+	request := lastBlobRequest(blobstore, name)
+
+	conditional := request.Header.Get("If-None-Match") != "" || request.Header.Get("If-Modified-Since") != ""
+	Ω(conditional).Should(BeTrue(), "the last download of %s was not conditional", name)
+	Ω(request.RespondedWith).Should(Equal(http.StatusNotModified))
+}
+
+// ExpectFetchedInFull asserts that the last download of the blob was
+// answered with the whole blob, not from the cache.
+func ExpectFetchedInFull(blobstore *fake_blobstore.FakeBlobstore, name string) {
+	Ω(lastBlobRequest(blobstore, name).RespondedWith).Should(Equal(http.StatusOK))
+}
+
+func lastBlobRequest(blobstore *fake_blobstore.FakeBlobstore, name string) fake_blobstore.Request {
+	requests := blobstore.Requests(name)
+	Ω(requests).ShouldNot(BeEmpty(), "%s was never downloaded", name)
+
+	return requests[len(requests)-1]
+}
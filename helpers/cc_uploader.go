@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// CCUploaderDropletURL is what an UploadAction uploads a droplet to for
+// cc-uploader to relay it to CC at ccDropletUploadURI, e.g. one from
+// FakeCC.DropletUploadURI.
+func CCUploaderDropletURL(ccUploaderAddr string, appGuid string, ccDropletUploadURI string) string {
+	return fmt.Sprintf(
+		"http://%s/v1/droplet/%s?%s",
+		ccUploaderAddr,
+		appGuid,
+		url.Values{"cc-droplet-upload-uri": {ccDropletUploadURI}}.Encode(),
+	)
+}
+
+// CCUploaderBuildArtifactsURL is the build artifacts cache counterpart of
+// CCUploaderDropletURL.
+func CCUploaderBuildArtifactsURL(ccUploaderAddr string, appGuid string, ccBuildArtifactsUploadURI string) string {
+	return fmt.Sprintf(
+		"http://%s/v1/build_artifacts/%s?%s",
+		ccUploaderAddr,
+		appGuid,
+		url.Values{"cc-build-artifacts-upload-uri": {ccBuildArtifactsUploadURI}}.Encode(),
+	)
+}
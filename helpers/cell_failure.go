@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+type Migration struct {
+	Index int
+
+	FromCellID string
+	ToCellID   string
+
+	// from the cell being killed to the instance running elsewhere
+	Latency time.Duration
+}
+
+// KillCell ungracefully kills a cell's executor and rep, leaving its
+// containers behind as a dead machine would, and returns when it did so.
+func KillCell(cell ifrit.Process) time.Time {
+	killedAt := time.Now()
+
+	cell.Signal(os.Kill)
+	Eventually(cell.Wait()).Should(Receive())
+
+	return killedAt
+}
+
+// WaitForInstancesToMigrate waits for every instance of the LRP that was on
+// the given cell to be running on another one, and reports how long each
+// took since the cell was killed.
+func WaitForInstancesToMigrate(receptorClient receptor.Client, processGuid string, fromCellID string, killedAt time.Time, timeout time.Duration) []Migration {
+	lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	pending := map[int]bool{}
+	for _, lrp := range lrps {
+		if lrp.CellID == fromCellID {
+			pending[lrp.Index] = true
+		}
+	}
+
+	migrations := []Migration{}
+
+	Eventually(func() int {
+		lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		for _, lrp := range lrps {
+			if !pending[lrp.Index] {
+				continue
+			}
+
+			if lrp.State == receptor.ActualLRPStateRunning && lrp.CellID != fromCellID {
+				delete(pending, lrp.Index)
+
+				migrations = append(migrations, Migration{
+					Index:      lrp.Index,
+					FromCellID: fromCellID,
+					ToCellID:   lrp.CellID,
+					Latency:    time.Since(killedAt),
+				})
+			}
+		}
+
+		return len(pending)
+	}, timeout).Should(BeZero(), "instances never left cell %s", fromCellID)
+
+	return migrations
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// CellIDsPoller returns the sorted IDs of the cells currently registered.
+func CellIDsPoller(receptorClient receptor.Client) func() []string {
+	return func() []string {
+		cells, err := receptorClient.Cells()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		cellIDs := make([]string, 0, len(cells))
+		for _, cell := range cells {
+			cellIDs = append(cellIDs, cell.CellID)
+		}
+
+		sort.Strings(cellIDs)
+
+		return cellIDs
+	}
+}
+
+// WaitForCellRegistration waits for the given cell to register, and returns
+// what it registered with so that its capacity can be checked.
+func WaitForCellRegistration(receptorClient receptor.Client, cellID string) receptor.CellResponse {
+	var registered receptor.CellResponse
+
+	Eventually(func() bool {
+		cells, err := receptorClient.Cells()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		for _, cell := range cells {
+			if cell.CellID == cellID {
+				registered = cell
+				return true
+			}
+		}
+
+		return false
+	}).Should(BeTrue(), "cell %s never registered", cellID)
+
+	return registered
+}
+
+// WaitForCellDeregistration waits for the given cell's presence to go away,
+// e.g. after its rep has been stopped.
+func WaitForCellDeregistration(receptorClient receptor.Client, cellID string) {
+	Eventually(CellIDsPoller(receptorClient)).ShouldNot(ContainElement(cellID))
+}
@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+)
+
+// DefaultConfigPath is where LoadConfig looks when $INIGO_CONFIG is not
+// set, relative to a suite's directory, i.e. the root of the repo.
+const DefaultConfigPath = "../inigo.yml"
+
+// LoadConfig loads the config file named by $INIGO_CONFIG, or
+// DefaultConfigPath if there is one, so that what it sets is in place
+// before RegisterDefaultTimeouts and MakeComponentMaker read it.
+func LoadConfig() {
+	path := os.Getenv("INIGO_CONFIG")
+	if path == "" {
+		if _, err := os.Stat(DefaultConfigPath); err != nil {
+			return
+		}
+
+		path = DefaultConfigPath
+	}
+
+	world.LoadConfig(path)
+}
+
+// Stack is the stack to run on: $INIGO_STACK, or StackName.
+func Stack() string {
+	stack := os.Getenv("INIGO_STACK")
+	if stack == "" {
+		return StackName
+	}
+
+	return stack
+}
@@ -12,6 +12,10 @@ import (
 
 const StackName = "lucid64"
 
+// each parallel node's file-server listens on this plus its node number;
+// see also SharedFileServerAddress
+const fileServerBasePort = 17000
+
 func MakeComponentMaker(builtArtifacts world.BuiltArtifacts) world.ComponentMaker {
 	localIP, err := localip.LocalIP()
 	Ω(err).ShouldNot(HaveOccurred())
@@ -23,16 +27,27 @@ func MakeComponentMaker(builtArtifacts world.BuiltArtifacts) world.ComponentMake
 		EtcdPeer:            fmt.Sprintf("127.0.0.1:%d", 12500+config.GinkgoConfig.ParallelNode),
 		Executor:            fmt.Sprintf("127.0.0.1:%d", 13000+config.GinkgoConfig.ParallelNode),
 		Rep:                 fmt.Sprintf("0.0.0.0:%d", 14000+config.GinkgoConfig.ParallelNode),
-		FileServer:          fmt.Sprintf("%s:%d", localIP, 17000+config.GinkgoConfig.ParallelNode),
+		FileServer:          fmt.Sprintf("%s:%d", localIP, fileServerBasePort+config.GinkgoConfig.ParallelNode),
+		FileServerBackend:   fmt.Sprintf("127.0.0.1:%d", 17500+config.GinkgoConfig.ParallelNode),
 		Router:              fmt.Sprintf("127.0.0.1:%d", 18000+config.GinkgoConfig.ParallelNode),
+		RouterStatus:        fmt.Sprintf("127.0.0.1:%d", 18500+config.GinkgoConfig.ParallelNode),
+		RouterTLS:           fmt.Sprintf("127.0.0.1:%d", 18750+config.GinkgoConfig.ParallelNode),
 		TPS:                 fmt.Sprintf("127.0.0.1:%d", 19000+config.GinkgoConfig.ParallelNode),
 		FakeCC:              fmt.Sprintf("127.0.0.1:%d", 20000+config.GinkgoConfig.ParallelNode),
 		Receptor:            fmt.Sprintf("127.0.0.1:%d", 21000+config.GinkgoConfig.ParallelNode),
 		ReceptorTaskHandler: fmt.Sprintf("127.0.0.1:%d", 21500+config.GinkgoConfig.ParallelNode),
 		Stager:              fmt.Sprintf("127.0.0.1:%d", 22000+config.GinkgoConfig.ParallelNode),
 		Auctioneer:          fmt.Sprintf("0.0.0.0:%d", 23000+config.GinkgoConfig.ParallelNode),
+		FakeBlobstore:       fmt.Sprintf("%s:%d", localIP, 24000+config.GinkgoConfig.ParallelNode),
+		FakeMetron:          fmt.Sprintf("127.0.0.1:%d", 25000+config.GinkgoConfig.ParallelNode),
+		AnnouncementServer:  fmt.Sprintf("%s:%d", localIP, 26000+config.GinkgoConfig.ParallelNode),
+		CCUploader:          fmt.Sprintf("%s:%d", localIP, 27000+config.GinkgoConfig.ParallelNode),
+		FakeGardenCapacity:  fmt.Sprintf("127.0.0.1:%d", 28000+config.GinkgoConfig.ParallelNode),
+		Debug:               debugAddresses(29000),
 	}
 
+	world.Preflight(addresses)
+
 	gardenBinPath := os.Getenv("GARDEN_BINPATH")
 	gardenRootFSPath := os.Getenv("GARDEN_ROOTFS")
 	gardenGraphPath := os.Getenv("GARDEN_GRAPH_PATH")
@@ -42,20 +57,41 @@ func MakeComponentMaker(builtArtifacts world.BuiltArtifacts) world.ComponentMake
 		gardenGraphPath = os.TempDir()
 	}
 
-	Ω(gardenBinPath).ShouldNot(BeEmpty(), "must provide $GARDEN_BINPATH")
-	Ω(gardenRootFSPath).ShouldNot(BeEmpty(), "must provide $GARDEN_ROOTFS")
-	Ω(externalAddress).ShouldNot(BeEmpty(), "must provide $EXTERNAL_ADDRESS")
-
 	return world.ComponentMaker{
 		Artifacts: builtArtifacts,
 		Addresses: addresses,
 
-		Stack: StackName,
+		Stack: Stack(),
 
 		ExternalAddress: externalAddress,
 
 		GardenBinPath:    gardenBinPath,
 		GardenRootFSPath: gardenRootFSPath,
 		GardenGraphPath:  gardenGraphPath,
+
+		TempDirs: world.NewTempDirs(),
+		Timings:  world.NewTimings(),
+		LogFiles: world.NewLogFiles(os.Getenv("INIGO_COMPONENT_LOG_DIR")),
+		LiveLogs: world.NewLiveLogs(os.Getenv("INIGO_LIVE_LOGS") == "1", os.Getenv("INIGO_LOG_COMPONENTS")),
 	}
 }
+
+// SharedFileServerAddress is where the first parallel node runs the
+// world.SharedFileServer, reachable from containers like the FileServer.
+func SharedFileServerAddress() string {
+	localIP, err := localip.LocalIP()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return fmt.Sprintf("%s:%d", localIP, fileServerBasePort+world.SharedFileServerPortOffset)
+}
+
+// debugAddresses hands each of the DebuggableComponents a block of 100
+// ports from base, and each parallel node a port within it.
+func debugAddresses(base int) map[string]string {
+	addresses := map[string]string{}
+	for i, component := range world.DebuggableComponents {
+		addresses[component] = fmt.Sprintf("127.0.0.1:%d", base+100*i+config.GinkgoConfig.ParallelNode)
+	}
+
+	return addresses
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/onsi/ginkgo/config"
+	. "github.com/onsi/gomega"
+)
+
+var specsTracked int64
+
+// GardenContainerCount is how many containers Garden has, whoever owns them.
+func GardenContainerCount(gardenClient garden.Client) int {
+	containers, err := gardenClient.Containers(nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return len(containers)
+}
+
+// ContainerLeakDetector gives a spec an owner name of its own, for tagging
+// the containers it creates, so that any it leaves behind can be blamed on
+// it rather than on whichever spec next trips over them.
+type ContainerLeakDetector struct {
+	gardenClient garden.Client
+
+	Owner string
+}
+
+// NewContainerLeakDetector is meant for a suite's BeforeEach; hand its Owner
+// to the executor via ComponentMaker.WithContainerOwner.
+func NewContainerLeakDetector(gardenClient garden.Client) *ContainerLeakDetector {
+	spec := atomic.AddInt64(&specsTracked, 1)
+
+	return &ContainerLeakDetector{
+		gardenClient: gardenClient,
+
+		Owner: fmt.Sprintf("inigo-spec-%d-%d", config.GinkgoConfig.ParallelNode, spec),
+	}
+}
+
+// Leaks returns the handles of the containers still tagged with the spec's
+// owner. Call it from a suite's AfterEach, which runs once the spec's own
+// have cleaned up, and before Garden is wiped for the next spec; or after the
+// wipe, in a suite that leaves containers to it, for those it could not
+// destroy.
+func (detector *ContainerLeakDetector) Leaks() []string {
+	return OwnedContainersPoller(detector.gardenClient, detector.Owner)()
+}
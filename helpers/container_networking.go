@@ -0,0 +1,32 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/helpers/gardenx"
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// LRPContainer looks up the garden container running the given instance.
+func LRPContainer(receptorClient receptor.Client, gardenClient garden.Client, processGuid string, index int) garden.Container {
+	lrp, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, index)
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(lrp.InstanceGuid).ShouldNot(BeEmpty(), "instance %d of %s has no container yet", index, processGuid)
+
+	container, err := gardenClient.Lookup(lrp.InstanceGuid)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return container
+}
+
+// ContainerToContainerPoller reports whether the first instance of one LRP
+// can open a connection to the given port on the first instance of another,
+// by container IP rather than through the host.
+func ContainerToContainerPoller(receptorClient receptor.Client, gardenClient garden.Client, fromProcessGuid, toProcessGuid string, port uint16) func() bool {
+	return func() bool {
+		from := LRPContainer(receptorClient, gardenClient, fromProcessGuid, 0)
+		to := LRPContainer(receptorClient, gardenClient, toProcessGuid, 0)
+
+		return gardenx.CanConnect(from, gardenx.ContainerIP(to), port)
+	}
+}
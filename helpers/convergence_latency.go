@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Divergence is a disagreement with the desired state made on purpose, for
+// measuring how long convergence takes to repair it.
+type Divergence struct {
+	Description string
+	DivergedAt  time.Time
+
+	repaired func() bool
+}
+
+// DivergeByDeletingActualLRP deletes the record of the running instance at
+// index straight from etcd, as if it had been lost. It is repaired once the
+// converger has noticed the missing instance and a new one is running.
+func DivergeByDeletingActualLRP(etcdAddr string, receptorClient receptor.Client, processGuid string, index int) Divergence {
+	lost := WaitForLRPInstanceState(receptorClient, processGuid, index, receptor.ActualLRPStateRunning)
+
+	divergedAt := deleteActualLRPRecord(etcdAddr, processGuid, index)
+
+	return Divergence{
+		Description: fmt.Sprintf("deleted the actual LRP %s/%d", processGuid, index),
+		DivergedAt:  divergedAt,
+
+		repaired: func() bool {
+			lrp, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, index)
+			return err == nil && lrp.InstanceGuid != lost.InstanceGuid && lrp.State == receptor.ActualLRPStateRunning
+		},
+	}
+}
+
+// DivergeByOrphaningContainer creates a container in Garden behind the
+// executor's back, tagged as owned by ownerName, so that it belongs to no
+// instance at all. It is repaired once the executor owning that name has
+// noticed it is not tracking the container and destroyed it.
+func DivergeByOrphaningContainer(gardenClient garden.Client, ownerName string) Divergence {
+	divergedAt := time.Now()
+	orphaned := CreateGardenContainers(gardenClient, ownerName, 1)
+
+	return Divergence{
+		Description: fmt.Sprintf("orphaned the container %s", orphaned[0]),
+		DivergedAt:  divergedAt,
+
+		repaired: func() bool {
+			return len(SurvivingContainersPoller(gardenClient, orphaned)()) == 0
+		},
+	}
+}
+
+// WaitForRepair waits for the divergence to have been repaired, and returns
+// how long that took since it was made. It is only as fine as the polling
+// interval.
+func (divergence Divergence) WaitForRepair(timeout time.Duration) time.Duration {
+	Eventually(divergence.repaired, timeout).Should(BeTrue(), "never repaired: %s", divergence.Description)
+
+	latency := time.Since(divergence.DivergedAt)
+	fmt.Fprintf(ginkgo.GinkgoWriter, "repaired in %s: %s\n", latency, divergence.Description)
+
+	return latency
+}
+
+// ExpectRepairWithin waits for the divergence to have been repaired, and
+// fails if that took longer than budget. It waits a while past the budget
+// so that a slow repair is reported with how slow it was.
+func (divergence Divergence) ExpectRepairWithin(budget time.Duration) time.Duration {
+	latency := divergence.WaitForRepair(budget + Timeouts.Long)
+	Ω(latency).Should(BeNumerically("<=", budget), "took %s to repair, over the %s budget: %s", latency, budget, divergence.Description)
+
+	return latency
+}
+
+func deleteActualLRPRecord(etcdAddr string, processGuid string, index int) time.Time {
+	request, err := http.NewRequest("DELETE", "http://"+etcdAddr+"/v2/keys"+shared.ActualLRPSchemaPath(processGuid, index), nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	deletedAt := time.Now()
+
+	response, err := http.DefaultClient.Do(request)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer response.Body.Close()
+
+	Ω(response.StatusCode).Should(Equal(http.StatusOK), "etcd did not delete the actual LRP")
+
+	return deletedAt
+}
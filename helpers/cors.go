@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/gomega"
+)
+
+// CORSResponse is what a server told a browser about cross-origin access.
+type CORSResponse struct {
+	StatusCode int
+
+	AllowOrigin      string
+	AllowMethods     string
+	AllowHeaders     string
+	AllowCredentials string
+}
+
+// CORSPreflight sends the OPTIONS request a browser would before a
+// cross-origin request from origin with the given method and headers.
+func CORSPreflight(url string, origin string, method string, requestHeaders ...string) CORSResponse {
+	request, err := http.NewRequest("OPTIONS", url, nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	request.Header.Set("Origin", origin)
+	request.Header.Set("Access-Control-Request-Method", method)
+	if len(requestHeaders) > 0 {
+		request.Header.Set("Access-Control-Request-Headers", strings.Join(requestHeaders, ", "))
+	}
+
+	return corsResponse(request)
+}
+
+// CORSRequest sends a GET the way a browser would from a page at origin.
+func CORSRequest(url string, origin string) CORSResponse {
+	request, err := http.NewRequest("GET", url, nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	request.Header.Set("Origin", origin)
+
+	return corsResponse(request)
+}
+
+func corsResponse(request *http.Request) CORSResponse {
+	response, err := http.DefaultClient.Do(request)
+	Ω(err).ShouldNot(HaveOccurred())
+	response.Body.Close()
+
+	return CORSResponse{
+		StatusCode: response.StatusCode,
+
+		AllowOrigin:      response.Header.Get("Access-Control-Allow-Origin"),
+		AllowMethods:     response.Header.Get("Access-Control-Allow-Methods"),
+		AllowHeaders:     response.Header.Get("Access-Control-Allow-Headers"),
+		AllowCredentials: response.Header.Get("Access-Control-Allow-Credentials"),
+	}
+}
@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/gomega"
+)
+
+// DebugValues are the numbers a component reports on its debug endpoints,
+// by name, e.g. "HeapAlloc" or "goroutines".
+type DebugValues map[string]float64
+
+// debugPages are the pprof pages ScrapeDebugEndpoint reads; debug=1 makes
+// them human-readable, and so parseable.
+var debugPages = []string{
+	"/debug/pprof/heap?debug=1",
+	"/debug/pprof/goroutine?debug=1",
+}
+
+// ScrapeDebugEndpoint reads the debug server at addr, as enabled by
+// ComponentMaker.WithDebugServers, and returns what it reports: the
+// runtime's memory statistics, and how many goroutines are running.
+func ScrapeDebugEndpoint(addr string) DebugValues {
+	values := DebugValues{}
+
+	for _, page := range debugPages {
+		resp, err := http.Get("http://" + addr + page)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(resp.StatusCode).Should(Equal(http.StatusOK), "%s%s: %s", addr, page, body)
+
+		for name, value := range ParseDebugValues(string(body)) {
+			values[name] = value
+		}
+	}
+
+	return values
+}
+
+// ScrapeDebugEndpointPoller returns a single value from the debug server at
+// addr, for watching e.g. the goroutine count settle.
+func ScrapeDebugEndpointPoller(addr string, name string) func() float64 {
+	return func() float64 {
+		values := ScrapeDebugEndpoint(addr)
+		Ω(values).Should(HaveKey(name))
+
+		return values[name]
+	}
+}
+
+// ParseDebugValues picks the numbers out of a debug page. It understands
+// pprof's "# Name = value" memory statistics, its "goroutine profile: total
+// N" header, and Prometheus-style "name value" lines; anything else, e.g.
+// stack traces, is skipped.
+func ParseDebugValues(body string) DebugValues {
+	values := DebugValues{}
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "goroutine profile: total ") {
+			if value, err := strconv.ParseFloat(strings.TrimPrefix(line, "goroutine profile: total "), 64); err == nil {
+				values["goroutines"] = value
+			}
+			continue
+		}
+
+		var name, value string
+		if strings.HasPrefix(line, "# ") {
+			fields := strings.SplitN(strings.TrimPrefix(line, "# "), " = ", 2)
+			if len(fields) != 2 {
+				continue
+			}
+			name, value = fields[0], fields[1]
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			name, value = fields[0], fields[1]
+		}
+
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			values[name] = parsed
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/gomega"
+)
+
+// DirectorySize is the total size of the regular files under path, e.g. a
+// Garden graph. Files that go away while it is walking are skipped.
+func DirectorySize(path string) int64 {
+	var size int64
+
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return size
+}
+
+func DirectorySizePoller(path string) func() int64 {
+	return func() int64 {
+		return DirectorySize(path)
+	}
+}
@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// BumpDomain marks the domain fresh for ttl, or for good if ttl is 0, the
+// way nsync's bulker does after each sync with CC.
+func BumpDomain(receptorClient receptor.Client, domain string, ttl time.Duration) {
+	err := receptorClient.UpsertDomain(domain, ttl)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Ω(DomainFreshPoller(receptorClient, domain)()).Should(BeTrue(), "domain %s is not fresh right after bumping it", domain)
+}
+
+func DomainFreshPoller(receptorClient receptor.Client, domain string) func() bool {
+	return func() bool {
+		domains, err := receptorClient.Domains()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		for _, fresh := range domains {
+			if fresh == domain {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// WaitForDomainToGoStale waits for a domain bumped with the given ttl to
+// expire.
+func WaitForDomainToGoStale(receptorClient receptor.Client, domain string, ttl time.Duration) {
+	Eventually(DomainFreshPoller(receptorClient, domain), ttl+Timeouts.Short).Should(BeFalse(), "domain %s never went stale", domain)
+}
+
+// ExpectExtraInstancesKept asserts that the converger leaves the LRP's
+// instances alone, as it must while their domain is stale, even those
+// beyond what is desired.
+func ExpectExtraInstancesKept(receptorClient receptor.Client, processGuid string, instances int, convergeInterval time.Duration) {
+	Consistently(RunningLRPCountPoller(receptorClient, processGuid), 5*convergeInterval).Should(Equal(instances))
+}
+
+// ExpectExtraInstancesRetired asserts that the converger eventually stops
+// the LRP's instances beyond what is desired, as it may once their domain
+// is fresh.
+func ExpectExtraInstancesRetired(receptorClient receptor.Client, processGuid string, desired int) {
+	Eventually(RunningLRPCountPoller(receptorClient, processGuid)).Should(Equal(desired))
+}
@@ -0,0 +1,254 @@
+package helpers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+// EgressDestination is somewhere an EgressProbeServer tries to connect to.
+type EgressDestination struct {
+	Name string
+	IP   string
+	Port uint16
+}
+
+// the first three octets of the /24s NamespacedDestinations are made in,
+// inside ranges the egress specs deny, and neither routed anywhere real
+const (
+	// the range clouds serve instance metadata from, credentials included
+	LinkLocalSubnet = "169.254.253"
+
+	// TEST-NET-3, standing in for the internet at large
+	ExternalSubnet = "203.0.113"
+)
+
+// the port NamespacedDestinations listen on, in namespaces of their own
+const namespacedDestinationPort = 8080
+
+var namespacedDestinations int64
+
+// HostDestination is a listener on the host at hostIP, e.g. the maker's
+// ExternalAddress, for an egress probe to try to reach. Close it when done.
+func HostDestination(hostIP string) (EgressDestination, net.Listener) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(hostIP, "0"))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conn.Close()
+		}
+	}()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	Ω(err).ShouldNot(HaveOccurred())
+
+	portInt, err := strconv.Atoi(port)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return EgressDestination{"host", hostIP, uint16(portInt)}, listener
+}
+
+// NamespacedDestination is a listener for an egress probe to try to reach,
+// in a network namespace of its own at an address in subnet, e.g.
+// LinkLocalSubnet, that the host routes to over a veth pair. Reaching it
+// takes a container through garden's forwarding rules, the ones
+// -denyNetworks and -allowNetworks write, whereas a listener on the host is
+// only ever governed by -allowHostAccess. Like garden, it needs root. Close
+// it when done.
+func NamespacedDestination(maker world.ComponentMaker, name string, subnet string) (EgressDestination, *NamespacedListener) {
+	node := config.GinkgoConfig.ParallelNode
+	Ω(node).Should(BeNumerically("<", 64), "a /30 per node only fits 63 nodes in %s.0/24", subnet)
+
+	// a /30 per node: the host's end, then the namespace's
+	hostIP := fmt.Sprintf("%s.%d", subnet, 4*node+1)
+	destinationIP := fmt.Sprintf("%s.%d", subnet, 4*node+2)
+
+	id := atomic.AddInt64(&namespacedDestinations, 1)
+	namespace := fmt.Sprintf("inigo-egress-%d-%d", node, id)
+	hostVeth := fmt.Sprintf("ie%d-%dh", node, id)
+	namespaceVeth := fmt.Sprintf("ie%d-%dn", node, id)
+
+	runIP("netns", "add", namespace)
+	runIP("link", "add", hostVeth, "type", "veth", "peer", "name", namespaceVeth)
+	runIP("link", "set", namespaceVeth, "netns", namespace)
+	runIP("addr", "add", hostIP+"/30", "dev", hostVeth)
+	runIP("link", "set", hostVeth, "up")
+	runIP("netns", "exec", namespace, "ip", "addr", "add", destinationIP+"/30", "dev", namespaceVeth)
+	runIP("netns", "exec", namespace, "ip", "link", "set", namespaceVeth, "up")
+	runIP("netns", "exec", namespace, "ip", "route", "add", "default", "via", hostIP)
+
+	// anything that accepts connections will do
+	cmd := exec.Command("ip", "netns", "exec", namespace, maker.Artifacts.Fixtures["websocket-echo"])
+	cmd.Env = append(os.Environ(), fmt.Sprintf("PORT=%d", namespacedDestinationPort))
+
+	session, err := gexec.Start(cmd, ginkgo.GinkgoWriter, ginkgo.GinkgoWriter)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	listener := &NamespacedListener{namespace: namespace, session: session}
+	address := net.JoinHostPort(destinationIP, strconv.Itoa(namespacedDestinationPort))
+
+	Eventually(func() error {
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}).ShouldNot(HaveOccurred(), "%s never listened on %s", name, address)
+
+	return EgressDestination{name, destinationIP, namespacedDestinationPort}, listener
+}
+
+// NamespacedListener is what listens for a NamespacedDestination, and the
+// network namespace it listens in.
+type NamespacedListener struct {
+	namespace string
+	session   *gexec.Session
+}
+
+// Close stops the listener and deletes its namespace, which takes the veth
+// pair with it. A nil listener has nothing to close.
+func (listener *NamespacedListener) Close() {
+	if listener == nil {
+		return
+	}
+
+	listener.session.Kill().Wait()
+	runIP("netns", "delete", listener.namespace)
+}
+
+func runIP(args ...string) {
+	output, err := exec.Command("ip", args...).CombinedOutput()
+	Ω(err).ShouldNot(HaveOccurred(), "ip %s: %s", strings.Join(args, " "), output)
+}
+
+// EgressProbeServer is a bash script that serves, on port, whether each
+// of the destinations could be connected to from inside the container,
+// trying them all again for every request.
+func EgressProbeServer(port uint16, destinations []EgressDestination) string {
+	probes := []string{}
+	for _, destination := range destinations {
+		probes = append(probes, fmt.Sprintf(
+			`if nc -z -w 2 %s %d; then echo %s=yes; else echo %s=no; fi`,
+			destination.IP, destination.Port, destination.Name, destination.Name,
+		))
+	}
+
+	return fmt.Sprintf(`
+		mkfifo request
+
+		while true; do
+		{
+			read < request
+
+			report=$(%s)
+
+			echo -n -e "HTTP/1.1 200 OK\r\n"
+			echo -n -e "Content-Length: ${#report}\r\n\r\n"
+			echo -n "${report}"
+		} | nc -l 0.0.0.0 %d > request;
+		done
+	`, strings.Join(probes, "; "), port)
+}
+
+// DesireEgressProbeLRP desires a single instance serving an
+// EgressProbeServer's report on port 8080, and waits for it to run.
+func DesireEgressProbeLRP(receptorClient receptor.Client, domain string, stack string, processGuid string, destinations []EgressDestination) receptor.ActualLRPResponse {
+	err := receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+		Domain:      domain,
+		ProcessGuid: processGuid,
+		Instances:   1,
+		Stack:       stack,
+		MemoryMB:    128,
+
+		Ports: []uint16{8080},
+
+		Action: &models.RunAction{
+			Path: "bash",
+			Args: []string{"-c", EgressProbeServer(8080, destinations)},
+		},
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return WaitForLRPInstanceState(receptorClient, processGuid, 0, receptor.ActualLRPStateRunning)
+}
+
+// ParseEgressReport reads an EgressProbeServer's report, by destination
+// name, failing if any of the destinations is missing from it.
+func ParseEgressReport(output string, destinations []EgressDestination) (map[string]bool, error) {
+	report := map[string]bool{}
+
+	for _, line := range strings.Split(output, "\n") {
+		name, reachable, ok := cutIsolationLine(strings.TrimSpace(line))
+		if ok {
+			report[name] = reachable
+		}
+	}
+
+	for _, destination := range destinations {
+		if _, found := report[destination.Name]; !found {
+			return nil, fmt.Errorf("egress report %q is missing %s", output, destination.Name)
+		}
+	}
+
+	return report, nil
+}
+
+// FetchEgressReport asks the EgressProbeServer on the instance for its
+// report, through the instance's mapped port.
+func FetchEgressReport(lrp receptor.ActualLRPResponse, destinations []EgressDestination) (map[string]bool, error) {
+	resp, err := http.Get("http://" + MappedAddress(lrp, 8080))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	return ParseEgressReport(string(body), destinations)
+}
+
+// ExpectEgress asserts, for each destination, whether the probe on the
+// instance could connect to it, as expected says by destination name. Every
+// destination must be in expected, so that none goes unchecked.
+func ExpectEgress(lrp receptor.ActualLRPResponse, destinations []EgressDestination, expected map[string]bool) {
+	var report map[string]bool
+	Eventually(func() error {
+		var err error
+		report, err = FetchEgressReport(lrp, destinations)
+		return err
+	}).ShouldNot(HaveOccurred())
+
+	for _, destination := range destinations {
+		reachable, found := expected[destination.Name]
+		Ω(found).Should(BeTrue(), "nothing expected of %s", destination.Name)
+		Ω(report[destination.Name]).Should(Equal(reachable), "whether %s was reachable", destination.Name)
+	}
+}
@@ -0,0 +1,186 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// WaitForLRPState waits for the LRP's first instance, as LRPStatePoller sees
+// it, to be in the given state, and returns it. It waits for the timeout if
+// one is given, and otherwise for Timeouts.Long, as long as the Eventually
+// it replaces would have.
+//
+// It follows the receptor's event stream and looks again as soon as an
+// event for the process guid arrives, so it returns about as soon as the
+// state is reached rather than on the next polling interval.
+func WaitForLRPState(receptorClient receptor.Client, processGuid string, state receptor.ActualLRPState, timeout ...time.Duration) receptor.ActualLRPResponse {
+	var lrp receptor.ActualLRPResponse
+	poller := LRPStatePoller(receptorClient, processGuid, &lrp)
+
+	reached := awaitEvents(
+		func() bool { return poller() == state },
+		receptorEventsFor(receptorClient, processGuid),
+		waitTimeout(timeout),
+	)
+	Ω(reached).Should(BeTrue(), "LRP %s never became %s; it is %s", processGuid, state, lrp.State)
+
+	return lrp
+}
+
+// WaitForLRPInstanceState is WaitForLRPState for the instance at index.
+func WaitForLRPInstanceState(receptorClient receptor.Client, processGuid string, index int, state receptor.ActualLRPState, timeout ...time.Duration) receptor.ActualLRPResponse {
+	var lrp receptor.ActualLRPResponse
+	poller := LRPInstanceStatePoller(receptorClient, processGuid, index, &lrp)
+
+	reached := awaitEvents(
+		func() bool { return poller() == state },
+		receptorEventsFor(receptorClient, processGuid),
+		waitTimeout(timeout),
+	)
+	Ω(reached).Should(BeTrue(), "LRP %s/%d never became %s; it is %s", processGuid, index, state, lrp.State)
+
+	return lrp
+}
+
+// WaitForContainerState waits for the executor's container to be in the
+// given state, and returns it. Like WaitForLRPState it looks again on every
+// event for the container, so it is only as slow as the executor, and waits
+// for Timeouts.Long unless given a timeout.
+//
+// There is no event for a container becoming created, so waiting for
+// executor.StateCreated falls back to polling.
+func WaitForContainerState(executorClient executor.Client, guid string, state executor.State, timeout ...time.Duration) executor.Container {
+	var container executor.Container
+
+	reached := awaitEvents(
+		func() bool {
+			var err error
+			container, err = executorClient.GetContainer(guid)
+			return err == nil && container.State == state
+		},
+		executorEventsFor(executorClient, guid),
+		waitTimeout(timeout),
+	)
+	Ω(reached).Should(BeTrue(), "container %s never became %s; it is %s", guid, state, container.State)
+
+	return container
+}
+
+// awaitEvents calls check until it passes or timeout expires, saying which.
+//
+// It checks again whenever events yields one that could have changed what
+// check looks at, and otherwise every Timeouts.EventFallbackPollingInterval,
+// in case the stream breaks or an event is missed. With no stream to follow,
+// e.g. when subscribing failed, it only polls.
+func awaitEvents(check func() bool, events eventWait, timeout time.Duration) bool {
+	if events.stop != nil {
+		defer events.stop()
+	}
+
+	// the events are subscribed to before this first look, so that nothing
+	// happening in between can be missed
+	if check() {
+		return true
+	}
+
+	relevant := make(chan struct{}, 1)
+
+	if events.next != nil {
+		go func() {
+			for {
+				ok, err := events.next()
+				if err != nil {
+					return
+				}
+
+				if !ok {
+					continue
+				}
+
+				select {
+				case relevant <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+
+	fallback := time.NewTicker(Timeouts.EventFallbackPollingInterval)
+	defer fallback.Stop()
+
+	expired := time.After(timeout)
+
+	for {
+		select {
+		case <-relevant:
+		case <-fallback.C:
+		case <-expired:
+			return check()
+		}
+
+		if check() {
+			return true
+		}
+	}
+}
+
+// eventWait is a stream for awaitEvents to follow. next blocks for an event,
+// and says whether it is relevant; it is called from another goroutine, and
+// returns an error once stop is called.
+type eventWait struct {
+	next func() (bool, error)
+	stop func()
+}
+
+func receptorEventsFor(receptorClient receptor.Client, processGuid string) eventWait {
+	eventSource, err := receptorClient.SubscribeToEvents()
+	if err != nil {
+		return eventWait{}
+	}
+
+	return eventWait{
+		next: func() (bool, error) {
+			event, err := eventSource.Next()
+			if err != nil {
+				return false, err
+			}
+
+			return receptorEventProcessGuid(event) == processGuid, nil
+		},
+		stop: func() { eventSource.Close() },
+	}
+}
+
+func executorEventsFor(executorClient executor.Client, guid string) eventWait {
+	eventSource, err := executorClient.SubscribeToEvents()
+	if err != nil {
+		return eventWait{}
+	}
+
+	return eventWait{
+		next: func() (bool, error) {
+			event, err := eventSource.Next()
+			if err != nil {
+				return false, err
+			}
+
+			e, ok := event.(containerEvent)
+			return ok && e.Container().Guid == guid, nil
+		},
+		stop: func() { eventSource.Close() },
+	}
+}
+
+// waitTimeout is the optional timeout the waits take, defaulting to
+// Timeouts.Long rather than Gomega's default Eventually timeout, which a
+// suite may have changed
+func waitTimeout(timeout []time.Duration) time.Duration {
+	if len(timeout) > 0 {
+		return timeout[0]
+	}
+
+	return Timeouts.Long
+}
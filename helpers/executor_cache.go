@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	. "github.com/onsi/gomega"
+)
+
+type CacheEntry struct {
+	Name string
+	Size int64
+}
+
+// ExecutorCachePath returns the download cache directory the executor run by
+// the given runner was told to use.
+func ExecutorCachePath(executorRunner *world.TimedRunner) string {
+	cachePath := ""
+
+	args := executorRunner.Command.Args
+	for i, arg := range args {
+		// later flags win, as they do for the executor itself
+		if arg == "-cachePath" && i+1 < len(args) {
+			cachePath = args[i+1]
+		}
+	}
+
+	Ω(cachePath).ShouldNot(BeEmpty(), "executor was not given a -cachePath")
+
+	return cachePath
+}
+
+// ExecutorCacheEntries lists the files in the executor's download cache,
+// sorted by name. A cache directory that does not exist yet is empty.
+func ExecutorCacheEntries(cachePath string) []CacheEntry {
+	infos, err := ioutil.ReadDir(cachePath)
+	if os.IsNotExist(err) {
+		return []CacheEntry{}
+	}
+	Ω(err).ShouldNot(HaveOccurred())
+
+	entries := []CacheEntry{}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+
+		entries = append(entries, CacheEntry{Name: info.Name(), Size: info.Size()})
+	}
+
+	return entries
+}
+
+func ExecutorCacheSize(cachePath string) int64 {
+	var size int64
+	for _, entry := range ExecutorCacheEntries(cachePath) {
+		size += entry.Size
+	}
+
+	return size
+}
+
+func ExecutorCacheEntryCountPoller(cachePath string) func() int {
+	return func() int {
+		return len(ExecutorCacheEntries(cachePath))
+	}
+}
@@ -0,0 +1,197 @@
+package helpers
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/executor"
+	. "github.com/onsi/gomega"
+)
+
+// ReconnectingEventSource is an executor.EventSource that survives the
+// executor restarting. When the stream breaks it subscribes again, and then
+// catches up by comparing every container with the state last seen for it,
+// so that transitions made while it was away still show up as events.
+//
+// Next may be called from a goroutine of its own while the others are
+// called from the spec's.
+type ReconnectingEventSource struct {
+	client executor.Client
+
+	source executor.EventSource
+	closed bool
+
+	// the last state seen for each container; the cursor caught up from
+	seen map[string]executor.State
+
+	pending []executor.Event
+	lost    []string
+
+	lock *sync.Mutex
+}
+
+type containerEvent interface {
+	Container() executor.Container
+}
+
+func SubscribeToExecutorEvents(client executor.Client) *ReconnectingEventSource {
+	source, err := client.SubscribeToEvents()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return &ReconnectingEventSource{
+		client: client,
+		source: source,
+		seen:   map[string]executor.State{},
+		lock:   new(sync.Mutex),
+	}
+}
+
+// Watch starts following a container the stream has not mentioned yet, so
+// that it is caught up on after a reconnect even if no event was seen for it.
+func (s *ReconnectingEventSource) Watch(guid string) {
+	container, err := s.client.GetContainer(guid)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.seen[guid] = container.State
+}
+
+// Lost returns the containers that were gone when the source caught up,
+// e.g. because the restarted executor destroyed them.
+func (s *ReconnectingEventSource) Lost() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.lost...)
+}
+
+func (s *ReconnectingEventSource) Next() (executor.Event, error) {
+	for {
+		s.lock.Lock()
+		if len(s.pending) > 0 {
+			event := s.pending[0]
+			s.pending = s.pending[1:]
+			s.lock.Unlock()
+			return event, nil
+		}
+
+		// not held while waiting, so that Close can interrupt it
+		source := s.source
+		s.lock.Unlock()
+
+		event, err := source.Next()
+		if err != nil {
+			if s.isClosed() {
+				return nil, err
+			}
+
+			s.reconnect()
+			continue
+		}
+
+		if s.alreadySeen(event) {
+			// already caught up on while reconnecting
+			continue
+		}
+
+		return event, nil
+	}
+}
+
+func (s *ReconnectingEventSource) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+	return s.source.Close()
+}
+
+func (s *ReconnectingEventSource) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.closed
+}
+
+// alreadySeen records the state a container event is for, reporting whether
+// it had been seen before.
+func (s *ReconnectingEventSource) alreadySeen(event executor.Event) bool {
+	e, ok := event.(containerEvent)
+	if !ok {
+		return false
+	}
+
+	container := e.Container()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if state, ok := s.seen[container.Guid]; ok && state == container.State {
+		return true
+	}
+
+	s.seen[container.Guid] = container.State
+	return false
+}
+
+func (s *ReconnectingEventSource) reconnect() {
+	var source executor.EventSource
+	Eventually(func() error {
+		var err error
+		source, err = s.client.SubscribeToEvents()
+		return err
+	}, Timeouts.Long).ShouldNot(HaveOccurred(), "never managed to resubscribe to the executor's events")
+
+	containers, err := s.client.ListContainers(nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.source.Close()
+	s.source = source
+
+	if s.closed {
+		// closed while resubscribing, so Next must not wait on the new one
+		s.source.Close()
+		return
+	}
+
+	present := map[string]bool{}
+	for _, container := range containers {
+		present[container.Guid] = true
+
+		if state, ok := s.seen[container.Guid]; ok && state == container.State {
+			continue
+		}
+
+		s.seen[container.Guid] = container.State
+
+		if event := eventForState(container); event != nil {
+			s.pending = append(s.pending, event)
+		}
+	}
+
+	for guid := range s.seen {
+		if !present[guid] {
+			delete(s.seen, guid)
+			s.lost = append(s.lost, guid)
+		}
+	}
+}
+
+// eventForState is the event the executor emits on a container reaching
+// its current state, if any.
+func eventForState(container executor.Container) executor.Event {
+	switch container.State {
+	case executor.StateReserved:
+		return executor.NewContainerReservedEvent(container)
+	case executor.StateRunning:
+		return executor.NewContainerRunningEvent(container)
+	case executor.StateCompleted:
+		return executor.NewContainerCompleteEvent(container)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,24 @@
+package helpers
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	. "github.com/onsi/ginkgo"
+)
+
+// SkipUnlessExecutorSupports skips the spec unless the executor the maker
+// runs has every one of flags, e.g. "maxLogLinesPerSecond", for options the
+// executor only grew recently.
+func SkipUnlessExecutorSupports(maker world.ComponentMaker, flags ...string) {
+	// exits nonzero after printing its usage
+	usage, _ := exec.Command(maker.Artifacts.Executables["exec"], "-help").CombinedOutput()
+
+	for _, flag := range flags {
+		if !regexp.MustCompile(`(?m)^\s*-` + regexp.QuoteMeta(flag) + `\b`).Match(usage) {
+			Skip(fmt.Sprintf("this executor has no -%s flag", flag))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/gomega"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+)
+
+// FakeBuildpackServer serves buildpacks the way the places apps point CC at
+// for custom buildpacks do: as zips over HTTP, and as git repositories over
+// git's dumb HTTP protocol. It counts the requests for each buildpack, and
+// can be told to refuse one.
+type FakeBuildpackServer struct {
+	Address string
+
+	server *httptest.Server
+	dir    string
+
+	// requests and status codes to refuse with, by buildpack name
+	requests map[string]int
+	refusals map[string]int
+	lock     *sync.RWMutex
+}
+
+// NewFakeBuildpackServer listens on listenHost, which staging containers
+// must be able to reach, e.g. the maker's ExternalAddress.
+func NewFakeBuildpackServer(listenHost string) *FakeBuildpackServer {
+	dir, err := ioutil.TempDir("", "fake-buildpack-server")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	buildpackServer := &FakeBuildpackServer{
+		dir:      dir,
+		requests: map[string]int{},
+		refusals: map[string]int{},
+		lock:     new(sync.RWMutex),
+	}
+
+	buildpackServer.server, buildpackServer.Address = Callback(listenHost, buildpackServer.serveHTTP)
+
+	return buildpackServer
+}
+
+func (buildpackServer *FakeBuildpackServer) Close() {
+	buildpackServer.server.Close()
+
+	err := os.RemoveAll(buildpackServer.dir)
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+// AddZipBuildpack serves the files zipped up at ZipURL(name).
+func (buildpackServer *FakeBuildpackServer) AddZipBuildpack(name string, files []archive_helper.ArchiveFile) {
+	archive_helper.CreateZipArchive(filepath.Join(buildpackServer.dir, name+".zip"), files)
+}
+
+// AddGitBuildpack serves the files, committed to a repository of their
+// own, at GitURL(name).
+func (buildpackServer *FakeBuildpackServer) AddGitBuildpack(name string, files []archive_helper.ArchiveFile) {
+	repoDir := filepath.Join(buildpackServer.dir, name)
+
+	err := os.MkdirAll(repoDir, 0755)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	for _, file := range files {
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0755
+		}
+
+		path := filepath.Join(repoDir, file.Name)
+
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		err = ioutil.WriteFile(path, []byte(file.Body), os.FileMode(mode))
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+
+		output, err := cmd.CombinedOutput()
+		Ω(err).ShouldNot(HaveOccurred(), "git %s: %s", strings.Join(args, " "), output)
+	}
+
+	git("init")
+	git("config", "user.email", "inigo@example.com")
+	git("config", "user.name", "inigo")
+	git("add", "-A")
+	git("commit", "-m", "buildpack")
+	git("update-server-info")
+}
+
+// ZipURL is where a buildpack added with AddZipBuildpack is served.
+func (buildpackServer *FakeBuildpackServer) ZipURL(name string) string {
+	return "http://" + buildpackServer.Address + "/" + name + ".zip"
+}
+
+// GitURL is where a buildpack added with AddGitBuildpack is served.
+func (buildpackServer *FakeBuildpackServer) GitURL(name string) string {
+	return "http://" + buildpackServer.Address + "/" + name + "/.git"
+}
+
+// Refuse makes the server answer every request for the buildpack with the
+// status code, e.g. 404 for one that has been taken down; 0 stops refusing.
+func (buildpackServer *FakeBuildpackServer) Refuse(name string, statusCode int) {
+	buildpackServer.lock.Lock()
+	defer buildpackServer.lock.Unlock()
+
+	buildpackServer.refusals[name] = statusCode
+}
+
+// Requests is how many requests for the buildpack the server has had,
+// refused ones included; cloning a git buildpack takes several.
+func (buildpackServer *FakeBuildpackServer) Requests(name string) int {
+	buildpackServer.lock.RLock()
+	defer buildpackServer.lock.RUnlock()
+
+	return buildpackServer.requests[name]
+}
+
+// UnreachableBuildpackURL is a zip buildpack URL that nothing listens on.
+func UnreachableBuildpackURL(listenHost string) string {
+	listener, err := net.Listen("tcp", listenHost+":0")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	address := listener.Addr().String()
+
+	err = listener.Close()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return "http://" + address + "/buildpack.zip"
+}
+
+func (buildpackServer *FakeBuildpackServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0], ".zip")
+
+	buildpackServer.lock.Lock()
+	buildpackServer.requests[name]++
+	refusal := buildpackServer.refusals[name]
+	buildpackServer.lock.Unlock()
+
+	if refusal != 0 {
+		w.WriteHeader(refusal)
+		return
+	}
+
+	http.FileServer(http.Dir(buildpackServer.dir)).ServeHTTP(w, r)
+}
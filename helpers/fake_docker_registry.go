@@ -0,0 +1,209 @@
+package helpers
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/gomega"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+)
+
+// FakeDockerRegistry serves single-layer images over the v1 Docker registry
+// API, enough for Garden to pull them as rootfses, e.g. to fill its graph
+// with layers that nothing else uses. Once told to RequireCredentials, it
+// only answers requests carrying them, e.g. those of
+// ComponentMaker.WithDockerRegistryCredentials.
+type FakeDockerRegistry struct {
+	Address string
+
+	server *httptest.Server
+
+	// layers by image id, and image ids by repository
+	layers       map[string][]byte
+	repositories map[string]string
+
+	// if username is set, the basic auth credentials required
+	username string
+	password string
+
+	authorizedRequests   []string
+	unauthorizedRequests []string
+	lock                 *sync.RWMutex
+}
+
+func NewFakeDockerRegistry(listenHost string) *FakeDockerRegistry {
+	registry := &FakeDockerRegistry{
+		layers:       map[string][]byte{},
+		repositories: map[string]string{},
+		lock:         new(sync.RWMutex),
+	}
+
+	registry.server, registry.Address = Callback(listenHost, registry.serveHTTP)
+
+	return registry
+}
+
+func (registry *FakeDockerRegistry) Close() {
+	registry.server.Close()
+}
+
+// RequireCredentials makes the registry refuse every request without the
+// given basic auth credentials.
+func (registry *FakeDockerRegistry) RequireCredentials(username, password string) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	registry.username = username
+	registry.password = password
+}
+
+// AddImage serves the tarball layer as the only layer of the image name, at
+// tag latest.
+func (registry *FakeDockerRegistry) AddImage(name string, layer []byte) {
+	id := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	registry.layers[id] = layer
+	registry.repositories[name] = id
+}
+
+// LayerOf is a tarball of files, for AddImage.
+func LayerOf(files []archive_helper.ArchiveFile) []byte {
+	layer := new(bytes.Buffer)
+	writer := tar.NewWriter(layer)
+
+	for _, file := range files {
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+
+		err := writer.WriteHeader(&tar.Header{
+			Name: file.Name,
+			Mode: mode,
+			Size: int64(len(file.Body)),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = writer.Write([]byte(file.Body))
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	err := writer.Close()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return layer.Bytes()
+}
+
+// RootFSURL is what to create a container with for it to run in the image
+// name.
+func (registry *FakeDockerRegistry) RootFSURL(name string) string {
+	return fmt.Sprintf("docker://%s/%s", registry.Address, name)
+}
+
+func (registry *FakeDockerRegistry) AuthorizedRequests() []string {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	return append([]string{}, registry.authorizedRequests...)
+}
+
+func (registry *FakeDockerRegistry) UnauthorizedRequests() []string {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	return append([]string{}, registry.unauthorizedRequests...)
+}
+
+func (registry *FakeDockerRegistry) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !registry.authorize(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="fake-docker-registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.URL.Path == "/v1/_ping":
+		w.Header().Set("X-Docker-Registry-Version", "0.6.0")
+		writeRegistryJSON(w, map[string]string{})
+
+	case len(path) >= 4 && path[0] == "v1" && path[1] == "repositories":
+		id, found := registry.repositories[strings.TrimPrefix(strings.Join(path[2:len(path)-1], "/"), "library/")]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch path[len(path)-1] {
+		case "images":
+			w.Header().Set("X-Docker-Endpoints", registry.Address)
+			w.Header().Set("X-Docker-Token", "signature=fake,access=read")
+			writeRegistryJSON(w, []map[string]string{{"id": id}})
+		case "tags":
+			writeRegistryJSON(w, map[string]string{"latest": id})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+	case len(path) == 4 && path[0] == "v1" && path[1] == "images":
+		id := path[2]
+
+		layer, found := registry.layers[id]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch path[3] {
+		case "ancestry":
+			writeRegistryJSON(w, []string{id})
+		case "json":
+			w.Header().Set("X-Docker-Size", strconv.Itoa(len(layer)))
+			writeRegistryJSON(w, map[string]interface{}{"id": id, "Size": len(layer)})
+		case "layer":
+			w.Write(layer)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+	default:
+		// including /v2/, so that clients fall back to v1
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// authorize records the request as authorized or not, going by the
+// credentials required, if any.
+func (registry *FakeDockerRegistry) authorize(r *http.Request) bool {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	username, password, ok := r.BasicAuth()
+	if registry.username != "" && (!ok || username != registry.username || password != registry.password) {
+		registry.unauthorizedRequests = append(registry.unauthorizedRequests, r.URL.Path)
+		return false
+	}
+
+	registry.authorizedRequests = append(registry.authorizedRequests, r.URL.Path)
+	return true
+}
+
+func writeRegistryJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,11 @@
+package helpers
+
+import "github.com/cloudfoundry-incubator/garden"
+
+// PinnedGardenCapacity is a capacity small enough for specs to fill up on
+// any machine; see ComponentMaker.WithGardenCapacity.
+var PinnedGardenCapacity = garden.Capacity{
+	MemoryInBytes: 1024 * 1024 * 1024,
+	DiskInBytes:   2 * 1024 * 1024 * 1024,
+	MaxContainers: 4,
+}
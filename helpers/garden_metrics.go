@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/helpers/gardenx"
+	. "github.com/onsi/gomega"
+)
+
+// DiskUsageScope is what ContainerDiskUsage counts.
+type DiskUsageScope int
+
+const (
+	// ExclusiveDiskUsage is what the container's processes have written, as
+	// Garden reports it; it is what a DiskMB limit is enforced on.
+	ExclusiveDiskUsage DiskUsageScope = iota
+
+	// TotalDiskUsage is everything the container sees on its root
+	// filesystem, the rootfs it was made from included.
+	TotalDiskUsage
+)
+
+// ContainerDiskUsage returns the bytes the container uses on disk, in the
+// given scope.
+func ContainerDiskUsage(gardenClient garden.Client, handle string, scope DiskUsageScope) uint64 {
+	usage, err := containerDiskUsage(gardenClient, handle, scope)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return usage
+}
+
+// ContainerDiskUsagePoller reports 0 until the container exists.
+func ContainerDiskUsagePoller(gardenClient garden.Client, handle string, scope DiskUsageScope) func() uint64 {
+	return func() uint64 {
+		usage, err := containerDiskUsage(gardenClient, handle, scope)
+		if err != nil {
+			return 0
+		}
+
+		return usage
+	}
+}
+
+// ContainerCPUUsage returns the total CPU time, in nanoseconds, consumed by
+// the container's processes.
+func ContainerCPUUsage(gardenClient garden.Client, handle string) uint64 {
+	metrics, err := containerMetrics(gardenClient, handle)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return metrics.CPUStat.Usage
+}
+
+// ContainerCPUUsagePoller reports 0 until the container exists.
+func ContainerCPUUsagePoller(gardenClient garden.Client, handle string) func() uint64 {
+	return func() uint64 {
+		metrics, err := containerMetrics(gardenClient, handle)
+		if err != nil {
+			return 0
+		}
+
+		return metrics.CPUStat.Usage
+	}
+}
+
+func containerMetrics(gardenClient garden.Client, handle string) (garden.Metrics, error) {
+	container, err := gardenClient.Lookup(handle)
+	if err != nil {
+		return garden.Metrics{}, err
+	}
+
+	return container.Metrics()
+}
+
+func containerDiskUsage(gardenClient garden.Client, handle string, scope DiskUsageScope) (uint64, error) {
+	if scope == ExclusiveDiskUsage {
+		metrics, err := containerMetrics(gardenClient, handle)
+		if err != nil {
+			return 0, err
+		}
+
+		return metrics.DiskStat.BytesUsed, nil
+	}
+
+	container, err := gardenClient.Lookup(handle)
+	if err != nil {
+		return 0, err
+	}
+
+	// Garden only reports what the container wrote, so the rootfs is
+	// counted from inside
+	output := gardenx.MustRun(container, "du", "-sxb", "/")
+
+	return strconv.ParseUint(strings.Fields(output)[0], 10, 64)
+}
@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/garden"
+	. "github.com/onsi/gomega"
+)
+
+// ContainerStateProperty is the Garden property the executor keeps each of
+// its containers' state in, e.g. "completed".
+const ContainerStateProperty = "executor:state"
+
+// GardenContainerInfo is what Garden says about one of its containers.
+type GardenContainerInfo struct {
+	Handle      string
+	State       string
+	ContainerIP string
+	Properties  garden.Properties
+}
+
+// Owner is the executor that tagged the container, if any.
+func (info GardenContainerInfo) Owner() string {
+	return info.Properties[ContainerOwnerProperty]
+}
+
+// ExecutorState is the state the executor last recorded for the container,
+// if any.
+func (info GardenContainerInfo) ExecutorState() string {
+	return info.Properties[ContainerStateProperty]
+}
+
+// ContainersWithProperty returns what Garden says about every container
+// whose property key is value, sorted by handle. Containers that are gone
+// by the time they are asked about are left out.
+func ContainersWithProperty(gardenClient garden.Client, key string, value string) []GardenContainerInfo {
+	return containersMatching(gardenClient, garden.Properties{key: value})
+}
+
+// GardenContainers is ContainersWithProperty for every container Garden has.
+func GardenContainers(gardenClient garden.Client) []GardenContainerInfo {
+	return containersMatching(gardenClient, nil)
+}
+
+func containersMatching(gardenClient garden.Client, properties garden.Properties) []GardenContainerInfo {
+	containers, err := gardenClient.Containers(properties)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	infos := []GardenContainerInfo{}
+	for _, container := range containers {
+		info, err := container.Info()
+		if err != nil {
+			continue
+		}
+
+		infos = append(infos, GardenContainerInfo{
+			Handle:      container.Handle(),
+			State:       info.State,
+			ContainerIP: info.ContainerIP,
+			Properties:  info.Properties,
+		})
+	}
+
+	sort.Sort(byHandle(infos))
+
+	return infos
+}
+
+// ContainersWithPropertyPoller is ContainersWithProperty, for Eventually.
+func ContainersWithPropertyPoller(gardenClient garden.Client, key string, value string) func() []GardenContainerInfo {
+	return func() []GardenContainerInfo {
+		return ContainersWithProperty(gardenClient, key, value)
+	}
+}
+
+// ContainerHandles is the handle of each of the containers, in order.
+func ContainerHandles(infos []GardenContainerInfo) []string {
+	handles := make([]string, 0, len(infos))
+	for _, info := range infos {
+		handles = append(handles, info.Handle)
+	}
+
+	return handles
+}
+
+// ContainerWithHandle is the one of the containers with the handle, failing
+// if there is none.
+func ContainerWithHandle(infos []GardenContainerInfo, handle string) GardenContainerInfo {
+	Ω(ContainerHandles(infos)).Should(ContainElement(handle))
+
+	for _, info := range infos {
+		if info.Handle == handle {
+			return info
+		}
+	}
+
+	return GardenContainerInfo{}
+}
+
+type byHandle []GardenContainerInfo
+
+func (s byHandle) Len() int           { return len(s) }
+func (s byHandle) Less(i, j int) bool { return s[i].Handle < s[j].Handle }
+func (s byHandle) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// RestartableGarden is a garden run on its own, outside of any group, so
+// that it can be bounced without touching the components that use it.
+type RestartableGarden struct {
+	maker   world.ComponentMaker
+	argv    []string
+	process ifrit.Process
+}
+
+func StartGarden(maker world.ComponentMaker, argv ...string) *RestartableGarden {
+	garden := &RestartableGarden{
+		maker: maker,
+		argv:  argv,
+	}
+
+	garden.process = ginkgomon.Invoke(maker.GardenLinux(argv...))
+
+	return garden
+}
+
+// Restart stops garden gracefully, giving it the chance to snapshot its
+// containers, and starts it again with the same flags.
+func (garden *RestartableGarden) Restart() {
+	garden.restart(false)
+}
+
+// RestartLosingContainers is Restart, but throws away garden's snapshots and
+// depot while it is down, as if the machine had been rebuilt.
+func (garden *RestartableGarden) RestartLosingContainers() {
+	garden.restart(true)
+}
+
+func (garden *RestartableGarden) restart(loseContainers bool) {
+	garden.process.Signal(syscall.SIGTERM)
+	Eventually(garden.process.Wait()).Should(Receive())
+
+	if loseContainers {
+		for _, dir := range []string{garden.maker.GardenDepotPath, garden.maker.GardenSnapshotsPath} {
+			emptyDir(dir)
+		}
+	}
+
+	garden.process = ginkgomon.Invoke(garden.maker.GardenLinux(garden.argv...))
+}
+
+func emptyDir(dir string) {
+	if dir == "" {
+		return
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	for _, entry := range entries {
+		err := os.RemoveAll(filepath.Join(dir, entry.Name()))
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+}
+
+func (garden *RestartableGarden) Stop() {
+	StopProcesses(garden.process)
+}
@@ -0,0 +1,111 @@
+package gardenx
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	. "github.com/onsi/gomega"
+)
+
+type ProcessResult struct {
+	ExitStatus int
+	Stdout     string
+	Stderr     string
+}
+
+// Run runs the given command in the container, waits for it to exit, and
+// returns its exit status and output.
+func Run(container garden.Container, path string, args ...string) ProcessResult {
+	return RunSpec(container, garden.ProcessSpec{
+		Path: path,
+		Args: args,
+	})
+}
+
+func RunSpec(container garden.Container, spec garden.ProcessSpec) ProcessResult {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	process, err := container.Run(spec, garden.ProcessIO{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	exitStatus, err := process.Wait()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return ProcessResult{
+		ExitStatus: exitStatus,
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+	}
+}
+
+// MustRun is Run, but fails unless the command exits 0.
+func MustRun(container garden.Container, path string, args ...string) string {
+	result := Run(container, path, args...)
+	Ω(result.ExitStatus).Should(Equal(0), "%s exited %d: %s", path, result.ExitStatus, result.Stderr)
+
+	return result.Stdout
+}
+
+// Processes returns the command line of every process running in the
+// container.
+func Processes(container garden.Container) []string {
+	output := MustRun(container, "ps", "-eo", "args=")
+
+	processes := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			processes = append(processes, line)
+		}
+	}
+
+	return processes
+}
+
+// ReadFile streams a single file out of the container and returns its
+// contents.
+func ReadFile(container garden.Container, path string) string {
+	stream, err := container.StreamOut(path)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	defer stream.Close()
+
+	tarReader := tar.NewReader(stream)
+
+	_, err = tarReader.Next()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	contents, err := ioutil.ReadAll(tarReader)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return string(contents)
+}
+
+func Properties(container garden.Container) garden.Properties {
+	info, err := container.Info()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return info.Properties
+}
+
+func ContainerIP(container garden.Container) string {
+	info, err := container.Info()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return info.ContainerIP
+}
+
+// CanConnect reports whether a TCP connection can be opened from inside the
+// container to the given address, giving up after a second.
+func CanConnect(container garden.Container, ip string, port uint16) bool {
+	result := Run(container, "nc", "-z", "-w", "1", ip, strconv.Itoa(int(port)))
+	return result.ExitStatus == 0
+}
@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/inigo_announcement_server"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	. "github.com/onsi/gomega"
+)
+
+// StopGracePeriod is how long Garden gives a stopped container's processes
+// between SIGTERM and SIGKILL.
+const StopGracePeriod = 10 * time.Second
+
+// DrainAnnouncements are what an instance of fixtures.GracefulShutdownLRP
+// announces as it starts and shuts down.
+type DrainAnnouncements struct {
+	Started  string
+	Draining string
+	Drained  string
+}
+
+func NewDrainAnnouncements(guid string) DrainAnnouncements {
+	return DrainAnnouncements{
+		Started:  guid + "-started",
+		Draining: guid + "-draining",
+		Drained:  guid + "-drained",
+	}
+}
+
+// Env configures fixtures.GracefulShutdownLRP to make these announcements,
+// taking drainTime to shut down once it gets SIGTERM.
+func (announcements DrainAnnouncements) Env(client *AnnouncementClient, drainTime time.Duration) []models.EnvironmentVariable {
+	return []models.EnvironmentVariable{
+		{"STARTED_URL", client.AnnounceURL(announcements.Started)},
+		{"DRAINING_URL", client.AnnounceURL(announcements.Draining)},
+		{"DRAINED_URL", client.AnnounceURL(announcements.Drained)},
+		{"DRAIN_SECONDS", strconv.Itoa(int(drainTime / time.Second))},
+	}
+}
+
+// ExpectDrainedGracefully asserts that the instance got SIGTERM, and was let
+// finish draining.
+func ExpectDrainedGracefully(client *AnnouncementClient, announcements DrainAnnouncements, drainTime time.Duration) {
+	Eventually(client.Announcements).Should(ContainElement(announcements.Draining), "never got SIGTERM")
+	Eventually(client.Announcements, drainTime+Timeouts.Short).Should(ContainElement(announcements.Drained), "was not let finish draining")
+
+	Ω(client.AnnouncementsInOrder()).Should(inigo_announcement_server.AnnouncedBefore(announcements.Draining, announcements.Drained))
+}
+
+// ExpectKilledWhileDraining asserts that the instance got SIGTERM, but was
+// killed once the grace period was up rather than let finish draining.
+func ExpectKilledWhileDraining(client *AnnouncementClient, announcements DrainAnnouncements, drainTime time.Duration) {
+	Ω(drainTime).Should(BeNumerically(">", StopGracePeriod), "the instance would finish draining within the grace period")
+
+	Eventually(client.Announcements).Should(ContainElement(announcements.Draining), "never got SIGTERM")
+	Consistently(client.Announcements, drainTime).ShouldNot(ContainElement(announcements.Drained), "was let drain past the grace period")
+}
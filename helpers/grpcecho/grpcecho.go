@@ -0,0 +1,103 @@
+// Package grpcecho is the client of the grpc_echo fixture. It is kept out
+// of helpers so that only the specs that speak gRPC depend on grpc-go.
+package grpcecho
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+var echoStream = grpc.StreamDesc{
+	StreamName:    "Echo",
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+// Echo sends message on a gRPC stream to the grpc_echo fixture at addr, e.g.
+// an instance's port mapping, and returns what it echoed back.
+func Echo(addr string, message string) (string, error) {
+	return echo(addr, addr, message)
+}
+
+// EchoThroughRouter is Echo through the router, to the app routed at host.
+func EchoThroughRouter(routerAddr string, host string, message string) (string, error) {
+	return echo(routerAddr, host+":80", message)
+}
+
+// echo dials dialAddr whatever the target, so that the target can name
+// the route for the :authority while the connection goes to the router.
+func echo(dialAddr string, target string, message string) (string, error) {
+	conn, err := grpc.Dial(
+		target,
+		grpc.WithInsecure(),
+		grpc.WithCodec(rawCodec{}),
+		grpc.WithDialer(func(_ string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("tcp", dialAddr, timeout)
+		}),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), helpers.Timeouts.Short)
+	defer cancel()
+
+	stream, err := grpc.NewClientStream(ctx, &echoStream, conn, "/inigo.Echo/Echo")
+	if err != nil {
+		return "", err
+	}
+
+	err = stream.SendMsg([]byte(message))
+	if err != nil {
+		return "", err
+	}
+
+	err = stream.CloseSend()
+	if err != nil {
+		return "", err
+	}
+
+	var reply []byte
+	err = stream.RecvMsg(&reply)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// rawCodec sends []byte messages as they are, matching the grpc_echo
+// fixture's.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch message := v.(type) {
+	case []byte:
+		return message, nil
+	case *[]byte:
+		return *message, nil
+	default:
+		return nil, fmt.Errorf("cannot send a %T", v)
+	}
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("cannot receive into a %T", v)
+	}
+
+	*message = append([]byte{}, data...)
+	return nil
+}
+
+func (rawCodec) String() string {
+	return "raw"
+}
@@ -0,0 +1,162 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models/factories"
+)
+
+// specGuids are the guids NewGuid has made since the current spec started,
+// by prefix.
+var specGuids = &guidRegistry{
+	guids: map[string][]string{},
+	lock:  new(sync.Mutex),
+}
+
+type guidRegistry struct {
+	specID   string
+	tracking bool
+	guids    map[string][]string
+	lock     *sync.Mutex
+}
+
+// StartSpecGuids forgets the guids of the last spec, and has NewGuid put
+// specID, e.g. the world.Timings' SpecID, in the ones it makes from now on.
+// Suites call it from their top-level BeforeEach, and CleanupSpecGuids
+// from their top-level AfterEach.
+func StartSpecGuids(specID string) {
+	startSpecGuids(specID, true)
+}
+
+// NameSpecGuids is StartSpecGuids for suites with nothing for
+// CleanupSpecGuids to do: their specs each start a fresh etcd, so no tasks
+// or LRPs outlive them, and no receptor is left by their top-level
+// AfterEach to clean up through. NewGuid still puts specID in guids, but
+// does not keep them.
+func NameSpecGuids(specID string) {
+	startSpecGuids(specID, false)
+}
+
+func startSpecGuids(specID string, tracking bool) {
+	specGuids.lock.Lock()
+	defer specGuids.lock.Unlock()
+
+	specGuids.specID = specID
+	specGuids.tracking = tracking
+	specGuids.guids = map[string][]string{}
+}
+
+// NewGuid is a fresh guid for a task, LRP, or whatever else a spec creates,
+// e.g. "task-n1-s12-<uuid>" for prefix "task": anything logged about it
+// says what it is and which spec made it (see ComponentLinesMentioning), and
+// CleanupSpecGuids can find it afterwards.
+func NewGuid(prefix string) string {
+	return newGuid(prefix, "")
+}
+
+// NewGuidWithSuffix is NewGuid with suffix, e.g. an index among a batch,
+// on the end. It is the suffixed guid that CleanupSpecGuids finds.
+func NewGuidWithSuffix(prefix string, suffix string) string {
+	return newGuid(prefix, suffix)
+}
+
+func newGuid(prefix string, suffix string) string {
+	specGuids.lock.Lock()
+	defer specGuids.lock.Unlock()
+
+	parts := []string{prefix}
+	if specGuids.specID != "" {
+		parts = append(parts, specGuids.specID)
+	}
+
+	parts = append(parts, factories.GenerateGuid())
+	if suffix != "" {
+		parts = append(parts, suffix)
+	}
+
+	guid := strings.Join(parts, "-")
+
+	if specGuids.tracking {
+		specGuids.guids[prefix] = append(specGuids.guids[prefix], guid)
+	}
+
+	return guid
+}
+
+// SpecGuids are the guids NewGuid has made with prefix during the current
+// spec, in order.
+func SpecGuids(prefix string) []string {
+	specGuids.lock.Lock()
+	defer specGuids.lock.Unlock()
+
+	return append([]string{}, specGuids.guids[prefix]...)
+}
+
+// CleanupSpecGuids deletes every desired LRP, and cancels and deletes every
+// Task, whose guid NewGuid made during the current spec, and returns what
+// failed. Like CleanupGarden, it carries on past failures so that one
+// leftover does not hide the rest.
+func CleanupSpecGuids(receptorClient receptor.Client) []error {
+	guids := specGuidSet()
+	errs := []error{}
+
+	lrps, err := receptorClient.DesiredLRPs()
+	if err != nil {
+		return []error{err}
+	}
+
+	for _, lrp := range lrps {
+		if !guids[lrp.ProcessGuid] {
+			continue
+		}
+
+		err := receptorClient.DeleteDesiredLRP(lrp.ProcessGuid)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("deleting LRP %s: %s", lrp.ProcessGuid, err))
+		}
+	}
+
+	tasks, err := receptorClient.Tasks()
+	if err != nil {
+		return append(errs, err)
+	}
+
+	for _, task := range tasks {
+		if !guids[task.TaskGuid] || task.State == receptor.TaskStateResolving {
+			// resolving tasks are already on their way out
+			continue
+		}
+
+		if task.State != receptor.TaskStateCompleted {
+			err := receptorClient.CancelTask(task.TaskGuid)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cancelling task %s: %s", task.TaskGuid, err))
+				continue
+			}
+		}
+
+		err := receptorClient.DeleteTask(task.TaskGuid)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("deleting task %s: %s", task.TaskGuid, err))
+		}
+	}
+
+	return errs
+}
+
+func specGuidSet() map[string]bool {
+	specGuids.lock.Lock()
+	defer specGuids.lock.Unlock()
+
+	set := map[string]bool{}
+	for _, guids := range specGuids.guids {
+		for _, guid := range guids {
+			set[guid] = true
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// http2Preface is what a client with prior knowledge opens an HTTP/2
+// cleartext connection with, followed by its SETTINGS.
+const http2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+const http2FrameSettings = 0x4
+
+// SpeaksHTTP2 opens an HTTP/2 cleartext connection to addr with prior
+// knowledge, and succeeds if the other end answers with its SETTINGS, as
+// only something speaking HTTP/2 would. An HTTP/1 server answers with an
+// error response instead, which is returned.
+func SpeaksHTTP2(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, Timeouts.Short)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(Timeouts.Short))
+
+	// an empty SETTINGS frame: no payload, on stream 0
+	settings := []byte{0, 0, 0, http2FrameSettings, 0, 0, 0, 0, 0}
+
+	_, err = conn.Write(append([]byte(http2Preface), settings...))
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 9)
+	_, err = io.ReadFull(conn, header)
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(header, []byte("HTTP/")) {
+		response := make([]byte, 128)
+		n, _ := conn.Read(response)
+		return fmt.Errorf("answered HTTP/1: %q", string(header)+string(response[:n]))
+	}
+
+	if header[3] != http2FrameSettings {
+		return fmt.Errorf("expected a SETTINGS frame first, got frame type %d", header[3])
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// HTTPSResponseCodeFromHostPoller is ResponseCodeFromHostPoller against the
+// router's TLS listener, trusting whatever tlsConfig trusts.
+func HTTPSResponseCodeFromHostPoller(routerTLSAddr string, host string, tlsConfig *tls.Config) func() (int, error) {
+	return func() (int, error) {
+		_, statusCode, err := HTTPSResponseBodyAndStatusCodeFromHost(routerTLSAddr, host, tlsConfig)
+		return statusCode, err
+	}
+}
+
+// HTTPSResponseBodyAndStatusCodeFromHost is ResponseBodyAndStatusCodeFromHost
+// against the router's TLS listener, trusting whatever tlsConfig trusts.
+func HTTPSResponseBodyAndStatusCodeFromHost(routerTLSAddr string, host string, tlsConfig *tls.Config) ([]byte, int, error) {
+	request := &http.Request{
+		URL: &url.URL{
+			Scheme: "https",
+			Host:   routerTLSAddr,
+			Path:   "/",
+		},
+
+		Host: host,
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return contents, response.StatusCode, nil
+}
@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// InstanceEnv is what fixtures.InstanceEnvLRP reports about its environment.
+type InstanceEnv struct {
+	CFInstanceAddr  string                 `json:"CF_INSTANCE_ADDR"`
+	CFInstanceIP    string                 `json:"CF_INSTANCE_IP"`
+	CFInstancePort  string                 `json:"CF_INSTANCE_PORT"`
+	CFInstancePorts []InstancePortMapping  `json:"CF_INSTANCE_PORTS"`
+	VCAPApplication map[string]interface{} `json:"VCAP_APPLICATION"`
+}
+
+type InstancePortMapping struct {
+	External uint16 `json:"external"`
+	Internal uint16 `json:"internal"`
+}
+
+// FetchInstanceEnv asks the fixtures.InstanceEnvLRP routed to host for its
+// environment.
+func FetchInstanceEnv(routerAddr string, host string) (InstanceEnv, error) {
+	body, status, err := ResponseBodyAndStatusCodeFromHost(routerAddr, host)
+	if err != nil {
+		return InstanceEnv{}, err
+	}
+
+	if status != http.StatusOK {
+		return InstanceEnv{}, fmt.Errorf("unexpected status %d: %s", status, body)
+	}
+
+	var env InstanceEnv
+	err = json.Unmarshal(body, &env)
+	if err != nil {
+		return InstanceEnv{}, fmt.Errorf("malformed environment %q: %s", body, err)
+	}
+
+	return env, nil
+}
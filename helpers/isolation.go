@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsolationReport is what IsolationProbe found out from inside a container.
+type IsolationReport struct {
+	// it could write to /proc/sys
+	ProcSysWritable bool
+
+	// it could see processes running outside of it, i.e. this test binary
+	HostPIDsVisible bool
+}
+
+// IsolationProbe is a bash script that prints an IsolationReport for
+// ParseIsolationReport. It should run as root in the container.
+//
+// Writing to /proc/sys writes back the ip_default_ttl it read, which only
+// touches the container's own network namespace, so the probe is harmless
+// even where it succeeds. Host processes are spotted by looking for this
+// test binary among every visible /proc/*/cmdline.
+func IsolationProbe() string {
+	binary := filepath.Base(os.Args[0])
+
+	// bracket the first letter so that grep does not find its own cmdline
+	pattern := "[" + binary[:1] + "]" + binary[1:]
+
+	return fmt.Sprintf(`
+		ttl=$(cat /proc/sys/net/ipv4/ip_default_ttl)
+		if echo "$ttl" > /proc/sys/net/ipv4/ip_default_ttl; then
+			echo proc-sys-writable=yes
+		else
+			echo proc-sys-writable=no
+		fi
+
+		if grep -qs '%s' /proc/[0-9]*/cmdline; then
+			echo host-pids-visible=yes
+		else
+			echo host-pids-visible=no
+		fi
+	`, pattern)
+}
+
+// IsolationProbeServer is a bash script that serves the IsolationProbe's
+// report over HTTP on port, for running it as an LRP.
+func IsolationProbeServer(port uint16) string {
+	return fmt.Sprintf(`
+		report=$(%s)
+
+		mkfifo request
+
+		while true; do
+		{
+			read < request
+
+			echo -n -e "HTTP/1.1 200 OK\r\n"
+			echo -n -e "Content-Length: ${#report}\r\n\r\n"
+			echo -n "${report}"
+		} | nc -l 0.0.0.0 %d > request;
+		done
+	`, IsolationProbe(), port)
+}
+
+// ParseIsolationReport reads the IsolationProbe's output, failing if
+// either of its lines is missing.
+func ParseIsolationReport(output string) (IsolationReport, error) {
+	report := IsolationReport{}
+	found := map[string]bool{}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := cutIsolationLine(strings.TrimSpace(line))
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "proc-sys-writable":
+			report.ProcSysWritable = value
+		case "host-pids-visible":
+			report.HostPIDsVisible = value
+		default:
+			continue
+		}
+
+		found[key] = true
+	}
+
+	if len(found) != 2 {
+		return IsolationReport{}, fmt.Errorf("incomplete isolation report %q", output)
+	}
+
+	return report, nil
+}
+
+// FetchIsolationReport asks the IsolationProbeServer routed to host for its
+// report.
+func FetchIsolationReport(routerAddr string, host string) (IsolationReport, error) {
+	body, status, err := ResponseBodyAndStatusCodeFromHost(routerAddr, host)
+	if err != nil {
+		return IsolationReport{}, err
+	}
+
+	if status != http.StatusOK {
+		return IsolationReport{}, fmt.Errorf("unexpected status %d: %s", status, body)
+	}
+
+	return ParseIsolationReport(string(body))
+}
+
+func cutIsolationLine(line string) (string, bool, bool) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", false, false
+	}
+
+	switch parts[1] {
+	case "yes":
+		return parts[0], true, true
+	case "no":
+		return parts[0], false, true
+	default:
+		return "", false, false
+	}
+}
@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// ExpectLRPToStayStarting waits for the LRP's only instance to be claimed by
+// a cell, then asserts that it is not reported running for the duration,
+// e.g. while its monitor has yet to pass.
+func ExpectLRPToStayStarting(receptorClient receptor.Client, processGuid string, duration time.Duration) {
+	Eventually(LRPStatePoller(receptorClient, processGuid, nil)).Should(Equal(receptor.ActualLRPStateClaimed))
+	Consistently(LRPStatePoller(receptorClient, processGuid, nil), duration).Should(Equal(receptor.ActualLRPStateClaimed))
+}
+
+// WaitForLRPToRun waits for the LRP's only instance to be running, and
+// returns how long that took from startedAt.
+func WaitForLRPToRun(receptorClient receptor.Client, processGuid string, startedAt time.Time) time.Duration {
+	WaitForLRPState(receptorClient, processGuid, receptor.ActualLRPStateRunning)
+	return time.Since(startedAt)
+}
+
+// ExpectLRPToCrashAfterStartTimeout asserts that the LRP's only instance,
+// having never passed its monitor, is not crashed before its start timeout,
+// and is once it has expired. It looks at the crash count rather than the
+// state, as the first crashes are restarted straight away.
+func ExpectLRPToCrashAfterStartTimeout(receptorClient receptor.Client, processGuid string, startTimeout time.Duration) {
+	Eventually(LRPStatePoller(receptorClient, processGuid, nil)).Should(Equal(receptor.ActualLRPStateClaimed))
+	claimedAt := time.Now()
+
+	Eventually(CrashCountPoller(receptorClient, processGuid, 0), startTimeout+Timeouts.Long).Should(BeNumerically(">=", 1))
+
+	// it was claimed a little before we saw it, so allow for the polling
+	Ω(time.Since(claimedAt)).Should(BeNumerically(">=", startTimeout-2*Timeouts.EventuallyPollingInterval), "crashed before its start timeout")
+}
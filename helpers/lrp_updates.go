@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/route-emitter/cfroutes"
+	. "github.com/onsi/gomega"
+)
+
+// ScaleLRP changes the number of instances desired, and waits for exactly
+// that many actual instances to be running.
+func ScaleLRP(receptorClient receptor.Client, processGuid string, instances int) {
+	err := receptorClient.UpdateDesiredLRP(processGuid, receptor.DesiredLRPUpdateRequest{
+		Instances: &instances,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Eventually(RunningLRPCountPoller(receptorClient, processGuid)).Should(Equal(instances))
+	Eventually(ActualLRPCountPoller(receptorClient, processGuid)).Should(Equal(instances))
+}
+
+// UpdateRoutes replaces the desired LRP's routes, and waits for the router
+// at routerStatusAddr to have each of their hostnames.
+func UpdateRoutes(receptorClient receptor.Client, routerStatusAddr string, processGuid string, routes cfroutes.CFRoutes) {
+	UpdateDesiredRoutes(receptorClient, processGuid, routes)
+
+	for _, route := range routes {
+		for _, hostname := range route.Hostnames {
+			Eventually(RouterRoutesPoller(routerStatusAddr)).Should(HaveKey(hostname), "the router never got route %s", hostname)
+		}
+	}
+}
+
+// UpdateDesiredRoutes replaces the desired LRP's routes, and waits for the
+// receptor to report them, but not for the router, e.g. for while the
+// router cannot hear of them.
+func UpdateDesiredRoutes(receptorClient receptor.Client, processGuid string, routes cfroutes.CFRoutes) {
+	err := receptorClient.UpdateDesiredLRP(processGuid, receptor.DesiredLRPUpdateRequest{
+		Routes: routes.RoutingInfo(),
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Eventually(func() cfroutes.CFRoutes {
+		desired, err := receptorClient.GetDesiredLRP(processGuid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		desiredRoutes, err := cfroutes.CFRoutesFromRoutingInfo(desired.Routes)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return desiredRoutes
+	}).Should(ConsistOf(routes))
+}
+
+func ActualLRPCountPoller(receptorClient receptor.Client, processGuid string) func() int {
+	return func() int {
+		lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return len(lrps)
+	}
+}
+
+func RunningLRPCountPoller(receptorClient receptor.Client, processGuid string) func() int {
+	return func() int {
+		lrps, err := receptorClient.ActualLRPsByProcessGuid(processGuid)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		running := 0
+		for _, lrp := range lrps {
+			if lrp.State == receptor.ActualLRPStateRunning {
+				running++
+			}
+		}
+
+		return running
+	}
+}
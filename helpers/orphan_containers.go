@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	. "github.com/onsi/gomega"
+)
+
+// ContainerOwnerProperty is the Garden property the executor tags its
+// containers with, and prunes by.
+const ContainerOwnerProperty = "executor:owner"
+
+// CreateGardenContainers creates count containers directly in Garden,
+// bypassing the executor. They are tagged as owned by ownerName, or left
+// unowned if ownerName is empty.
+func CreateGardenContainers(gardenClient garden.Client, ownerName string, count int) []string {
+	properties := garden.Properties{}
+	if ownerName != "" {
+		properties[ContainerOwnerProperty] = ownerName
+	}
+
+	handles := []string{}
+	for i := 0; i < count; i++ {
+		container, err := gardenClient.Create(garden.ContainerSpec{Properties: properties})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		handles = append(handles, container.Handle())
+	}
+
+	return handles
+}
+
+// OwnedContainersPoller returns the handles of every Garden container
+// tagged as owned by ownerName.
+func OwnedContainersPoller(gardenClient garden.Client, ownerName string) func() []string {
+	return func() []string {
+		return ContainerHandles(ContainersWithProperty(gardenClient, ContainerOwnerProperty, ownerName))
+	}
+}
+
+// SurvivingContainersPoller returns which of the given handles still exist
+// in Garden.
+func SurvivingContainersPoller(gardenClient garden.Client, handles []string) func() []string {
+	return func() []string {
+		surviving := []string{}
+		for _, handle := range handles {
+			if _, err := gardenClient.Lookup(handle); err == nil {
+				surviving = append(surviving, handle)
+			}
+		}
+
+		return surviving
+	}
+}
+
+// ExpectNoOrphanContainers waits for the executor to have pruned every
+// container tagged with its owner name. Use it in an AfterEach once
+// everything the spec allocated through the executor has been deleted, and
+// before the executor is stopped.
+func ExpectNoOrphanContainers(gardenClient garden.Client, ownerName string) {
+	Eventually(OwnedContainersPoller(gardenClient, ownerName)).Should(BeEmpty(), "%s left orphaned containers behind", ownerName)
+}
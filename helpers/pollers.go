@@ -57,3 +57,12 @@ func LRPInstanceStatePoller(receptorClient receptor.Client, processGuid string,
 		return lrpInstance.State
 	}
 }
+
+func CrashCountPoller(receptorClient receptor.Client, processGuid string, index int) func() int {
+	return func() int {
+		lrpInstance, err := receptorClient.ActualLRPByProcessGuidAndIndex(processGuid, index)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return lrpInstance.CrashCount
+	}
+}
@@ -0,0 +1,21 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/onsi/ginkgo"
+)
+
+// MappedAddress is the host address that the instance's containerPort is
+// mapped to, for reaching it without going through the router.
+func MappedAddress(lrp receptor.ActualLRPResponse, containerPort uint16) string {
+	for _, port := range lrp.Ports {
+		if uint16(port.ContainerPort) == containerPort {
+			return fmt.Sprintf("%s:%d", lrp.Address, port.HostPort)
+		}
+	}
+
+	ginkgo.Fail(fmt.Sprintf("instance %s/%d has no mapping for port %d", lrp.ProcessGuid, lrp.Index, containerPort))
+	return ""
+}
@@ -0,0 +1,21 @@
+package helpers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	. "github.com/onsi/gomega"
+)
+
+// ProcessEnv is the environment the component run by runner was started
+// with, e.g. to check what ComponentMaker.WithEnv gave it.
+func ProcessEnv(runner *world.TimedRunner) []string {
+	Ω(runner.Command.Process).ShouldNot(BeNil(), "%s has not been started", runner.Name)
+
+	environ, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/environ", runner.Command.Process.Pid))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return strings.Split(strings.TrimRight(string(environ), "\x00"), "\x00")
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// ResidentBytes is how much memory the component run by runner has
+// resident right now.
+func ResidentBytes(runner *world.TimedRunner) int64 {
+	return procStatusBytes(runner, "VmRSS")
+}
+
+// PeakResidentBytes is the most memory the component run by runner has had
+// resident at once since it started, e.g. to tell whether it streamed
+// something through or held all of it at some point.
+func PeakResidentBytes(runner *world.TimedRunner) int64 {
+	return procStatusBytes(runner, "VmHWM")
+}
+
+func procStatusBytes(runner *world.TimedRunner, field string) int64 {
+	Ω(runner.Command.Process).ShouldNot(BeNil(), "%s has not been started", runner.Name)
+
+	status, err := os.Open(fmt.Sprintf("/proc/%d/status", runner.Command.Process.Pid))
+	Ω(err).ShouldNot(HaveOccurred())
+	defer status.Close()
+
+	scanner := bufio.NewScanner(status)
+	for scanner.Scan() {
+		// e.g. "VmHWM:	   12345 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] != field+":" {
+			continue
+		}
+
+		kilobytes, err := strconv.ParseInt(fields[1], 10, 64)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return kilobytes * 1024
+	}
+
+	Ω(scanner.Err()).ShouldNot(HaveOccurred())
+	ginkgo.Fail(fmt.Sprintf("%s's status has no %s", runner.Name, field))
+
+	return 0
+}
@@ -0,0 +1,100 @@
+package helpers
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+type ReceptorEventCollector struct {
+	eventSource receptor.EventSource
+
+	events []receptor.Event
+	lock   *sync.RWMutex
+	done   chan struct{}
+}
+
+// ReceptorEvents subscribes to the receptor's event stream and buffers every
+// event received until Stop is called.
+func ReceptorEvents(receptorClient receptor.Client) *ReceptorEventCollector {
+	eventSource, err := receptorClient.SubscribeToEvents()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	collector := &ReceptorEventCollector{
+		eventSource: eventSource,
+
+		lock: new(sync.RWMutex),
+		done: make(chan struct{}),
+	}
+
+	go collector.collect()
+
+	return collector
+}
+
+func (collector *ReceptorEventCollector) collect() {
+	defer close(collector.done)
+
+	for {
+		event, err := collector.eventSource.Next()
+		if err != nil {
+			return
+		}
+
+		collector.lock.Lock()
+		collector.events = append(collector.events, event)
+		collector.lock.Unlock()
+	}
+}
+
+func (collector *ReceptorEventCollector) Stop() {
+	collector.eventSource.Close()
+	<-collector.done
+}
+
+func (collector *ReceptorEventCollector) Events() []receptor.Event {
+	collector.lock.RLock()
+	defer collector.lock.RUnlock()
+
+	return append([]receptor.Event{}, collector.events...)
+}
+
+// EventTypesFor returns the types of the events received for the given
+// process guid, in the order they were received.
+func (collector *ReceptorEventCollector) EventTypesFor(processGuid string) []receptor.EventType {
+	eventTypes := []receptor.EventType{}
+
+	for _, event := range collector.Events() {
+		if receptorEventProcessGuid(event) == processGuid {
+			eventTypes = append(eventTypes, event.EventType())
+		}
+	}
+
+	return eventTypes
+}
+
+func (collector *ReceptorEventCollector) EventTypesForPoller(processGuid string) func() []receptor.EventType {
+	return func() []receptor.EventType {
+		return collector.EventTypesFor(processGuid)
+	}
+}
+
+func receptorEventProcessGuid(event receptor.Event) string {
+	switch e := event.(type) {
+	case receptor.DesiredLRPCreatedEvent:
+		return e.DesiredLRPResponse.ProcessGuid
+	case receptor.DesiredLRPChangedEvent:
+		return e.After.ProcessGuid
+	case receptor.DesiredLRPRemovedEvent:
+		return e.DesiredLRPResponse.ProcessGuid
+	case receptor.ActualLRPCreatedEvent:
+		return e.ActualLRPResponse.ProcessGuid
+	case receptor.ActualLRPChangedEvent:
+		return e.After.ProcessGuid
+	case receptor.ActualLRPRemovedEvent:
+		return e.ActualLRPResponse.ProcessGuid
+	}
+
+	return ""
+}
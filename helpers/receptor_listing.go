@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// CreateTasks creates n copies of template in domain, concurrency of them at
+// a time and each with its own guid, and returns their guids, sorted.
+//
+// With no cells around the Tasks stay pending, which makes this a cheap way
+// to fill the BBS up for ListedTaskGuids and friends.
+func CreateTasks(receptorClient receptor.Client, domain string, n int, concurrency int, template receptor.TaskCreateRequest) []string {
+	guids := make(chan string)
+	go func() {
+		for i := 0; i < n; i++ {
+			guids <- NewGuidWithSuffix(domain, strconv.Itoa(i))
+		}
+
+		close(guids)
+	}()
+
+	created := []string{}
+	failures := []string{}
+	lock := new(sync.Mutex)
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for guid := range guids {
+				task := template
+				task.Domain = domain
+				task.TaskGuid = guid
+
+				err := receptorClient.CreateTask(task)
+
+				lock.Lock()
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %s", guid, err))
+				} else {
+					created = append(created, guid)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	Ω(failures).Should(BeEmpty(), "failed to create %d of %d tasks", len(failures), n)
+
+	sort.Strings(created)
+
+	return created
+}
+
+// ListedTaskGuids lists the guids of the Tasks in domain, or of every Task
+// if domain is empty, sorted.
+func ListedTaskGuids(receptorClient receptor.Client, domain string) []string {
+	var tasks []receptor.TaskResponse
+	var err error
+
+	if domain == "" {
+		tasks, err = receptorClient.Tasks()
+	} else {
+		tasks, err = receptorClient.TasksByDomain(domain)
+	}
+	Ω(err).ShouldNot(HaveOccurred())
+
+	guids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		guids = append(guids, task.TaskGuid)
+	}
+
+	sort.Strings(guids)
+
+	return guids
+}
@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// ShortRepHeartbeatInterval is how often a rep from RepWithShortPresence
+// refreshes its presence.
+const ShortRepHeartbeatInterval = 200 * time.Millisecond
+
+// RepWithShortPresence is the maker's rep with a heartbeat short enough that
+// its cell's presence expires within a couple of seconds of it stopping.
+func RepWithShortPresence(maker world.ComponentMaker, argv ...string) *world.TimedRunner {
+	return maker.Rep(append([]string{"-heartbeatInterval", ShortRepHeartbeatInterval.String()}, argv...)...)
+}
+
+// WaitForCellPresenceToExpire waits for the cell to deregister while its rep
+// is unable to heartbeat, and returns how long that took.
+func WaitForCellPresenceToExpire(receptorClient receptor.Client, cellID string) time.Duration {
+	startedAt := time.Now()
+	WaitForCellDeregistration(receptorClient, cellID)
+	return time.Since(startedAt)
+}
+
+// ExpectCellPresenceKept asserts that the cell stays registered for the
+// whole duration, e.g. while its rep is paused for less than the TTL.
+func ExpectCellPresenceKept(receptorClient receptor.Client, cellID string, duration time.Duration) {
+	Consistently(CellIDsPoller(receptorClient), duration).Should(ContainElement(cellID))
+}
@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/executor"
+	. "github.com/onsi/gomega"
+)
+
+// ResourceAccountant checks that what an executor has reserved adds up:
+// that its RemainingResources come back to its TotalResources once its
+// containers are gone.
+type ResourceAccountant struct {
+	client executor.Client
+
+	Baseline executor.ExecutorResources
+}
+
+// NewResourceAccountant snapshots the executor's total resources. Nothing
+// may be reserved yet.
+func NewResourceAccountant(client executor.Client) *ResourceAccountant {
+	total, err := client.TotalResources()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Ω(client.RemainingResources()).Should(Equal(total), "resources were already reserved before the accountant started")
+
+	return &ResourceAccountant{
+		client:   client,
+		Baseline: total,
+	}
+}
+
+// ExpectReserved asserts that exactly the given resources, and no more, are
+// reserved.
+func (accountant *ResourceAccountant) ExpectReserved(memoryMB, diskMB, containers int) {
+	Eventually(accountant.client.RemainingResources).Should(Equal(executor.ExecutorResources{
+		MemoryMB:   accountant.Baseline.MemoryMB - memoryMB,
+		DiskMB:     accountant.Baseline.DiskMB - diskMB,
+		Containers: accountant.Baseline.Containers - containers,
+	}))
+}
+
+// ExpectBaseline asserts that nothing is reserved any more.
+func (accountant *ResourceAccountant) ExpectBaseline() {
+	accountant.ExpectReserved(0, 0, 0)
+}
+
+// ExpectNoLeaks deletes every container the executor still has and asserts
+// that all of their reservations were given back. It is meant for an
+// AfterEach, so it does nothing if the spec left the executor unreachable.
+func (accountant *ResourceAccountant) ExpectNoLeaks() {
+	if accountant.client.Ping() != nil {
+		return
+	}
+
+	DeleteExecutorContainers(accountant.client)
+
+	accountant.ExpectBaseline()
+}
+
+// DeleteExecutorContainers deletes every container the executor has, e.g.
+// before stopping it in an AfterEach, so that none of them are left behind
+// in Garden.
+func DeleteExecutorContainers(client executor.Client) {
+	containers, err := client.ListContainers(nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	for _, container := range containers {
+		err := client.DeleteContainer(container.Guid)
+		if err != executor.ErrContainerNotFound {
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+	}
+}
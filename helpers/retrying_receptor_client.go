@@ -0,0 +1,182 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/onsi/ginkgo"
+)
+
+const receptorRetryInterval = 100 * time.Millisecond
+
+// retryableReceptorErrors are what the receptor client says when the
+// receptor, or something in front of it, is not quite up yet.
+var retryableReceptorErrors = []string{
+	"connection refused",
+	"connection reset by peer",
+	"EOF",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+}
+
+// RetryingReceptorClient is a receptor.Client that retries its idempotent
+// calls for up to a budget while they fail as if the receptor were still
+// starting, e.g. with connection refused, so that specs do not fail
+// spuriously in the moments after a StartCheck has passed. Everything else
+// goes straight through, once.
+type RetryingReceptorClient struct {
+	receptor.Client
+
+	budget time.Duration
+}
+
+func NewRetryingReceptorClient(client receptor.Client, budget time.Duration) *RetryingReceptorClient {
+	return &RetryingReceptorClient{
+		Client: client,
+		budget: budget,
+	}
+}
+
+func (c *RetryingReceptorClient) UpsertDomain(domain string, ttl time.Duration) error {
+	return c.retry("UpsertDomain", func() error {
+		return c.Client.UpsertDomain(domain, ttl)
+	})
+}
+
+func (c *RetryingReceptorClient) Domains() ([]string, error) {
+	var domains []string
+	err := c.retry("Domains", func() error {
+		var err error
+		domains, err = c.Client.Domains()
+		return err
+	})
+
+	return domains, err
+}
+
+func (c *RetryingReceptorClient) Tasks() ([]receptor.TaskResponse, error) {
+	var tasks []receptor.TaskResponse
+	err := c.retry("Tasks", func() error {
+		var err error
+		tasks, err = c.Client.Tasks()
+		return err
+	})
+
+	return tasks, err
+}
+
+func (c *RetryingReceptorClient) TasksByDomain(domain string) ([]receptor.TaskResponse, error) {
+	var tasks []receptor.TaskResponse
+	err := c.retry("TasksByDomain", func() error {
+		var err error
+		tasks, err = c.Client.TasksByDomain(domain)
+		return err
+	})
+
+	return tasks, err
+}
+
+func (c *RetryingReceptorClient) GetTask(taskGuid string) (receptor.TaskResponse, error) {
+	var task receptor.TaskResponse
+	err := c.retry("GetTask", func() error {
+		var err error
+		task, err = c.Client.GetTask(taskGuid)
+		return err
+	})
+
+	return task, err
+}
+
+func (c *RetryingReceptorClient) DesiredLRPs() ([]receptor.DesiredLRPResponse, error) {
+	var lrps []receptor.DesiredLRPResponse
+	err := c.retry("DesiredLRPs", func() error {
+		var err error
+		lrps, err = c.Client.DesiredLRPs()
+		return err
+	})
+
+	return lrps, err
+}
+
+func (c *RetryingReceptorClient) GetDesiredLRP(processGuid string) (receptor.DesiredLRPResponse, error) {
+	var lrp receptor.DesiredLRPResponse
+	err := c.retry("GetDesiredLRP", func() error {
+		var err error
+		lrp, err = c.Client.GetDesiredLRP(processGuid)
+		return err
+	})
+
+	return lrp, err
+}
+
+func (c *RetryingReceptorClient) ActualLRPs() ([]receptor.ActualLRPResponse, error) {
+	var lrps []receptor.ActualLRPResponse
+	err := c.retry("ActualLRPs", func() error {
+		var err error
+		lrps, err = c.Client.ActualLRPs()
+		return err
+	})
+
+	return lrps, err
+}
+
+func (c *RetryingReceptorClient) ActualLRPsByProcessGuid(processGuid string) ([]receptor.ActualLRPResponse, error) {
+	var lrps []receptor.ActualLRPResponse
+	err := c.retry("ActualLRPsByProcessGuid", func() error {
+		var err error
+		lrps, err = c.Client.ActualLRPsByProcessGuid(processGuid)
+		return err
+	})
+
+	return lrps, err
+}
+
+func (c *RetryingReceptorClient) ActualLRPByProcessGuidAndIndex(processGuid string, index int) (receptor.ActualLRPResponse, error) {
+	var lrp receptor.ActualLRPResponse
+	err := c.retry("ActualLRPByProcessGuidAndIndex", func() error {
+		var err error
+		lrp, err = c.Client.ActualLRPByProcessGuidAndIndex(processGuid, index)
+		return err
+	})
+
+	return lrp, err
+}
+
+func (c *RetryingReceptorClient) Cells() ([]receptor.CellResponse, error) {
+	var cells []receptor.CellResponse
+	err := c.retry("Cells", func() error {
+		var err error
+		cells, err = c.Client.Cells()
+		return err
+	})
+
+	return cells, err
+}
+
+// retry calls call until it succeeds, fails for some other reason than the
+// receptor not being up, or the budget runs out, and returns its last error.
+func (c *RetryingReceptorClient) retry(name string, call func() error) error {
+	deadline := time.Now().Add(c.budget)
+
+	for {
+		err := call()
+		if err == nil || !retryableReceptorError(err) || time.Now().After(deadline) {
+			return err
+		}
+
+		fmt.Fprintf(ginkgo.GinkgoWriter, "[RECEPTOR CLIENT] Retrying %s: %s\n", name, err)
+		time.Sleep(receptorRetryInterval)
+	}
+}
+
+func retryableReceptorError(err error) bool {
+	for _, retryable := range retryableReceptorErrors {
+		if strings.Contains(err.Error(), retryable) {
+			return true
+		}
+	}
+
+	return false
+}
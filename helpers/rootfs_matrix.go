@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+const RootFSMatrixTag = "[rootfs-matrix]"
+
+// DescribeOnEachRootFS runs body once per rootfs in $GARDEN_ROOTFS_MATRIX,
+// each against its own garden started from that rootfs, or just once against
+// the suite's garden if no matrix is given. The specs are tagged with
+// RootFSMatrixTag so they can be focused on.
+//
+// The maker passed to body is only valid inside its setup and specs; build
+// executors from it so that they use the right garden.
+func DescribeOnEachRootFS(text string, suiteMaker *world.ComponentMaker, body func(maker *world.ComponentMaker)) bool {
+	return Describe(RootFSMatrixTag+" "+text, func() {
+		rootfses := world.RootFSMatrix()
+		if len(rootfses) == 0 {
+			body(suiteMaker)
+			return
+		}
+
+		for i, rootfs := range rootfses {
+			index := i + 1
+			rootfs := rootfs
+
+			Context(fmt.Sprintf("on the %s rootfs", rootfs.Name), func() {
+				maker := new(world.ComponentMaker)
+
+				var garden ifrit.Process
+
+				BeforeEach(func() {
+					*maker = suiteMaker.WithRootFS(index, rootfs)
+
+					garden = ginkgomon.Invoke(maker.GardenLinux(
+						"-denyNetworks=0.0.0.0/0",
+						"-allowHostAccess=true",
+					))
+				})
+
+				body(maker)
+
+				// registered after body's so that everything using this garden is
+				// stopped before it is
+				AfterEach(func() {
+					destroyContainerErrors := CleanupGarden(maker.GardenClient())
+
+					StopProcesses(garden)
+
+					Ω(destroyContainerErrors).Should(BeEmpty(), "containers failed to be destroyed on the %s rootfs", rootfs.Name)
+				})
+			})
+		}
+	})
+}
@@ -31,6 +31,30 @@ func ResponseCodeFromHostPoller(routerAddr string, host string) func() (int, err
 	}
 }
 
+// ContainerPortFromHostPoller reports which container port served a request
+// for the host, as announced by fixtures.HelloWorldIndexLRP.
+func ContainerPortFromHostPoller(routerAddr string, host string) func() (string, error) {
+	return func() (string, error) {
+		request := &http.Request{
+			URL: &url.URL{
+				Scheme: "http",
+				Host:   routerAddr,
+				Path:   "/",
+			},
+
+			Host: host,
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		return response.Header.Get("X-Container-Port"), nil
+	}
+}
+
 func ResponseBodyAndStatusCodeFromHost(routerAddr string, host string) ([]byte, int, error) {
 	request := &http.Request{
 		URL: &url.URL{
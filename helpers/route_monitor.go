@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"sync"
+	"time"
+)
+
+const routeMonitorInterval = 100 * time.Millisecond
+
+type RouteSample struct {
+	At         time.Time
+	StatusCode int
+	Err        error
+}
+
+// RouteMonitor requests a route through the router continuously in the
+// background, recording every response, so that a spec can assert on how
+// the route behaved while something else was going on.
+type RouteMonitor struct {
+	samples []RouteSample
+	lock    *sync.Mutex
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func MonitorRoute(routerAddr string, host string) *RouteMonitor {
+	monitor := &RouteMonitor{
+		lock:    new(sync.Mutex),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	poll := ResponseCodeFromHostPoller(routerAddr, host)
+
+	go func() {
+		defer close(monitor.stopped)
+
+		ticker := time.NewTicker(routeMonitorInterval)
+		defer ticker.Stop()
+
+		for {
+			statusCode, err := poll()
+
+			monitor.lock.Lock()
+			monitor.samples = append(monitor.samples, RouteSample{
+				At:         time.Now(),
+				StatusCode: statusCode,
+				Err:        err,
+			})
+			monitor.lock.Unlock()
+
+			select {
+			case <-ticker.C:
+			case <-monitor.stop:
+				return
+			}
+		}
+	}()
+
+	return monitor
+}
+
+// Samples returns every response seen so far, oldest first.
+func (monitor *RouteMonitor) Samples() []RouteSample {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	return append([]RouteSample{}, monitor.samples...)
+}
+
+func (monitor *RouteMonitor) Stop() {
+	close(monitor.stop)
+	<-monitor.stopped
+}
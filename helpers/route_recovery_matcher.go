@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// DropOutThenRecoverWithin succeeds if the route was routable, then 404ed,
+// then was routable again no more than window after it first 404ed. Match
+// it against RouteMonitor.Samples:
+//
+//	Eventually(monitor.Samples).Should(DropOutThenRecoverWithin(30 * time.Second))
+func DropOutThenRecoverWithin(window time.Duration) types.GomegaMatcher {
+	return &dropOutThenRecoverMatcher{window: window}
+}
+
+type dropOutThenRecoverMatcher struct {
+	window time.Duration
+
+	explanation string
+}
+
+func (matcher *dropOutThenRecoverMatcher) Match(actual interface{}) (bool, error) {
+	samples, ok := actual.([]RouteSample)
+	if !ok {
+		return false, fmt.Errorf("DropOutThenRecoverWithin matcher expects a []RouteSample; got:\n%s", format.Object(actual, 1))
+	}
+
+	routable := func(sample RouteSample) bool {
+		return sample.Err == nil && sample.StatusCode == http.StatusOK
+	}
+
+	notFound := func(sample RouteSample) bool {
+		return sample.Err == nil && sample.StatusCode == http.StatusNotFound
+	}
+
+	firstUp := firstSample(samples, 0, routable)
+	if firstUp == -1 {
+		matcher.explanation = "the route was never routable"
+		return false, nil
+	}
+
+	droppedOut := firstSample(samples, firstUp, notFound)
+	if droppedOut == -1 {
+		matcher.explanation = "the route never dropped out"
+		return false, nil
+	}
+
+	recovered := firstSample(samples, droppedOut, routable)
+	if recovered == -1 {
+		matcher.explanation = fmt.Sprintf("the route has not recovered since dropping out at %s", samples[droppedOut].At.Format(time.StampMilli))
+		return false, nil
+	}
+
+	downFor := samples[recovered].At.Sub(samples[droppedOut].At)
+	if downFor > matcher.window {
+		matcher.explanation = fmt.Sprintf("the route took %s to recover", downFor)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (matcher *dropOutThenRecoverMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the route to drop out and recover within %s, but %s", matcher.window, matcher.explanation)
+}
+
+func (matcher *dropOutThenRecoverMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the route not to drop out and recover within %s, but it did", matcher.window)
+}
+
+func firstSample(samples []RouteSample, from int, predicate func(RouteSample) bool) int {
+	for i := from; i < len(samples); i++ {
+		if predicate(samples[i]) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/apcera/nats"
+	"github.com/cloudfoundry/gunk/diegonats"
+	. "github.com/onsi/gomega"
+)
+
+type RegistryMessage struct {
+	Host              string   `json:"host"`
+	Port              uint16   `json:"port"`
+	URIs              []string `json:"uris"`
+	App               string   `json:"app,omitempty"`
+	PrivateInstanceId string   `json:"private_instance_id,omitempty"`
+}
+
+func (message RegistryMessage) Endpoint() string {
+	return fmt.Sprintf("%s:%d", message.Host, message.Port)
+}
+
+type RouteRegistrationCollector struct {
+	subscriptions []*nats.Subscription
+
+	events []routeEvent
+	lock   *sync.RWMutex
+}
+
+type routeEvent struct {
+	registered bool
+	message    RegistryMessage
+}
+
+// RouteRegistrations subscribes to router.register and router.unregister and
+// records every message seen until Stop is called.
+func RouteRegistrations(natsClient diegonats.NATSClient) *RouteRegistrationCollector {
+	collector := &RouteRegistrationCollector{
+		lock: new(sync.RWMutex),
+	}
+
+	collector.subscribe(natsClient, "router.register", true)
+	collector.subscribe(natsClient, "router.unregister", false)
+
+	return collector
+}
+
+func (collector *RouteRegistrationCollector) subscribe(natsClient diegonats.NATSClient, subject string, registered bool) {
+	subscription, err := natsClient.Subscribe(subject, func(msg *nats.Msg) {
+		var message RegistryMessage
+		err := json.Unmarshal(msg.Data, &message)
+		if err != nil {
+			return
+		}
+
+		collector.lock.Lock()
+		collector.events = append(collector.events, routeEvent{registered: registered, message: message})
+		collector.lock.Unlock()
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	collector.subscriptions = append(collector.subscriptions, subscription)
+}
+
+func (collector *RouteRegistrationCollector) Stop() {
+	for _, subscription := range collector.subscriptions {
+		subscription.Unsubscribe()
+	}
+}
+
+func (collector *RouteRegistrationCollector) Registrations() []RegistryMessage {
+	return collector.messages(true)
+}
+
+func (collector *RouteRegistrationCollector) Unregistrations() []RegistryMessage {
+	return collector.messages(false)
+}
+
+func (collector *RouteRegistrationCollector) messages(registered bool) []RegistryMessage {
+	collector.lock.RLock()
+	defer collector.lock.RUnlock()
+
+	messages := []RegistryMessage{}
+	for _, event := range collector.events {
+		if event.registered == registered {
+			messages = append(messages, event.message)
+		}
+	}
+
+	return messages
+}
+
+// RegisteredRoutes replays registrations and unregistrations in the order
+// they were received, returning the endpoints currently mapped to each uri.
+func (collector *RouteRegistrationCollector) RegisteredRoutes() map[string][]string {
+	collector.lock.RLock()
+	defer collector.lock.RUnlock()
+
+	endpoints := map[string]map[string]bool{}
+	for _, event := range collector.events {
+		for _, uri := range event.message.URIs {
+			if endpoints[uri] == nil {
+				endpoints[uri] = map[string]bool{}
+			}
+
+			if event.registered {
+				endpoints[uri][event.message.Endpoint()] = true
+			} else {
+				delete(endpoints[uri], event.message.Endpoint())
+			}
+		}
+	}
+
+	routes := map[string][]string{}
+	for uri, uriEndpoints := range endpoints {
+		for endpoint := range uriEndpoints {
+			routes[uri] = append(routes[uri], endpoint)
+		}
+
+		sort.Strings(routes[uri])
+	}
+
+	return routes
+}
+
+func (collector *RouteRegistrationCollector) RegisteredRoutesPoller() func() map[string][]string {
+	return collector.RegisteredRoutes
+}
+
+func (collector *RouteRegistrationCollector) EndpointsForRoutePoller(uri string) func() []string {
+	return func() []string {
+		return collector.RegisteredRoutes()[uri]
+	}
+}
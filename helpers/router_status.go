@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	. "github.com/onsi/gomega"
+)
+
+// RouterRoutes returns the router's route table as seen through its status
+// endpoint, mapping each uri to its sorted backend addresses.
+func RouterRoutes(routerStatusAddr string) map[string][]string {
+	var table map[string][]interface{}
+	getRouterStatus(routerStatusAddr, "/routes", &table)
+
+	routes := map[string][]string{}
+	for uri, backends := range table {
+		for _, backend := range backends {
+			switch b := backend.(type) {
+			case string:
+				routes[uri] = append(routes[uri], b)
+			case map[string]interface{}:
+				routes[uri] = append(routes[uri], fmt.Sprintf("%v", b["address"]))
+			}
+		}
+
+		sort.Strings(routes[uri])
+	}
+
+	return routes
+}
+
+func RouterRoutesPoller(routerStatusAddr string) func() map[string][]string {
+	return func() map[string][]string {
+		return RouterRoutes(routerStatusAddr)
+	}
+}
+
+func RouterVarz(routerStatusAddr string) map[string]interface{} {
+	var varz map[string]interface{}
+	getRouterStatus(routerStatusAddr, "/varz", &varz)
+
+	return varz
+}
+
+func RouterHealthzPoller(routerStatusAddr string) func() (int, error) {
+	return func() (int, error) {
+		response, err := http.Get(routerStatusURL(routerStatusAddr, "/healthz"))
+		if err != nil {
+			return 0, err
+		}
+		defer response.Body.Close()
+
+		return response.StatusCode, nil
+	}
+}
+
+func getRouterStatus(routerStatusAddr string, path string, result interface{}) {
+	response, err := http.Get(routerStatusURL(routerStatusAddr, path))
+	Ω(err).ShouldNot(HaveOccurred())
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	Ω(response.StatusCode).Should(Equal(http.StatusOK), "router %s responded with: %s", path, body)
+
+	err = json.Unmarshal(body, result)
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+func routerStatusURL(routerStatusAddr string, path string) string {
+	return fmt.Sprintf("http://%s:%s@%s%s", world.RouterStatusUsername, world.RouterStatusPassword, routerStatusAddr, path)
+}
@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+)
+
+// ComponentLinesMentioning returns the lines of output mentioning id, e.g. a
+// process or instance guid, of every component run during the current spec,
+// by component name. Every line is tagged with the spec's id, so given that
+// it returns all of them.
+func ComponentLinesMentioning(maker world.ComponentMaker, id string) map[string][]string {
+	mentions := map[string][]string{}
+
+	for _, runner := range maker.Timings.SpecComponents() {
+		buffer := runner.Output()
+		if buffer == nil {
+			// not started yet
+			continue
+		}
+
+		for _, line := range strings.Split(string(buffer.Contents()), "\n") {
+			if strings.Contains(line, id) {
+				mentions[runner.Name] = append(mentions[runner.Name], line)
+			}
+		}
+	}
+
+	return mentions
+}
@@ -0,0 +1,144 @@
+package helpers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega/types"
+)
+
+// how many of the states a poller went through PollUntilStableState shows
+// when it fails, most recent last
+const maxReportedStates = 20
+
+// PollUntilStableState polls, every Timeouts.EventuallyPollingInterval,
+// until the poller's state has matched for stablePolls polls in a row, and
+// fails if that has not happened within the timeout (Timeouts.Long by
+// default). It is Eventually and Consistently fused, for states that can
+// flap: an Eventually alone passes on a state that is gone again by the next
+// poll, and a Consistently started after it is blind to flapping in between.
+//
+// The poller is a func taking no arguments and returning the state, and
+// optionally an error as its second value, as for Eventually; a poll that
+// returns an error does not match. On failure, the sequence of states seen
+// is reported, e.g. `"RUNNING" x3, "CRASHED" x1, "RUNNING" x2`.
+func PollUntilStableState(poller interface{}, matcher types.GomegaMatcher, stablePolls int, timeout ...time.Duration) {
+	pollerValue := reflect.ValueOf(poller)
+	if pollerValue.Kind() != reflect.Func || pollerValue.Type().NumIn() != 0 || pollerValue.Type().NumOut() == 0 || pollerValue.Type().NumOut() > 2 {
+		ginkgo.Fail(fmt.Sprintf("PollUntilStableState needs a func() T or func() (T, error), not %T", poller), 1)
+	}
+
+	deadline := time.Now().Add(Timeouts.Long)
+	if len(timeout) > 0 {
+		deadline = time.Now().Add(timeout[0])
+	}
+
+	history := &stateHistory{}
+	matched := 0
+
+	for {
+		state := pollState(pollerValue)
+		history.record(state)
+
+		if state.err == nil {
+			success, err := matcher.Match(state.value)
+			if err != nil {
+				ginkgo.Fail(fmt.Sprintf("PollUntilStableState could not match %#v: %s", state.value, err), 1)
+			}
+
+			if success {
+				matched++
+			} else {
+				matched = 0
+			}
+		} else {
+			matched = 0
+		}
+
+		if matched >= stablePolls {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			message := fmt.Sprintf(
+				"state never matched for %d polls in a row; it went through:\n\t%s",
+				stablePolls, history,
+			)
+
+			if state.err == nil {
+				message += "\nand, last of all:\n" + matcher.FailureMessage(state.value)
+			}
+
+			ginkgo.Fail(message, 1)
+		}
+
+		time.Sleep(Timeouts.EventuallyPollingInterval)
+	}
+}
+
+type polledState struct {
+	value interface{}
+	err   error
+}
+
+func pollState(poller reflect.Value) polledState {
+	values := poller.Call(nil)
+
+	state := polledState{value: values[0].Interface()}
+	if len(values) == 2 && !values[1].IsNil() {
+		state.err = values[1].Interface().(error)
+	}
+
+	return state
+}
+
+func (state polledState) String() string {
+	if state.err != nil {
+		return "error: " + state.err.Error()
+	}
+
+	return fmt.Sprintf("%#v", state.value)
+}
+
+// stateHistory keeps the states polled, with runs of the same one counted
+// rather than repeated.
+type stateHistory struct {
+	states  []string
+	repeats []int
+	dropped int
+}
+
+func (history *stateHistory) record(state polledState) {
+	description := state.String()
+
+	last := len(history.states) - 1
+	if last >= 0 && history.states[last] == description {
+		history.repeats[last]++
+		return
+	}
+
+	history.states = append(history.states, description)
+	history.repeats = append(history.repeats, 1)
+
+	if len(history.states) > maxReportedStates {
+		history.states = history.states[1:]
+		history.repeats = history.repeats[1:]
+		history.dropped++
+	}
+}
+
+func (history *stateHistory) String() string {
+	runs := []string{}
+	if history.dropped > 0 {
+		runs = append(runs, fmt.Sprintf("(%d earlier states)", history.dropped))
+	}
+
+	for i, state := range history.states {
+		runs = append(runs, fmt.Sprintf("%s x%d", state, history.repeats[i]))
+	}
+
+	return strings.Join(runs, ", ")
+}
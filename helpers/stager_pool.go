@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry/gunk/urljoiner"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// StagerPool runs several stagers and spreads staging requests across them
+// the way CC's load balancing would.
+type StagerPool struct {
+	maker world.ComponentMaker
+
+	runners   []*world.TimedRunner
+	processes []ifrit.Process
+	killed    []bool
+
+	next int
+}
+
+func StartStagerPool(maker world.ComponentMaker, size int, argv ...string) *StagerPool {
+	pool := &StagerPool{maker: maker}
+
+	for i := 0; i < size; i++ {
+		runner := maker.StagerN(i, argv...)
+
+		pool.runners = append(pool.runners, runner)
+		pool.processes = append(pool.processes, ginkgomon.Invoke(runner))
+		pool.killed = append(pool.killed, false)
+	}
+
+	return pool
+}
+
+func (pool *StagerPool) Stop() {
+	live := []ifrit.Process{}
+	for i, process := range pool.processes {
+		if !pool.killed[i] {
+			live = append(live, process)
+		}
+	}
+
+	StopProcesses(live...)
+}
+
+// Kill ungracefully stops the stager at the given index; Stage skips it from
+// then on.
+func (pool *StagerPool) Kill(index int) {
+	pool.killed[index] = true
+
+	pool.processes[index].Signal(os.Kill)
+	Eventually(pool.processes[index].Wait()).Should(Receive())
+}
+
+// Stage sends the staging request to the next stager in turn, moving on to
+// the one after if a stager cannot be reached, and returns the index of the
+// stager that accepted it.
+func (pool *StagerPool) Stage(stagingGuid string, payload string) int {
+	for attempt := 0; attempt < len(pool.runners); attempt++ {
+		index := pool.next
+		pool.next = (pool.next + 1) % len(pool.runners)
+
+		if pool.killed[index] {
+			continue
+		}
+
+		stageURL := urljoiner.Join("http://"+pool.maker.StagerAddressN(index), "v1", "staging", stagingGuid)
+		request, err := http.NewRequest("PUT", stageURL, strings.NewReader(payload))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			continue
+		}
+		response.Body.Close()
+
+		Ω(response.StatusCode).Should(Equal(http.StatusAccepted))
+
+		return index
+	}
+
+	Ω(false).Should(BeTrue(), "no stager accepted the staging request for %s", stagingGuid)
+	return -1
+}
+
+// HandledBy scrapes the stagers' logs for the staging guid, returning the
+// indices of every stager that mentioned it.
+func (pool *StagerPool) HandledBy(stagingGuid string) []int {
+	indices := []int{}
+	for i, runner := range pool.runners {
+		if strings.Contains(string(runner.Buffer().Contents()), stagingGuid) {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+func (pool *StagerPool) HandledByPoller(stagingGuid string) func() []int {
+	return func() []int {
+		return pool.HandledBy(stagingGuid)
+	}
+}
@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry/gunk/urljoiner"
+	. "github.com/onsi/gomega"
+)
+
+// WaitForStagingToStart waits for the stager's task for the staging guid to
+// be running, e.g. so that it can be stopped mid-compile.
+func WaitForStagingToStart(receptorClient receptor.Client, stagingGuid string) {
+	Eventually(func() string {
+		task, err := receptorClient.GetTask(stagingGuid)
+		if err != nil {
+			return ""
+		}
+
+		return task.State
+	}, Timeouts.Long).Should(Equal(receptor.TaskStateRunning))
+}
+
+// StopStaging asks the stager to stop staging, the way CC does when an app
+// is stopped or deleted mid-staging.
+func StopStaging(stagerAddress string, stagingGuid string) {
+	request, err := http.NewRequest("DELETE", urljoiner.Join("http://"+stagerAddress, "v1", "staging", stagingGuid), nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	response, err := http.DefaultClient.Do(request)
+	Ω(err).ShouldNot(HaveOccurred())
+	response.Body.Close()
+
+	Ω(response.StatusCode).Should(Equal(http.StatusAccepted))
+}
+
+// TaskCancelledReason is the failure reason of a task that was cancelled.
+const TaskCancelledReason = "task was cancelled"
+
+// ExpectStagingCancelled asserts that the staging task was cancelled, that
+// its container is gone, and that CC was told staging failed because of it.
+func ExpectStagingCancelled(receptorClient receptor.Client, gardenClient garden.Client, fakeCC *fake_cc.FakeCC, stagingGuid string) {
+	var task receptor.TaskResponse
+	var deleted bool
+	Eventually(func() bool {
+		var err error
+		task, err = receptorClient.GetTask(stagingGuid)
+		if err != nil {
+			// already resolved by the stager, and deleted; the callback below
+			// still says why it finished
+			deleted = true
+			return true
+		}
+
+		return task.State == receptor.TaskStateCompleted
+	}).Should(BeTrue(), "staging task %s never completed", stagingGuid)
+
+	if !deleted {
+		Ω(task.Failed).Should(BeTrue(), "staging task %s succeeded", stagingGuid)
+		Ω(task.FailureReason).Should(Equal(TaskCancelledReason))
+	}
+
+	Eventually(func() error {
+		_, err := gardenClient.Lookup(stagingGuid)
+		return err
+	}, Timeouts.Short).Should(HaveOccurred(), "staging container %s was not destroyed", stagingGuid)
+
+	Eventually(fakeCC.StagingCallbacks).Should(HaveLen(1))
+
+	callback := fakeCC.StagingCallbacks()[0]
+	Ω(callback.StagingGuid).Should(Equal(stagingGuid))
+	Ω(callback.Response.Error).ShouldNot(BeNil(), "CC was told staging %s succeeded", stagingGuid)
+	Ω(callback.Response.Error.Message).Should(ContainSubstring(TaskCancelledReason))
+}
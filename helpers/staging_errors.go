@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	. "github.com/onsi/gomega"
+)
+
+// StagingFailedError is what CC is told whenever staging itself fails,
+// whether the buildpack detected nothing, failed to compile, or ran past
+// the staging timeout; the stager does not tell these apart.
+var StagingFailedError = cc_messages.StagingError{
+	Id:      cc_messages.STAGING_ERROR,
+	Message: "staging failed",
+}
+
+// ExpectStagingError waits up to timeout for CC to be told about the
+// staging, and asserts that it was told exactly the given error and
+// nothing else. It returns the callback, e.g. to check when it came.
+func ExpectStagingError(fakeCC *fake_cc.FakeCC, stagingGuid string, expected cc_messages.StagingError, timeout ...interface{}) fake_cc.StagingCallback {
+	var callback fake_cc.StagingCallback
+	Eventually(func() bool {
+		for _, candidate := range fakeCC.StagingCallbacks() {
+			if candidate.StagingGuid == stagingGuid {
+				callback = candidate
+				return true
+			}
+		}
+
+		return false
+	}, timeout...).Should(BeTrue(), "CC was never told about staging %s", stagingGuid)
+
+	Ω(callback.Response).Should(Equal(cc_messages.StagingResponseForCC{Error: &expected}), "CC was told something else about staging %s: %s", stagingGuid, callback.Body)
+
+	return callback
+}
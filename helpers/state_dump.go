@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/onsi/ginkgo"
+)
+
+type ReceptorState struct {
+	DumpedAt time.Time `json:"dumped_at"`
+	Spec     string    `json:"spec"`
+
+	DesiredLRPs []receptor.DesiredLRPResponse `json:"desired_lrps"`
+	ActualLRPs  []receptor.ActualLRPResponse  `json:"actual_lrps"`
+	Tasks       []receptor.TaskResponse       `json:"tasks"`
+	Cells       []receptor.CellResponse       `json:"cells"`
+
+	Errors []string `json:"errors,omitempty"`
+}
+
+var unsafeFilenameCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// DumpReceptorStateOnFailure snapshots everything the receptor knows about
+// into $ARTIFACTS_DIR (or the temp dir) if the current spec failed.
+//
+// It never fails the spec itself; whatever could not be fetched is recorded
+// in the dump instead.
+func DumpReceptorStateOnFailure(receptorClient receptor.Client) {
+	description := ginkgo.CurrentGinkgoTestDescription()
+	if !description.Failed {
+		return
+	}
+
+	state := ReceptorState{
+		DumpedAt: time.Now(),
+		Spec:     description.FullTestText,
+	}
+
+	var err error
+
+	state.DesiredLRPs, err = receptorClient.DesiredLRPs()
+	state.recordError("desired LRPs", err)
+
+	state.ActualLRPs, err = receptorClient.ActualLRPs()
+	state.recordError("actual LRPs", err)
+
+	state.Tasks, err = receptorClient.Tasks()
+	state.recordError("tasks", err)
+
+	state.Cells, err = receptorClient.Cells()
+	state.recordError("cells", err)
+
+	artifactsDir := os.Getenv("ARTIFACTS_DIR")
+	if artifactsDir == "" {
+		artifactsDir = os.TempDir()
+	}
+
+	filename := fmt.Sprintf(
+		"receptor-state-node-%d-%d-%s.json",
+		ginkgo.GinkgoParallelNode(),
+		state.DumpedAt.Unix(),
+		unsafeFilenameCharacters.ReplaceAllString(description.FullTestText, "_"),
+	)
+
+	if len(filename) > 200 {
+		filename = filename[:195] + ".json"
+	}
+
+	path := filepath.Join(artifactsDir, filename)
+
+	payload, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(path, payload, 0644)
+	}
+
+	if err != nil {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "failed to dump receptor state: %s\n", err)
+		return
+	}
+
+	fmt.Fprintf(ginkgo.GinkgoWriter, "dumped receptor state to %s\n", path)
+}
+
+func (state *ReceptorState) recordError(what string, err error) {
+	if err != nil {
+		state.Errors = append(state.Errors, fmt.Sprintf("fetching %s: %s", what, err))
+	}
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+
+	. "github.com/onsi/gomega"
+)
+
+// the cookie the router sets to pin a session to an instance, whenever the
+// instance sets a JSESSIONID
+const vcapIDCookie = "__VCAP_ID__"
+
+// StickySessionIndices makes requests to the fixtures.HelloWorldIndexLRP
+// routed to host, run with $STICKY_SESSIONS set, all in one session: every
+// request after the first presents the JSESSIONID and __VCAP_ID__ cookies
+// handed out so far. It returns the index that answered each request.
+func StickySessionIndices(routerAddr string, host string, requests int) []string {
+	jar, err := cookiejar.New(nil)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	client := &http.Client{Jar: jar}
+
+	routerURL := &url.URL{Scheme: "http", Host: routerAddr, Path: "/"}
+
+	indices := []string{}
+	for i := 0; i < requests; i++ {
+		request := &http.Request{
+			Method: "GET",
+			URL:    routerURL,
+			Header: http.Header{},
+			Host:   host,
+		}
+
+		response, err := client.Do(request)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(response.StatusCode).Should(Equal(http.StatusOK), "request %d of the session failed: %s", i, body)
+
+		if i == 0 {
+			Ω(sessionCookieNames(jar, routerURL)).Should(ContainElement(vcapIDCookie), "the router did not pin the session to an instance")
+		}
+
+		indices = append(indices, string(body))
+	}
+
+	return indices
+}
+
+// ExpectStickySession asserts that every request of a session lands on the
+// same instance, and returns its index; see StickySessionIndices.
+func ExpectStickySession(routerAddr string, host string, requests int) string {
+	indices := StickySessionIndices(routerAddr, host, requests)
+
+	for i, index := range indices {
+		Ω(index).Should(Equal(indices[0]), "request %d of the session left instance %s for %s", i, indices[0], index)
+	}
+
+	return indices[0]
+}
+
+func sessionCookieNames(jar http.CookieJar, u *url.URL) []string {
+	names := []string{}
+	for _, cookie := range jar.Cookies(u) {
+		names = append(names, cookie.Name)
+	}
+
+	return names
+}
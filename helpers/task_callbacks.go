@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// TaskCallback is a completion callback a TaskCallbackServer received.
+type TaskCallback struct {
+	Task       receptor.TaskResponse
+	ReceivedAt time.Time
+
+	// the status code the server responded with
+	RespondedWith int
+}
+
+// TaskCallbackServer is a Task's CompletionCallbackURL: it records every
+// TaskResponse POSTed to it, and can be told to fail some of them first.
+type TaskCallbackServer struct {
+	server *httptest.Server
+	url    string
+
+	callbacks     []TaskCallback
+	failuresLeft  int
+	failureStatus int
+
+	lock *sync.Mutex
+}
+
+// NewTaskCallbackServer listens on listenHost, which must be reachable from
+// the receptor. Close it when done.
+func NewTaskCallbackServer(listenHost string) *TaskCallbackServer {
+	callbackServer := &TaskCallbackServer{
+		lock: new(sync.Mutex),
+	}
+
+	var addr string
+	callbackServer.server, addr = Callback(listenHost, callbackServer.handle)
+	callbackServer.url = "http://" + addr + "/task-completed"
+
+	return callbackServer
+}
+
+// URL is what to set the Task's CompletionCallbackURL to.
+func (callbackServer *TaskCallbackServer) URL() string {
+	return callbackServer.url
+}
+
+// FailNext has the server respond to the next count callbacks with status,
+// e.g. http.StatusServiceUnavailable, before accepting any.
+func (callbackServer *TaskCallbackServer) FailNext(count int, status int) {
+	callbackServer.lock.Lock()
+	defer callbackServer.lock.Unlock()
+
+	callbackServer.failuresLeft = count
+	callbackServer.failureStatus = status
+}
+
+func (callbackServer *TaskCallbackServer) Callbacks() []TaskCallback {
+	callbackServer.lock.Lock()
+	defer callbackServer.lock.Unlock()
+
+	return append([]TaskCallback{}, callbackServer.callbacks...)
+}
+
+// CallbacksFor are the callbacks received for the Task, in order.
+func (callbackServer *TaskCallbackServer) CallbacksFor(taskGuid string) []TaskCallback {
+	callbacks := []TaskCallback{}
+	for _, callback := range callbackServer.Callbacks() {
+		if callback.Task.TaskGuid == taskGuid {
+			callbacks = append(callbacks, callback)
+		}
+	}
+
+	return callbacks
+}
+
+// CallbacksForPoller is how many callbacks were received for the Task, for
+// Eventually and Consistently.
+func (callbackServer *TaskCallbackServer) CallbacksForPoller(taskGuid string) func() int {
+	return func() int {
+		return len(callbackServer.CallbacksFor(taskGuid))
+	}
+}
+
+func (callbackServer *TaskCallbackServer) Close() {
+	callbackServer.server.Close()
+}
+
+func (callbackServer *TaskCallbackServer) handle(w http.ResponseWriter, r *http.Request) {
+	Ω(r.Method).Should(Equal("POST"))
+
+	body, err := ioutil.ReadAll(r.Body)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	var task receptor.TaskResponse
+	err = json.Unmarshal(body, &task)
+	Ω(err).ShouldNot(HaveOccurred(), "malformed completion callback: %s", body)
+
+	callbackServer.lock.Lock()
+	defer callbackServer.lock.Unlock()
+
+	status := http.StatusOK
+	if callbackServer.failuresLeft > 0 {
+		callbackServer.failuresLeft--
+		status = callbackServer.failureStatus
+	}
+
+	callbackServer.callbacks = append(callbackServer.callbacks, TaskCallback{
+		Task:          task,
+		ReceivedAt:    time.Now(),
+		RespondedWith: status,
+	})
+
+	w.WriteHeader(status)
+}
@@ -0,0 +1,157 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// CellFailure is how a cell goes away while it is running a task.
+type CellFailure int
+
+const (
+	// the whole machine dies; its containers are left behind
+	CellCrash CellFailure = iota
+
+	// only the rep dies; the executor finishes the task, but nobody is left
+	// to report the result
+	RepCrash
+
+	// the rep is told to evacuate, and exits once it has
+	CellEvacuation
+)
+
+func (failure CellFailure) String() string {
+	switch failure {
+	case CellCrash:
+		return "cell crash"
+	case RepCrash:
+		return "rep crash"
+	case CellEvacuation:
+		return "cell evacuation"
+	default:
+		return fmt.Sprintf("CellFailure(%d)", int(failure))
+	}
+}
+
+// TaskCell is an executor and rep registered under their own cell ID, run as
+// separate processes so that either can be failed on its own.
+type TaskCell struct {
+	ID string
+
+	RepAddr string
+
+	Executor ifrit.Process
+	Rep      ifrit.Process
+}
+
+// StartTaskCell starts the index'th of a set of cells, and waits for it to
+// register.
+func StartTaskCell(maker world.ComponentMaker, receptorClient receptor.Client, index int) *TaskCell {
+	cellMaker := maker.Cell(fmt.Sprintf("task-cell-%d", index), index)
+
+	cell := &TaskCell{
+		ID:      cellMaker.CellID(),
+		RepAddr: cellMaker.Addresses.Rep,
+
+		Executor: ginkgomon.Invoke(cellMaker.Executor("-memoryMB", "1024")),
+	}
+
+	cell.Rep = ginkgomon.Invoke(cellMaker.Rep("-evacuationTimeout", "30s"))
+
+	WaitForCellRegistration(receptorClient, cell.ID)
+
+	return cell
+}
+
+func (cell *TaskCell) Stop() {
+	StopProcesses(cell.Rep, cell.Executor)
+}
+
+// Fail takes the cell out the given way, and returns when it did so.
+func (cell *TaskCell) Fail(failure CellFailure) time.Time {
+	failedAt := time.Now()
+
+	switch failure {
+	case CellCrash:
+		killProcess(cell.Rep)
+		killProcess(cell.Executor)
+	case RepCrash:
+		killProcess(cell.Rep)
+	case CellEvacuation:
+		resp, err := http.Post(fmt.Sprintf("http://%s/evacuate", cell.RepAddr), "text/html", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		resp.Body.Close()
+		Ω(resp.StatusCode).Should(Equal(http.StatusAccepted))
+	default:
+		ginkgo.Fail(fmt.Sprintf("unknown cell failure %s", failure))
+	}
+
+	return failedAt
+}
+
+// WaitForTaskToRunOnCell waits for the task to be running, and returns the
+// ID of the cell it is running on.
+func WaitForTaskToRunOnCell(receptorClient receptor.Client, taskGuid string) string {
+	return EventuallyTask(receptorClient, taskGuid, receptor.TaskStateRunning).CellID
+}
+
+// TaskFailurePolicy is what should become of a task whose cell went away.
+type TaskFailurePolicy int
+
+const (
+	// the converger fails the task
+	TaskFailsWithCell TaskFailurePolicy = iota
+
+	// the task is run again, on another cell
+	TaskResubmitted
+
+	// the task is left to finish where it was, and succeeds
+	TaskCompletesInPlace
+)
+
+// ExpectTaskOutcome waits for the task that was running on fromCellID to
+// meet the given policy, and returns it.
+func ExpectTaskOutcome(receptorClient receptor.Client, taskGuid string, fromCellID string, policy TaskFailurePolicy) receptor.TaskResponse {
+	switch policy {
+	case TaskFailsWithCell:
+		task := EventuallyTask(receptorClient, taskGuid, receptor.TaskStateCompleted)
+		Ω(task.Failed).Should(BeTrue(), "task %s survived the failure of %s", taskGuid, fromCellID)
+		Ω(task.CellID).Should(Equal(fromCellID))
+		return task
+
+	case TaskResubmitted:
+		var task receptor.TaskResponse
+		Eventually(func() bool {
+			var err error
+			task, err = receptorClient.GetTask(taskGuid)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			return task.State == receptor.TaskStateRunning && task.CellID != fromCellID
+		}, Timeouts.Long).Should(BeTrue(), "task %s was never resubmitted", taskGuid)
+		return task
+
+	case TaskCompletesInPlace:
+		task := EventuallyTask(receptorClient, taskGuid, receptor.TaskStateCompleted)
+		Ω(task.Failed).Should(BeFalse(), task.FailureReason)
+		Ω(task.CellID).Should(Equal(fromCellID))
+		return task
+
+	default:
+		ginkgo.Fail(fmt.Sprintf("unknown task failure policy %d", policy))
+		return receptor.TaskResponse{}
+	}
+}
+
+func killProcess(process ifrit.Process) {
+	process.Signal(os.Kill)
+	Eventually(process.Wait()).Should(Receive())
+}
@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/receptor"
+	. "github.com/onsi/gomega"
+)
+
+// EventuallyTask waits up to Timeouts.Long for the task to reach the given
+// state and returns it.
+func EventuallyTask(receptorClient receptor.Client, taskGuid string, state string) receptor.TaskResponse {
+	var task receptor.TaskResponse
+	Eventually(TaskStatePoller(receptorClient, taskGuid, &task), Timeouts.Long).Should(Equal(state))
+
+	return task
+}
+
+// CompletedTask waits for the task to complete and returns it.
+func CompletedTask(receptorClient receptor.Client, taskGuid string) receptor.TaskResponse {
+	return EventuallyTask(receptorClient, taskGuid, receptor.TaskStateCompleted)
+}
+
+// ExpectTaskToFailWith waits for the task to complete and asserts that it
+// failed with exactly the given reason and no result.
+func ExpectTaskToFailWith(receptorClient receptor.Client, taskGuid string, failureReason string) {
+	task := CompletedTask(receptorClient, taskGuid)
+
+	Ω(task.Failed).Should(BeTrue(), "task %s did not fail", taskGuid)
+	Ω(task.FailureReason).Should(Equal(failureReason))
+	Ω(task.Result).Should(BeEmpty())
+}
+
+// ResultFileTooLargeReason is the failure reason the executor reports for a
+// result file of the given size over the given limit.
+func ResultFileTooLargeReason(size int, limit int) string {
+	return fmt.Sprintf("result file size exceeds allowed limit (got %d bytes > %d bytes)", size, limit)
+}
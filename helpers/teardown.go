@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"github.com/cloudfoundry-incubator/inigo/world"
+	"github.com/tedsuo/ifrit"
+)
+
+// Teardown stops the processes, keyed by the names of the components they
+// run, in world.DefaultTopology's shutdown order, so that e.g. a rep is not
+// left complaining about its executor having gone away.
+func Teardown(processes map[string]ifrit.Process) {
+	TeardownWith(world.DefaultTopology, processes)
+}
+
+// TeardownWith is Teardown in the order of the given topology.
+func TeardownWith(topology world.Topology, processes map[string]ifrit.Process) {
+	names := []string{}
+	for name := range processes {
+		names = append(names, name)
+	}
+
+	for _, wave := range topology.ShutdownWaves(names) {
+		waveProcesses := []ifrit.Process{}
+		for _, name := range wave {
+			waveProcesses = append(waveProcesses, processes[name])
+		}
+
+		StopProcesses(waveProcesses...)
+	}
+}
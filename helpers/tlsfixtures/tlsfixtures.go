@@ -0,0 +1,144 @@
+package tlsfixtures
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/localip"
+)
+
+const ClientCommonName = "inigo-client"
+
+// Credentials are the paths to a throwaway CA and the server and client
+// certificates it signed, as passed to components' TLS flags.
+type Credentials struct {
+	CACertFile string
+
+	ServerCertFile string
+	ServerKeyFile  string
+
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// Generate writes a fresh CA, a server certificate valid for 127.0.0.1 and
+// the machine's local IP, on which most fakes listen, and a client
+// certificate into dir.
+func Generate(dir string) Credentials {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	caTemplate := certificateTemplate(1, "inigo-ca")
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	caCert, err := x509.ParseCertificate(caDER)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	serverTemplate := certificateTemplate(2, "inigo-server")
+	localIP, err := localip.LocalIP()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	serverTemplate.IPAddresses = []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP(localIP)}
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	clientTemplate := certificateTemplate(3, ClientCommonName)
+	clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	credentials := Credentials{
+		CACertFile: filepath.Join(dir, "ca.crt"),
+
+		ServerCertFile: filepath.Join(dir, "server.crt"),
+		ServerKeyFile:  filepath.Join(dir, "server.key"),
+
+		ClientCertFile: filepath.Join(dir, "client.crt"),
+		ClientKeyFile:  filepath.Join(dir, "client.key"),
+	}
+
+	writePEM(credentials.CACertFile, "CERTIFICATE", caDER)
+	writeSignedCertificate(serverTemplate, caCert, caKey, credentials.ServerCertFile, credentials.ServerKeyFile)
+	writeSignedCertificate(clientTemplate, caCert, caKey, credentials.ClientCertFile, credentials.ClientKeyFile)
+
+	return credentials
+}
+
+// ServerConfig is the TLS configuration for a fake to listen with; if
+// requireClientCert is set, clients must present a certificate signed by the
+// CA.
+func (credentials Credentials) ServerConfig(requireClientCert bool) *tls.Config {
+	certificate, err := tls.LoadX509KeyPair(credentials.ServerCertFile, credentials.ServerKeyFile)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	if requireClientCert {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = credentials.caPool()
+	}
+
+	return config
+}
+
+// ClientConfig trusts the CA and presents the client certificate, for
+// talking to a fake directly from tests.
+func (credentials Credentials) ClientConfig() *tls.Config {
+	certificate, err := tls.LoadX509KeyPair(credentials.ClientCertFile, credentials.ClientKeyFile)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      credentials.caPool(),
+	}
+}
+
+func (credentials Credentials) caPool() *x509.CertPool {
+	caPEM, err := ioutil.ReadFile(credentials.CACertFile)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	pool := x509.NewCertPool()
+	Ω(pool.AppendCertsFromPEM(caPEM)).Should(BeTrue())
+
+	return pool
+}
+
+func certificateTemplate(serial int64, commonName string) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+}
+
+func writeSignedCertificate(template, caCert *x509.Certificate, caKey *rsa.PrivateKey, certFile, keyFile string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	writePEM(certFile, "CERTIFICATE", der)
+	writePEM(keyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+func writePEM(path string, blockType string, der []byte) {
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	Ω(err).ShouldNot(HaveOccurred())
+}
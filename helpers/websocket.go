@@ -0,0 +1,155 @@
+package helpers
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebsocketConn is a bare-bones WebSocket client connection that sends and
+// receives unfragmented text frames.
+type WebsocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// WebsocketDial opens a WebSocket through the router to the app routed at
+// host, failing if the upgrade is not accepted.
+func WebsocketDial(routerAddr string, host string) (*WebsocketConn, error) {
+	conn, err := net.Dial("tcp", routerAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	request, err := http.NewRequest("GET", "http://"+host+"/", nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	request.Header.Set("Sec-WebSocket-Version", "13")
+
+	err = request.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("websocket upgrade refused: %s", response.Status)
+	}
+
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	if response.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		conn.Close()
+		return nil, errors.New("websocket upgrade returned the wrong Sec-WebSocket-Accept")
+	}
+
+	return &WebsocketConn{conn: conn, reader: reader}, nil
+}
+
+// Send writes a masked text frame, as clients must.
+func (ws *WebsocketConn) Send(message string) error {
+	return ws.writeFrame(0x1, []byte(message))
+}
+
+// Receive reads the next frame's payload.
+func (ws *WebsocketConn) Receive() (string, error) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(ws.reader, header)
+	if err != nil {
+		return "", err
+	}
+
+	if header[0]&0x0f == 0x8 {
+		return "", io.EOF
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended uint16
+		err = binary.Read(ws.reader, binary.BigEndian, &extended)
+		length = uint64(extended)
+	case 127:
+		err = binary.Read(ws.reader, binary.BigEndian, &length)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(ws.reader, payload)
+	if err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
+
+// Close sends a close frame and closes the connection.
+func (ws *WebsocketConn) Close() error {
+	ws.writeFrame(0x8, nil)
+	return ws.conn.Close()
+}
+
+func (ws *WebsocketConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 0x80|126, byte(len(payload)>>8), byte(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		extended := make([]byte, 8)
+		binary.BigEndian.PutUint64(extended, uint64(len(payload)))
+		frame = append(frame, extended...)
+	}
+
+	mask := make([]byte, 4)
+	_, err := rand.Read(mask)
+	if err != nil {
+		return err
+	}
+
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err = ws.conn.Write(frame)
+	return err
+}
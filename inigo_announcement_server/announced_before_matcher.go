@@ -0,0 +1,59 @@
+package inigo_announcement_server
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// AnnouncedBefore succeeds if the first time earlier was announced precedes
+// the first time later was; both must have been announced. Match it against
+// AnnouncementsInOrder:
+//
+//	Ω(AnnouncementsInOrder()).Should(AnnouncedBefore("setup", "run"))
+func AnnouncedBefore(earlier, later string) types.GomegaMatcher {
+	return &announcedBeforeMatcher{earlier: earlier, later: later}
+}
+
+type announcedBeforeMatcher struct {
+	earlier string
+	later   string
+}
+
+func (matcher *announcedBeforeMatcher) Match(actual interface{}) (bool, error) {
+	announcements, ok := actual.([]Announcement)
+	if !ok {
+		return false, fmt.Errorf("AnnouncedBefore matcher expects a []Announcement; got:\n%s", format.Object(actual, 1))
+	}
+
+	earlier, found := firstSequence(announcements, matcher.earlier)
+	if !found {
+		return false, nil
+	}
+
+	later, found := firstSequence(announcements, matcher.later)
+	if !found {
+		return false, nil
+	}
+
+	return earlier < later, nil
+}
+
+func (matcher *announcedBeforeMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to announce %q before %q", matcher.earlier, matcher.later))
+}
+
+func (matcher *announcedBeforeMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to announce %q before %q", matcher.earlier, matcher.later))
+}
+
+func firstSequence(announcements []Announcement, announcement string) (int, bool) {
+	for _, a := range announcements {
+		if a.Announcement == announcement {
+			return a.Sequence, true
+		}
+	}
+
+	return 0, false
+}
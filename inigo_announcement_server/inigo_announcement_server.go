@@ -2,58 +2,105 @@ package inigo_announcement_server
 
 import (
 	"encoding/json"
-	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
+)
 
-	"net/http"
-	"net/http/httptest"
+type Announcement struct {
+	Announcement string    `json:"announcement"`
+	Sequence     int       `json:"sequence"`
+	AnnouncedAt  time.Time `json:"announced_at"`
+}
 
-	"github.com/cloudfoundry-incubator/inigo/helpers"
-	. "github.com/onsi/gomega"
-)
+// Server records what containers announce to it. Every announcement is
+// written through to its state file, so a restarted server picks up where
+// the last one left off.
+type Server struct {
+	stateFile string
 
-var server *httptest.Server
-var serverAddr string
-
-func Start(externalAddress string) {
-	lock := &sync.RWMutex{}
-
-	registered := []string{}
-
-	server, serverAddr = helpers.Callback(externalAddress, func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/announce":
-			lock.Lock()
-			registered = append(registered, r.URL.Query().Get("announcement"))
-			lock.Unlock()
-		case "/announcements":
-			lock.RLock()
-			json.NewEncoder(w).Encode(registered)
-			lock.RUnlock()
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
-	})
+	announcements []Announcement
+	lock          *sync.RWMutex
 }
 
-func Stop() {
-	server.Close()
+// NewServer returns a Server persisting to stateFile, loading whatever an
+// earlier one left there.
+func NewServer(stateFile string) (*Server, error) {
+	server := &Server{
+		stateFile: stateFile,
+
+		announcements: []Announcement{},
+		lock:          new(sync.RWMutex),
+	}
+
+	state, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return server, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(state, &server.announcements)
+	if err != nil {
+		return nil, err
+	}
+
+	return server, nil
 }
 
-func AnnounceURL(announcement string) string {
-	return fmt.Sprintf("http://%s/announce?announcement=%s", serverAddr, announcement)
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/announce":
+		err := s.announce(r.URL.Query().Get("announcement"))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	case "/announcements":
+		s.lock.RLock()
+		registered := []string{}
+		for _, announcement := range s.announcements {
+			registered = append(registered, announcement.Announcement)
+		}
+		s.lock.RUnlock()
+
+		json.NewEncoder(w).Encode(registered)
+	case "/announcements-in-order":
+		s.lock.RLock()
+		json.NewEncoder(w).Encode(s.announcements)
+		s.lock.RUnlock()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
 }
 
-func Announcements() []string {
-	response, err := http.Get(fmt.Sprintf("http://%s/announcements", serverAddr))
-	Ω(err).ShouldNot(HaveOccurred())
+func (s *Server) announce(announcement string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.announcements = append(s.announcements, Announcement{
+		Announcement: announcement,
+		Sequence:     len(s.announcements),
+		AnnouncedAt:  time.Now(),
+	})
 
-	defer response.Body.Close()
+	state, err := json.Marshal(s.announcements)
+	if err != nil {
+		return err
+	}
 
-	var responses []string
+	// write and rename, so that a server killed mid-write leaves the last
+	// complete state behind
+	tmpFile := filepath.Join(filepath.Dir(s.stateFile), "."+filepath.Base(s.stateFile)+".tmp")
 
-	err = json.NewDecoder(response.Body).Decode(&responses)
-	Ω(err).ShouldNot(HaveOccurred())
+	err = ioutil.WriteFile(tmpFile, state, 0644)
+	if err != nil {
+		return err
+	}
 
-	return responses
+	return os.Rename(tmpFile, s.stateFile)
 }
@@ -0,0 +1,149 @@
+package perf
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/soak"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+const (
+	OperationAllocate = "allocate"
+	OperationRun      = "run"
+	OperationDelete   = "delete"
+)
+
+var ErrRunTimeout = errors.New("timed out waiting for container to run")
+
+type Config struct {
+	// how many containers to put through allocate, run and delete
+	Containers int
+
+	// how many of them to have in flight at once
+	Concurrency int
+
+	MemoryMB int
+	DiskMB   int
+
+	// how long to wait for each container to start running before counting
+	// it as an error
+	RunTimeout time.Duration
+}
+
+// Benchmark allocates, runs, and deletes many small containers concurrently
+// through the executor, timing each step.
+type Benchmark struct {
+	executorClient executor.Client
+	config         Config
+}
+
+func NewBenchmark(executorClient executor.Client, config Config) *Benchmark {
+	return &Benchmark{
+		executorClient: executorClient,
+		config:         config,
+	}
+}
+
+func (benchmark *Benchmark) Run() *soak.Report {
+	report := soak.NewReport()
+
+	guids := make(chan string)
+	go func() {
+		for i := 0; i < benchmark.config.Containers; i++ {
+			guids <- helpers.NewGuid("container")
+		}
+
+		close(guids)
+	}()
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < benchmark.config.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for guid := range guids {
+				benchmark.cycle(report, guid)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	report.Finish()
+
+	return report
+}
+
+// cycle puts one container through its whole lifecycle, stopping at the
+// first failed step but always trying to delete what it allocated.
+func (benchmark *Benchmark) cycle(report *soak.Report, guid string) {
+	startedAt := time.Now()
+	err := benchmark.allocate(guid)
+	report.Record(OperationAllocate, time.Since(startedAt), err)
+	if err != nil {
+		return
+	}
+
+	startedAt = time.Now()
+	err = benchmark.run(guid)
+	report.Record(OperationRun, time.Since(startedAt), err)
+
+	startedAt = time.Now()
+	err = benchmark.executorClient.DeleteContainer(guid)
+	report.Record(OperationDelete, time.Since(startedAt), err)
+}
+
+func (benchmark *Benchmark) allocate(guid string) error {
+	allocationErrors, err := benchmark.executorClient.AllocateContainers([]executor.Container{{
+		Guid:     guid,
+		MemoryMB: benchmark.config.MemoryMB,
+		DiskMB:   benchmark.config.DiskMB,
+
+		Action: &models.RunAction{
+			Path: "sh",
+			Args: []string{"-c", "while true; do sleep 1; done"},
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	if allocationErr, found := allocationErrors[guid]; found {
+		return fmt.Errorf("failed to allocate: %s", allocationErr)
+	}
+
+	return nil
+}
+
+func (benchmark *Benchmark) run(guid string) error {
+	err := benchmark.executorClient.RunContainer(guid)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(benchmark.config.RunTimeout)
+	for time.Now().Before(deadline) {
+		container, err := benchmark.executorClient.GetContainer(guid)
+		if err != nil {
+			return err
+		}
+
+		switch container.State {
+		case executor.StateRunning:
+			return nil
+		case executor.StateCompleted:
+			return fmt.Errorf("container completed before running: %s", container.RunResult.FailureReason)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return ErrRunTimeout
+}
@@ -0,0 +1,73 @@
+package perf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/soak"
+	"github.com/cloudfoundry-incubator/receptor"
+)
+
+const (
+	OperationListTasks         = "list-tasks"
+	OperationListTasksByDomain = "list-tasks-by-domain"
+)
+
+type ListingConfig struct {
+	// the domain to list by, and how many Tasks are in it
+	Domain        string
+	TasksInDomain int
+
+	// how many Tasks there are in all
+	Tasks int
+
+	// how many times to list each way
+	Samples int
+}
+
+// ListingBenchmark times listing every Task through the receptor, and
+// listing the Tasks of one domain, over and over. A listing that comes back
+// with the wrong number of Tasks counts as an error.
+//
+// The receptor does not paginate, so each listing is of the whole set.
+type ListingBenchmark struct {
+	receptorClient receptor.Client
+	config         ListingConfig
+}
+
+func NewListingBenchmark(receptorClient receptor.Client, config ListingConfig) *ListingBenchmark {
+	return &ListingBenchmark{
+		receptorClient: receptorClient,
+		config:         config,
+	}
+}
+
+func (benchmark *ListingBenchmark) Run() *soak.Report {
+	report := soak.NewReport()
+
+	for i := 0; i < benchmark.config.Samples; i++ {
+		startedAt := time.Now()
+		tasks, err := benchmark.receptorClient.Tasks()
+		report.Record(OperationListTasks, time.Since(startedAt), countError(tasks, err, benchmark.config.Tasks))
+
+		startedAt = time.Now()
+		tasks, err = benchmark.receptorClient.TasksByDomain(benchmark.config.Domain)
+		report.Record(OperationListTasksByDomain, time.Since(startedAt), countError(tasks, err, benchmark.config.TasksInDomain))
+	}
+
+	report.Finish()
+
+	return report
+}
+
+func countError(tasks []receptor.TaskResponse, err error, expected int) error {
+	if err != nil {
+		return err
+	}
+
+	if len(tasks) != expected {
+		return fmt.Errorf("listed %d tasks, expected %d", len(tasks), expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package perf_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/ginkgoreporter"
+	"github.com/tedsuo/ifrit"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+)
+
+var (
+	componentMaker world.ComponentMaker
+
+	plumbing     map[string]ifrit.Process
+	gardenClient garden.Client
+)
+
+var _ = SynchronizedBeforeSuite(func() []byte {
+	payload, err := json.Marshal(world.BuiltArtifacts{
+		Executables: world.CompileTestedExecutables(),
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return payload
+}, func(encodedBuiltArtifacts []byte) {
+	var builtArtifacts world.BuiltArtifacts
+
+	err := json.Unmarshal(encodedBuiltArtifacts, &builtArtifacts)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	componentMaker = helpers.MakeComponentMaker(builtArtifacts)
+})
+
+var _ = AfterSuite(func() {
+	componentMaker.Timings.WriteReport("perf")
+	componentMaker.TempDirs.RemoveAll()
+})
+
+var _ = BeforeEach(func() {
+	componentMaker.Timings.StartSpec()
+	helpers.NameSpecGuids(componentMaker.Timings.SpecID())
+
+	environment := world.BootstrapPlumbing(world.BootstrapConfig{
+		Maker:    componentMaker,
+		Plumbing: []string{"garden-linux"},
+	})
+
+	plumbing = environment.Processes
+	gardenClient = environment.GardenClient
+})
+
+var _ = AfterEach(func() {
+	defer componentMaker.Timings.FinishSpec()
+
+	destroyContainerErrors := helpers.CleanupGarden(gardenClient)
+
+	helpers.Teardown(plumbing)
+
+	Ω(destroyContainerErrors).Should(
+		BeEmpty(),
+		"%d containers failed to be destroyed!",
+		len(destroyContainerErrors),
+	)
+})
+
+func TestPerf(t *testing.T) {
+	helpers.LoadConfig()
+
+	if os.Getenv("PERF") != "1" {
+		t.Skip("perf tests only run with PERF=1")
+	}
+
+	helpers.RegisterDefaultTimeouts()
+
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t, "Perf Suite", []Reporter{
+		ginkgoreporter.New(GinkgoWriter),
+	})
+}
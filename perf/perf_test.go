@@ -0,0 +1,93 @@
+package perf_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/perf"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Executor allocation", func() {
+	var (
+		config   perf.Config
+		executor ifrit.Process
+	)
+
+	BeforeEach(func() {
+		config = perf.Config{
+			Containers:  envInt("PERF_CONTAINERS", 200),
+			Concurrency: envInt("PERF_CONCURRENCY", 20),
+
+			MemoryMB: 16,
+			DiskMB:   16,
+
+			RunTimeout: envDuration("PERF_RUN_TIMEOUT", helpers.Timeouts.Long),
+		}
+
+		executor = ginkgomon.Invoke(componentMaker.Executor())
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(executor)
+	})
+
+	It("allocates, runs, and deletes many containers at once", func() {
+		fmt.Fprintf(GinkgoWriter, "cycling %d containers, %d at a time\n", config.Containers, config.Concurrency)
+
+		report := perf.NewBenchmark(componentMaker.ExecutorClient(), config).Run()
+
+		reportPath := os.Getenv("PERF_REPORT")
+		if reportPath == "" {
+			reportPath = filepath.Join(os.TempDir(), fmt.Sprintf("perf-report-%d.json", GinkgoParallelNode()))
+		}
+
+		err := report.WriteJSON(reportPath)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		fmt.Fprintf(GinkgoWriter, "wrote perf report to %s\n", reportPath)
+
+		for _, operation := range []string{perf.OperationAllocate, perf.OperationRun, perf.OperationDelete} {
+			opReport := report.Operations[operation]
+			Ω(opReport).ShouldNot(BeNil(), "no %s operations were recorded", operation)
+			Ω(opReport.Errors).Should(BeZero(), "%s failed: %v", operation, opReport.Failures)
+		}
+
+		if os.Getenv("PERF_MAX_ALLOCATE_P90") != "" {
+			maxP90 := envDuration("PERF_MAX_ALLOCATE_P90", 0)
+			Ω(report.Operations[perf.OperationAllocate].LatencyP90).Should(BeNumerically("<=", maxP90))
+		}
+	})
+})
+
+func envInt(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return parsed
+}
+
+func envDuration(name string, defaultDuration time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultDuration
+	}
+
+	duration, err := time.ParseDuration(value)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return duration
+}
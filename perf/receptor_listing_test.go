@@ -0,0 +1,93 @@
+package perf_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/perf"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Receptor listing", func() {
+	var (
+		receptorClient receptor.Client
+
+		runtime ifrit.Process
+	)
+
+	BeforeEach(func() {
+		runtime = ginkgomon.Invoke(grouper.NewOrdered(os.Kill, grouper.Members{
+			{"etcd", componentMaker.Etcd()},
+			{"receptor", componentMaker.Receptor()},
+		}))
+
+		receptorClient = componentMaker.ReceptorClient()
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(runtime)
+	})
+
+	It("lists thousands of Tasks, by domain and in all, correctly and quickly", func() {
+		tasks := envInt("PERF_LIST_TASKS", 2000)
+		concurrency := envInt("PERF_CONCURRENCY", 20)
+
+		template := receptor.TaskCreateRequest{
+			Stack: componentMaker.Stack,
+			Action: &models.RunAction{
+				Path: "true",
+			},
+		}
+
+		By(fmt.Sprintf("creating %d Tasks in one domain and %d in another", tasks, tasks/4))
+		inDomain := helpers.CreateTasks(receptorClient, "listed", tasks, concurrency, template)
+		inOtherDomain := helpers.CreateTasks(receptorClient, "not-listed", tasks/4, concurrency, template)
+
+		By("listing exactly the Tasks in each domain")
+		Ω(helpers.ListedTaskGuids(receptorClient, "listed")).Should(Equal(inDomain))
+		Ω(helpers.ListedTaskGuids(receptorClient, "not-listed")).Should(Equal(inOtherDomain))
+		Ω(helpers.ListedTaskGuids(receptorClient, "nonexistent")).Should(BeEmpty())
+		Ω(helpers.ListedTaskGuids(receptorClient, "")).Should(HaveLen(len(inDomain) + len(inOtherDomain)))
+
+		By("timing the listings")
+		report := perf.NewListingBenchmark(receptorClient, perf.ListingConfig{
+			Domain:        "listed",
+			TasksInDomain: len(inDomain),
+			Tasks:         len(inDomain) + len(inOtherDomain),
+			Samples:       envInt("PERF_LIST_SAMPLES", 20),
+		}).Run()
+
+		reportPath := os.Getenv("PERF_LIST_REPORT")
+		if reportPath == "" {
+			reportPath = filepath.Join(os.TempDir(), fmt.Sprintf("perf-listing-report-%d.json", GinkgoParallelNode()))
+		}
+
+		err := report.WriteJSON(reportPath)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		fmt.Fprintf(GinkgoWriter, "wrote listing report to %s\n", reportPath)
+
+		for _, operation := range []string{perf.OperationListTasks, perf.OperationListTasksByDomain} {
+			opReport := report.Operations[operation]
+			Ω(opReport).ShouldNot(BeNil(), "no %s operations were recorded", operation)
+			Ω(opReport.Errors).Should(BeZero(), "%s failed: %v", operation, opReport.Failures)
+
+			fmt.Fprintf(GinkgoWriter, "%s: p50 %s, p90 %s, max %s\n", operation, opReport.LatencyP50, opReport.LatencyP90, opReport.LatencyMax)
+		}
+
+		if os.Getenv("PERF_MAX_LIST_P90") != "" {
+			maxP90 := envDuration("PERF_MAX_LIST_P90", 0)
+			Ω(report.Operations[perf.OperationListTasks].LatencyP90).Should(BeNumerically("<=", maxP90))
+			Ω(report.Operations[perf.OperationListTasksByDomain].LatencyP90).Should(BeNumerically("<=", maxP90))
+		}
+	})
+})
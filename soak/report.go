@@ -0,0 +1,120 @@
+package soak
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Report struct {
+	StartedAt  time.Time                   `json:"started_at"`
+	FinishedAt time.Time                   `json:"finished_at"`
+	Operations map[string]*OperationReport `json:"operations"`
+
+	lock *sync.Mutex
+}
+
+type OperationReport struct {
+	Count     int      `json:"count"`
+	Errors    int      `json:"errors"`
+	ErrorRate float64  `json:"error_rate"`
+	Failures  []string `json:"failures,omitempty"`
+
+	// convergence latencies of successful operations, in nanoseconds
+	LatencyP50 time.Duration `json:"latency_p50_ns"`
+	LatencyP90 time.Duration `json:"latency_p90_ns"`
+	LatencyP99 time.Duration `json:"latency_p99_ns"`
+	LatencyMax time.Duration `json:"latency_max_ns"`
+
+	latencies []time.Duration
+}
+
+func NewReport() *Report {
+	return &Report{
+		StartedAt:  time.Now(),
+		Operations: map[string]*OperationReport{},
+		lock:       new(sync.Mutex),
+	}
+}
+
+func (report *Report) Record(operation string, latency time.Duration, err error) {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	opReport, found := report.Operations[operation]
+	if !found {
+		opReport = &OperationReport{}
+		report.Operations[operation] = opReport
+	}
+
+	opReport.Count++
+
+	if err != nil {
+		opReport.Errors++
+		opReport.Failures = append(opReport.Failures, err.Error())
+	} else {
+		opReport.latencies = append(opReport.latencies, latency)
+	}
+
+	opReport.ErrorRate = float64(opReport.Errors) / float64(opReport.Count)
+}
+
+// Finish computes the latency percentiles; it must be called before the
+// report is written out.
+func (report *Report) Finish() {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	report.FinishedAt = time.Now()
+
+	for _, opReport := range report.Operations {
+		latencies := opReport.latencies
+		if len(latencies) == 0 {
+			continue
+		}
+
+		sort.Sort(durations(latencies))
+
+		opReport.LatencyP50 = latencies[len(latencies)*50/100]
+		opReport.LatencyP90 = latencies[len(latencies)*90/100]
+		opReport.LatencyP99 = latencies[len(latencies)*99/100]
+		opReport.LatencyMax = latencies[len(latencies)-1]
+	}
+}
+
+func (report *Report) ErrorRate() float64 {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	count, errors := 0, 0
+	for _, opReport := range report.Operations {
+		count += opReport.Count
+		errors += opReport.Errors
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return float64(errors) / float64(count)
+}
+
+func (report *Report) WriteJSON(path string) error {
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	payload, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, payload, 0644)
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
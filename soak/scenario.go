@@ -0,0 +1,235 @@
+package soak
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+const (
+	OperationCreateLRP = "create-lrp"
+	OperationScaleLRP  = "scale-lrp"
+	OperationDeleteLRP = "delete-lrp"
+	OperationRunTask   = "run-task"
+)
+
+var ErrConvergenceTimeout = errors.New("timed out waiting for convergence")
+
+type Config struct {
+	Domain string
+	Stack  string
+
+	// how long to keep issuing operations for
+	Duration time.Duration
+
+	// how long to wait for each operation to converge before counting it as
+	// an error
+	ConvergenceTimeout time.Duration
+
+	// upper bound on the number of LRPs desired at any one time
+	MaxLRPs int
+
+	// upper bound on the number of instances of any one LRP
+	MaxInstances int
+
+	Seed int64
+}
+
+type Scenario struct {
+	receptorClient receptor.Client
+	config         Config
+
+	random *rand.Rand
+	lrps   []string
+}
+
+func NewScenario(receptorClient receptor.Client, config Config) *Scenario {
+	return &Scenario{
+		receptorClient: receptorClient,
+		config:         config,
+
+		random: rand.New(rand.NewSource(config.Seed)),
+	}
+}
+
+// Run continuously creates, scales, and deletes LRPs and runs tasks until
+// the configured duration has elapsed, then deletes whatever LRPs remain.
+func (scenario *Scenario) Run() *Report {
+	report := NewReport()
+
+	deadline := time.Now().Add(scenario.config.Duration)
+	for time.Now().Before(deadline) {
+		operation, perform := scenario.nextOperation()
+
+		startedAt := time.Now()
+		err := perform()
+		report.Record(operation, time.Since(startedAt), err)
+	}
+
+	for len(scenario.lrps) > 0 {
+		startedAt := time.Now()
+		err := scenario.deleteLRP()
+		report.Record(OperationDeleteLRP, time.Since(startedAt), err)
+	}
+
+	report.Finish()
+
+	return report
+}
+
+func (scenario *Scenario) nextOperation() (string, func() error) {
+	if len(scenario.lrps) == 0 {
+		return OperationCreateLRP, scenario.createLRP
+	}
+
+	switch scenario.random.Intn(4) {
+	case 0:
+		if len(scenario.lrps) < scenario.config.MaxLRPs {
+			return OperationCreateLRP, scenario.createLRP
+		}
+		return OperationDeleteLRP, scenario.deleteLRP
+	case 1:
+		return OperationScaleLRP, scenario.scaleLRP
+	case 2:
+		return OperationDeleteLRP, scenario.deleteLRP
+	default:
+		return OperationRunTask, scenario.runTask
+	}
+}
+
+func (scenario *Scenario) createLRP() error {
+	processGuid := helpers.NewGuid("lrp")
+
+	err := scenario.receptorClient.CreateDesiredLRP(receptor.DesiredLRPCreateRequest{
+		Domain:      scenario.config.Domain,
+		ProcessGuid: processGuid,
+		Instances:   1,
+		Stack:       scenario.config.Stack,
+		MemoryMB:    32,
+		DiskMB:      32,
+
+		Action: &models.RunAction{
+			Path: "sh",
+			Args: []string{"-c", "while true; do sleep 1; done"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	scenario.lrps = append(scenario.lrps, processGuid)
+
+	return scenario.waitForRunningInstances(processGuid, 1)
+}
+
+func (scenario *Scenario) scaleLRP() error {
+	processGuid := scenario.lrps[scenario.random.Intn(len(scenario.lrps))]
+	instances := 1 + scenario.random.Intn(scenario.config.MaxInstances)
+
+	err := scenario.receptorClient.UpdateDesiredLRP(processGuid, receptor.DesiredLRPUpdateRequest{
+		Instances: &instances,
+	})
+	if err != nil {
+		return err
+	}
+
+	return scenario.waitForRunningInstances(processGuid, instances)
+}
+
+func (scenario *Scenario) deleteLRP() error {
+	i := scenario.random.Intn(len(scenario.lrps))
+	processGuid := scenario.lrps[i]
+	scenario.lrps = append(scenario.lrps[:i], scenario.lrps[i+1:]...)
+
+	err := scenario.receptorClient.DeleteDesiredLRP(processGuid)
+	if err != nil {
+		return err
+	}
+
+	return scenario.waitFor(func() (bool, error) {
+		lrps, err := scenario.receptorClient.ActualLRPsByProcessGuid(processGuid)
+		return len(lrps) == 0, err
+	})
+}
+
+func (scenario *Scenario) runTask() error {
+	taskGuid := helpers.NewGuid("task")
+
+	err := scenario.receptorClient.CreateTask(receptor.TaskCreateRequest{
+		Domain:   scenario.config.Domain,
+		TaskGuid: taskGuid,
+		Stack:    scenario.config.Stack,
+		MemoryMB: 32,
+		DiskMB:   32,
+
+		Action: &models.RunAction{
+			Path: "true",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	var task receptor.TaskResponse
+	err = scenario.waitFor(func() (bool, error) {
+		var err error
+		task, err = scenario.receptorClient.GetTask(taskGuid)
+		return task.State == receptor.TaskStateCompleted, err
+	})
+	if err != nil {
+		return err
+	}
+
+	if task.Failed {
+		return fmt.Errorf("task %s failed: %s", taskGuid, task.FailureReason)
+	}
+
+	return scenario.receptorClient.DeleteTask(taskGuid)
+}
+
+func (scenario *Scenario) waitForRunningInstances(processGuid string, instances int) error {
+	return scenario.waitFor(func() (bool, error) {
+		lrps, err := scenario.receptorClient.ActualLRPsByProcessGuid(processGuid)
+		if err != nil {
+			return false, err
+		}
+
+		running := 0
+		for _, lrp := range lrps {
+			if lrp.State == receptor.ActualLRPStateRunning {
+				running++
+			}
+		}
+
+		return running == instances && len(lrps) == instances, nil
+	})
+}
+
+// waitFor polls until the check reports convergence; errors from the check
+// are retried until the convergence timeout, as they frequently are
+// transient under load.
+func (scenario *Scenario) waitFor(check func() (bool, error)) error {
+	deadline := time.Now().Add(scenario.config.ConvergenceTimeout)
+
+	var lastErr error
+	for time.Now().Before(deadline) {
+		converged, err := check()
+		if err == nil && converged {
+			return nil
+		}
+
+		lastErr = err
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("%s: %s", ErrConvergenceTimeout, lastErr)
+	}
+
+	return ErrConvergenceTimeout
+}
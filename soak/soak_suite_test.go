@@ -0,0 +1,96 @@
+package soak_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/ginkgoreporter"
+
+	"github.com/cloudfoundry-incubator/inigo/helpers"
+	"github.com/cloudfoundry-incubator/inigo/world"
+)
+
+const INIGO_DOMAIN = "inigo"
+
+var (
+	componentMaker world.ComponentMaker
+
+	environment world.Environment
+)
+
+var _ = SynchronizedBeforeSuite(func() []byte {
+	payload, err := json.Marshal(helpers.BuildOrImportArtifacts("soak", func() world.BuiltArtifacts {
+		return world.BuiltArtifacts{
+			Executables: world.CompileTestedExecutables(),
+			Lifecycles:  world.BuildLifecycles(helpers.Stack()),
+		}
+	}))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return payload
+}, func(encodedBuiltArtifacts []byte) {
+	var builtArtifacts world.BuiltArtifacts
+
+	err := json.Unmarshal(encodedBuiltArtifacts, &builtArtifacts)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	componentMaker = helpers.MakeComponentMaker(builtArtifacts)
+})
+
+var _ = SynchronizedAfterSuite(func() {
+	componentMaker.Timings.WriteReport("soak")
+	componentMaker.TempDirs.RemoveAll()
+}, func() {
+	// only the first node imported them, and every node is done with them by now
+	componentMaker.Artifacts.RemoveImported()
+})
+
+var _ = BeforeEach(func() {
+	componentMaker.Timings.StartSpec()
+	helpers.StartSpecGuids(componentMaker.Timings.SpecID())
+
+	environment = world.Bootstrap(world.BootstrapConfig{
+		Maker:         componentMaker,
+		ExecutorArgs:  []string{"-memoryMB", "4096"},
+		ConvergerArgs: []string{"-convergeRepeatInterval", "1s"},
+	})
+
+	err := environment.ReceptorClient.UpsertDomain(INIGO_DOMAIN, 0)
+	Ω(err).ShouldNot(HaveOccurred())
+})
+
+var _ = AfterEach(func() {
+	defer componentMaker.Timings.FinishSpec()
+
+	cleanupGuidErrors := helpers.CleanupSpecGuids(environment.ReceptorClient)
+
+	destroyContainerErrors := helpers.CleanupGarden(environment.GardenClient)
+
+	helpers.Teardown(environment.Processes)
+
+	Ω(cleanupGuidErrors).Should(BeEmpty(), "tasks or LRPs the spec created failed to be cleaned up")
+	Ω(destroyContainerErrors).Should(
+		BeEmpty(),
+		"%d containers failed to be destroyed!",
+		len(destroyContainerErrors),
+	)
+})
+
+func TestSoak(t *testing.T) {
+	helpers.LoadConfig()
+
+	if os.Getenv("SOAK") != "1" {
+		t.Skip("soak tests only run with SOAK=1")
+	}
+
+	helpers.RegisterDefaultTimeouts()
+
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t, "Soak Suite", []Reporter{
+		ginkgoreporter.New(GinkgoWriter),
+	})
+}
@@ -0,0 +1,69 @@
+package soak_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/inigo/soak"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Soak", func() {
+	var config soak.Config
+
+	BeforeEach(func() {
+		config = soak.Config{
+			Domain: INIGO_DOMAIN,
+			Stack:  componentMaker.Stack,
+
+			Duration:           envDuration("SOAK_DURATION", 10*time.Minute),
+			ConvergenceTimeout: envDuration("SOAK_CONVERGENCE_TIMEOUT", time.Minute),
+
+			MaxLRPs:      10,
+			MaxInstances: 3,
+
+			Seed: time.Now().UnixNano(),
+		}
+	})
+
+	It("keeps converging under continuous churn", func() {
+		fmt.Fprintf(GinkgoWriter, "soaking for %s with seed %d\n", config.Duration, config.Seed)
+
+		report := soak.NewScenario(environment.ReceptorClient, config).Run()
+
+		reportPath := os.Getenv("SOAK_REPORT")
+		if reportPath == "" {
+			reportPath = filepath.Join(os.TempDir(), fmt.Sprintf("soak-report-%d.json", GinkgoParallelNode()))
+		}
+
+		err := report.WriteJSON(reportPath)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		fmt.Fprintf(GinkgoWriter, "wrote soak report to %s\n", reportPath)
+
+		maxErrorRate := 0.01
+		if rate := os.Getenv("SOAK_MAX_ERROR_RATE"); rate != "" {
+			maxErrorRate, err = strconv.ParseFloat(rate, 64)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		Ω(report.ErrorRate()).Should(BeNumerically("<=", maxErrorRate))
+	})
+})
+
+func envDuration(name string, defaultDuration time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultDuration
+	}
+
+	duration, err := time.ParseDuration(value)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return duration
+}
@@ -0,0 +1,334 @@
+package world
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// ArtifactsManifestName is where in an exported artifacts tarball its
+// ArtifactsManifest is.
+const ArtifactsManifestName = "manifest.json"
+
+// ArtifactsManifest says what is in an exported artifacts tarball, and what
+// each artifact was built from.
+type ArtifactsManifest struct {
+	// paths in the tarball, keyed as in BuiltArtifacts
+	Executables map[string]string
+	Lifecycles  map[string]string
+	Versions    map[string]map[string]string `json:",omitempty"`
+	Fixtures    map[string]string            `json:",omitempty"`
+
+	// keyed by path in the tarball
+	Sources map[string]ArtifactSource
+}
+
+type ArtifactSource struct {
+	ImportPath string
+	BuildFlags []string `json:",omitempty"`
+
+	// the commit the source was checked out at, if it is in a git repo
+	GitSHA string `json:",omitempty"`
+}
+
+// the fixed modification time of everything in an exported tarball, so
+// that exporting the same binaries twice gives the same bytes
+var artifactsModTime = time.Unix(0, 0).UTC()
+
+// ExportArtifacts writes the executables, lifecycles, versions and
+// fixtures in artifacts to artifacts-<suite>-node-<n>.tgz in dir, along
+// with an ArtifactsManifest, so that other suites can ImportArtifacts them
+// rather than build their own. It returns the tarball's path.
+//
+// The shared file-server is not exported; it only lives as long as the
+// suite that started it.
+func ExportArtifacts(artifacts BuiltArtifacts, dir string, suite string) string {
+	err := os.MkdirAll(dir, 0755)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	manifest := ArtifactsManifest{
+		Executables: map[string]string{},
+		Lifecycles:  map[string]string{},
+		Versions:    map[string]map[string]string{},
+		Fixtures:    map[string]string{},
+		Sources:     map[string]ArtifactSource{},
+	}
+
+	// tarball paths to the files to put there
+	files := map[string]string{}
+
+	for name, binPath := range artifacts.Executables {
+		tarPath := path.Join("executables", name)
+		files[tarPath] = binPath
+		manifest.Executables[name] = tarPath
+		manifest.Sources[tarPath] = executableSource(name, "")
+	}
+
+	for stack, tgzPath := range artifacts.Lifecycles {
+		tarPath := path.Join("lifecycles", stack, filepath.Base(tgzPath))
+		files[tarPath] = tgzPath
+		manifest.Lifecycles[stack] = tarPath
+		manifest.Sources[tarPath] = lifecycleSource()
+	}
+
+	for version, executables := range artifacts.Versions {
+		manifest.Versions[version] = map[string]string{}
+
+		for name, binPath := range executables {
+			tarPath := path.Join("versions", version, name)
+			files[tarPath] = binPath
+			manifest.Versions[version][name] = tarPath
+			manifest.Sources[tarPath] = executableSource(name, version)
+		}
+	}
+
+	for name, binPath := range artifacts.Fixtures {
+		tarPath := path.Join("fixtures", name)
+		files[tarPath] = binPath
+		manifest.Fixtures[name] = tarPath
+		manifest.Sources[tarPath] = fixtureSource(name)
+	}
+
+	tarballPath := filepath.Join(dir, fmt.Sprintf("artifacts-%s-node-%d.tgz", suite, ginkgo.GinkgoParallelNode()))
+
+	tarball, err := os.Create(tarballPath)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer tarball.Close()
+
+	gzipWriter := gzip.NewWriter(tarball)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:    ArtifactsManifestName,
+		Mode:    0644,
+		Size:    int64(len(manifestJSON)),
+		ModTime: artifactsModTime,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	_, err = tarWriter.Write(manifestJSON)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	tarPaths := []string{}
+	for tarPath := range files {
+		tarPaths = append(tarPaths, tarPath)
+	}
+	sort.Strings(tarPaths)
+
+	for _, tarPath := range tarPaths {
+		writeArtifact(tarWriter, tarPath, files[tarPath])
+	}
+
+	err = tarWriter.Close()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = gzipWriter.Close()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return tarballPath
+}
+
+// ImportArtifacts extracts a tarball made by ExportArtifacts into dir,
+// and returns the artifacts in it as if they had been built here. Once
+// nothing runs them any more, RemoveImported removes dir.
+func ImportArtifacts(tarballPath string, dir string) (BuiltArtifacts, ArtifactsManifest) {
+	tarball, err := os.Open(tarballPath)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer tarball.Close()
+
+	gzipReader, err := gzip.NewReader(tarball)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	var manifest ArtifactsManifest
+	foundManifest := false
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		Ω(err).ShouldNot(HaveOccurred())
+
+		if header.Name == ArtifactsManifestName {
+			err := json.NewDecoder(tarReader).Decode(&manifest)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			foundManifest = true
+			continue
+		}
+
+		// refuse anything that would land outside dir
+		name := path.Clean(header.Name)
+		Ω(path.IsAbs(name) || strings.HasPrefix(name, "..")).Should(BeFalse(), "artifact %s is outside the tarball", header.Name)
+
+		extractArtifact(tarReader, header, filepath.Join(dir, filepath.FromSlash(header.Name)))
+	}
+
+	Ω(foundManifest).Should(BeTrue(), "%s has no %s", tarballPath, ArtifactsManifestName)
+
+	inDir := func(tarPaths map[string]string) BuiltExecutables {
+		paths := BuiltExecutables{}
+		for name, tarPath := range tarPaths {
+			paths[name] = filepath.Join(dir, filepath.FromSlash(tarPath))
+		}
+
+		return paths
+	}
+
+	artifacts := BuiltArtifacts{
+		Executables: inDir(manifest.Executables),
+		Lifecycles:  BuiltLifecycles(inDir(manifest.Lifecycles)),
+		Fixtures:    inDir(manifest.Fixtures),
+
+		ImportedDir: dir,
+	}
+
+	if len(manifest.Versions) > 0 {
+		artifacts.Versions = map[string]BuiltExecutables{}
+		for version, tarPaths := range manifest.Versions {
+			artifacts.Versions[version] = inDir(tarPaths)
+		}
+	}
+
+	return artifacts, manifest
+}
+
+// RemoveImported removes the directory the artifacts were imported into,
+// if they were; call it from the second function of the suite's
+// SynchronizedAfterSuite, once every node is done with them.
+func (artifacts BuiltArtifacts) RemoveImported() {
+	if artifacts.ImportedDir == "" {
+		return
+	}
+
+	err := os.RemoveAll(artifacts.ImportedDir)
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+func writeArtifact(tarWriter *tar.Writer, tarPath string, filePath string) {
+	file, err := os.Open(filePath)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer file.Close()
+
+	info, err := file.Stat()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:    tarPath,
+		Mode:    int64(info.Mode().Perm()),
+		Size:    info.Size(),
+		ModTime: artifactsModTime,
+	})
+	Ω(err).ShouldNot(HaveOccurred())
+
+	_, err = io.Copy(tarWriter, file)
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+func extractArtifact(tarReader *tar.Reader, header *tar.Header, filePath string) {
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+	Ω(err).ShouldNot(HaveOccurred())
+	defer file.Close()
+
+	_, err = io.Copy(file, tarReader)
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+// executableSource is where CompileTestedExecutables, or for a version
+// CompileVersionedExecutables, built the executable from.
+func executableSource(name string, version string) ArtifactSource {
+	if name == "announcement-server" {
+		return ArtifactSource{
+			ImportPath: "github.com/cloudfoundry-incubator/inigo/cmd/announcement-server",
+			BuildFlags: []string{"-race"},
+			GitSHA:     gitSHA("."),
+		}
+	}
+
+	for _, executable := range testedExecutables {
+		if executable.name != name {
+			continue
+		}
+
+		gopathEnv := executable.gopathEnv
+		if version != "" {
+			gopathEnv += "_" + strings.ToUpper(version)
+		}
+
+		return ArtifactSource{
+			ImportPath: executable.importPath,
+			BuildFlags: executable.args,
+			GitSHA:     gitSHA(sourceDir(os.Getenv(gopathEnv), executable.importPath)),
+		}
+	}
+
+	return ArtifactSource{}
+}
+
+func lifecycleSource() ArtifactSource {
+	importPath := "github.com/cloudfoundry-incubator/buildpack_app_lifecycle"
+
+	return ArtifactSource{
+		ImportPath: importPath,
+		BuildFlags: []string{"-race"},
+		GitSHA:     gitSHA(sourceDir(os.Getenv("BUILDPACK_APP_LIFECYCLE_GOPATH"), importPath)),
+	}
+}
+
+// fixtureSource is where CompileFixtures built the fixture from.
+func fixtureSource(name string) ArtifactSource {
+	return ArtifactSource{
+		ImportPath: "github.com/cloudfoundry-incubator/inigo/fixtures/" + strings.Replace(name, "-", "_", -1),
+		BuildFlags: []string{"-tags", "netgo"},
+		GitSHA:     gitSHA("."),
+	}
+}
+
+// sourceDir is the first directory on gopath that has importPath.
+func sourceDir(gopath string, importPath string) string {
+	for _, entry := range filepath.SplitList(gopath) {
+		dir := filepath.Join(entry, "src", filepath.FromSlash(importPath))
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+// gitSHA is the commit checked out in dir, or nothing if it is not in a
+// git repo. Inigo's own artifacts are from the working directory's.
+func gitSHA(dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,168 @@
+package world
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/executor"
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/cloudfoundry/gunk/diegonats"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+type BootstrapConfig struct {
+	Maker ComponentMaker
+
+	// the plumbing components to start, by name, e.g. "garden-linux" for a
+	// suite that only talks to garden; all of PlumbingComponents if empty.
+	// Bootstrap always starts all of them.
+	Plumbing []string
+
+	GardenArgs     []string
+	ExecutorArgs   []string
+	RepArgs        []string
+	ConvergerArgs  []string
+	AuctioneerArgs []string
+}
+
+// PlumbingComponents are what BootstrapPlumbing can start, by name.
+var PlumbingComponents = []string{"etcd", "gnatsd", "receptor", "garden-linux"}
+
+type Environment struct {
+	// every component started, by the name it runs under, so that they can
+	// be stopped in order with helpers.Teardown
+	Processes map[string]ifrit.Process
+
+	FileServerStaticDir string
+
+	// those of the components that were started
+	ReceptorClient receptor.Client
+	GardenClient   garden.Client
+	ExecutorClient executor.Client
+	NATSClient     diegonats.NATSClient
+}
+
+// Bootstrap brings up a complete Diego deployment: the plumbing (etcd, NATS,
+// garden, receptor) first, then a single cell and the brain on top of it.
+func Bootstrap(config BootstrapConfig) Environment {
+	maker := config.Maker
+
+	fileServer, fileServerStaticDir := maker.FileServer()
+
+	if lifecycle, ok := maker.Artifacts.Lifecycles[maker.Stack]; ok {
+		err := exec.Command("cp", "-a", lifecycle, filepath.Join(fileServerStaticDir, LifecycleFilename)).Run()
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	config.Plumbing = nil
+
+	runners := plumbing(config)
+	runners["file-server"] = fileServer
+	runners["executor"] = maker.Executor(config.ExecutorArgs...)
+	runners["rep"] = maker.Rep(config.RepArgs...)
+	runners["converger"] = maker.Converger(config.ConvergerArgs...)
+	runners["auctioneer"] = maker.Auctioneer(config.AuctioneerArgs...)
+	runners["router"] = maker.Router()
+	runners["route-emitter"] = maker.RouteEmitter()
+
+	processes := invokeInOrder(DefaultTopology, runners)
+
+	environment := clients(maker, PlumbingComponents)
+	environment.Processes = processes
+	environment.FileServerStaticDir = fileServerStaticDir
+	environment.ExecutorClient = maker.ExecutorClient()
+
+	return environment
+}
+
+// BootstrapPlumbing brings up only the plumbing Bootstrap starts a
+// deployment on, or the part of it named by config.Plumbing, for suites
+// whose specs start the cell and brain components themselves. Of the
+// per-component args, only GardenArgs apply.
+func BootstrapPlumbing(config BootstrapConfig) Environment {
+	processes := invokeInOrder(DefaultTopology, plumbing(config))
+
+	environment := clients(config.Maker, plumbingNames(config))
+	environment.Processes = processes
+
+	return environment
+}
+
+func plumbing(config BootstrapConfig) map[string]ifrit.Runner {
+	maker := config.Maker
+
+	runners := map[string]ifrit.Runner{}
+	for _, name := range plumbingNames(config) {
+		switch name {
+		case "etcd":
+			runners[name] = maker.Etcd()
+		case "gnatsd":
+			runners[name] = maker.NATS()
+		case "receptor":
+			runners[name] = maker.Receptor()
+		case "garden-linux":
+			runners[name] = maker.GardenLinux(config.GardenArgs...)
+		default:
+			Ω(PlumbingComponents).Should(ContainElement(name), "no plumbing component named %s", name)
+		}
+	}
+
+	return runners
+}
+
+// invokeInOrder starts the runners, by name, in the topology's startup
+// waves, each wave together, and waits for each wave to be ready before
+// starting the next.
+func invokeInOrder(topology Topology, runners map[string]ifrit.Runner) map[string]ifrit.Process {
+	names := []string{}
+	for name := range runners {
+		names = append(names, name)
+	}
+
+	processes := map[string]ifrit.Process{}
+	for _, wave := range topology.StartupWaves(names) {
+		for _, name := range wave {
+			processes[name] = ifrit.Background(runners[name])
+		}
+
+		for _, name := range wave {
+			select {
+			case <-processes[name].Ready():
+			case err := <-processes[name].Wait():
+				// never closed, so always fails
+				Ω(processes[name].Ready()).Should(BeClosed(), "%s exited before it was ready: %v", name, err)
+			}
+		}
+	}
+
+	return processes
+}
+
+func plumbingNames(config BootstrapConfig) []string {
+	if len(config.Plumbing) == 0 {
+		return PlumbingComponents
+	}
+
+	return config.Plumbing
+}
+
+// clients only has those of the plumbing started, as NATSClient connects
+// straight away
+func clients(maker ComponentMaker, started []string) Environment {
+	environment := Environment{}
+
+	for _, name := range started {
+		switch name {
+		case "gnatsd":
+			environment.NATSClient = maker.NATSClient()
+		case "receptor":
+			environment.ReceptorClient = maker.ReceptorClient()
+		case "garden-linux":
+			environment.GardenClient = maker.GardenClient()
+		}
+	}
+
+	return environment
+}
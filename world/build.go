@@ -0,0 +1,180 @@
+package world
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+type testedExecutable struct {
+	name       string
+	gopathEnv  string
+	importPath string
+	args       []string
+}
+
+var testedExecutables = []testedExecutable{
+	{"garden-linux", "GARDEN_LINUX_GOPATH", "github.com/cloudfoundry-incubator/garden-linux", []string{"-race", "-a", "-tags", "daemon"}},
+	{"auctioneer", "AUCTIONEER_GOPATH", "github.com/cloudfoundry-incubator/auctioneer/cmd/auctioneer", []string{"-race"}},
+	{"exec", "EXECUTOR_GOPATH", "github.com/cloudfoundry-incubator/executor/cmd/executor", []string{"-race"}},
+	{"converger", "CONVERGER_GOPATH", "github.com/cloudfoundry-incubator/converger/cmd/converger", []string{"-race"}},
+	{"rep", "REP_GOPATH", "github.com/cloudfoundry-incubator/rep/cmd/rep", []string{"-race"}},
+	{"stager", "STAGER_GOPATH", "github.com/cloudfoundry-incubator/stager/cmd/stager", []string{"-race"}},
+	{"receptor", "RECEPTOR_GOPATH", "github.com/cloudfoundry-incubator/receptor/cmd/receptor", []string{"-race"}},
+	{"nsync-listener", "NSYNC_GOPATH", "github.com/cloudfoundry-incubator/nsync/cmd/nsync-listener", []string{"-race"}},
+	{"nsync-bulker", "NSYNC_GOPATH", "github.com/cloudfoundry-incubator/nsync/cmd/nsync-bulker", []string{"-race"}},
+	{"file-server", "FILE_SERVER_GOPATH", "github.com/cloudfoundry-incubator/file-server/cmd/file-server", []string{"-race"}},
+	{"route-emitter", "ROUTE_EMITTER_GOPATH", "github.com/cloudfoundry-incubator/route-emitter/cmd/route-emitter", []string{"-race"}},
+	{"tps", "TPS_GOPATH", "github.com/cloudfoundry-incubator/tps/cmd/tps", []string{"-race"}},
+	{"router", "ROUTER_GOPATH", "github.com/cloudfoundry/gorouter", []string{"-race"}},
+}
+
+// optionalExecutables are only run by some suites, so only those suites
+// build them, and only they need their GOPATHs set
+var optionalExecutables = []testedExecutable{
+	{"tps-watcher", "TPS_GOPATH", "github.com/cloudfoundry-incubator/tps/cmd/tps-watcher", []string{"-race"}},
+	{"cc-uploader", "CC_UPLOADER_GOPATH", "github.com/cloudfoundry-incubator/cc-uploader/cmd/cc-uploader", []string{"-race"}},
+}
+
+// CompileTestedExecutables builds every component the suites run, and the
+// named optional ones, e.g. "cc-uploader", that the calling suite runs too.
+func CompileTestedExecutables(optional ...string) BuiltExecutables {
+	builtExecutables := BuiltExecutables{}
+
+	executables := testedExecutables
+	for _, name := range optional {
+		executables = append(executables, optionalExecutable(name))
+	}
+
+	for _, executable := range executables {
+		var err error
+
+		builtExecutables[executable.name], err = gexec.BuildIn(os.Getenv(executable.gopathEnv), executable.importPath, executable.args...)
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	announcementServer, err := gexec.Build("github.com/cloudfoundry-incubator/inigo/cmd/announcement-server", "-race")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	builtExecutables["announcement-server"] = announcementServer
+
+	return builtExecutables
+}
+
+func optionalExecutable(name string) testedExecutable {
+	for _, executable := range optionalExecutables {
+		if executable.name == name {
+			return executable
+		}
+	}
+
+	ginkgo.Fail("no optional executable named " + name)
+	return testedExecutable{}
+}
+
+// CompileFixtures builds the fixture programs that are shipped into
+// containers; they are linked statically so that they run on any rootfs.
+func CompileFixtures() BuiltExecutables {
+	websocketEcho, err := gexec.Build("github.com/cloudfoundry-incubator/inigo/fixtures/websocket_echo", "-tags", "netgo")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	grpcEcho, err := gexec.Build("github.com/cloudfoundry-incubator/inigo/fixtures/grpc_echo", "-tags", "netgo")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return BuiltExecutables{
+		"websocket-echo": websocketEcho,
+		"grpc-echo":      grpcEcho,
+	}
+}
+
+// CompileVersionedExecutables builds the executables for an alternate
+// version of the components, e.g. the last release for upgrade tests.
+//
+// Each component is built from the GOPATH named by its usual env var
+// suffixed with the upper-cased version, e.g. REP_GOPATH_V0 for "v0".
+// Components with no such GOPATH are skipped, and fall back to the
+// current version when selected via ComponentMaker.WithVersion.
+func CompileVersionedExecutables(version string) BuiltExecutables {
+	builtExecutables := BuiltExecutables{}
+
+	for _, executable := range append(testedExecutables, optionalExecutables...) {
+		gopath := os.Getenv(executable.gopathEnv + "_" + strings.ToUpper(version))
+		if gopath == "" {
+			continue
+		}
+
+		var err error
+
+		builtExecutables[executable.name], err = gexec.BuildIn(gopath, executable.importPath, executable.args...)
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	return builtExecutables
+}
+
+// CompileComponentVersions builds every version named in the
+// comma-separated $COMPONENT_VERSIONS, e.g. "v0", and fetches every one
+// pinned in $PINNED_COMPONENT_VERSIONS; see FetchPinnedReleases. Of a
+// version that is both, the components that have a GOPATH are built.
+func CompileComponentVersions() map[string]BuiltExecutables {
+	versions := FetchPinnedReleases()
+
+	for _, version := range strings.Split(os.Getenv("COMPONENT_VERSIONS"), ",") {
+		version = strings.TrimSpace(version)
+		if version == "" {
+			continue
+		}
+
+		if _, found := versions[version]; !found {
+			versions[version] = BuiltExecutables{}
+		}
+
+		for name, path := range CompileVersionedExecutables(version) {
+			versions[version][name] = path
+		}
+	}
+
+	return versions
+}
+
+func BuildLifecycles(stack string) BuiltLifecycles {
+	builtLifecycles := BuiltLifecycles{}
+
+	builderPath, err := gexec.BuildIn(os.Getenv("BUILDPACK_APP_LIFECYCLE_GOPATH"), "github.com/cloudfoundry-incubator/buildpack_app_lifecycle/builder", "-race")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	healthcheckPath, err := gexec.BuildIn(os.Getenv("BUILDPACK_APP_LIFECYCLE_GOPATH"), "github.com/cloudfoundry-incubator/buildpack_app_lifecycle/healthcheck", "-race")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	launcherPath, err := gexec.BuildIn(os.Getenv("BUILDPACK_APP_LIFECYCLE_GOPATH"), "github.com/cloudfoundry-incubator/buildpack_app_lifecycle/launcher", "-race")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	lifecycleDir, err := ioutil.TempDir("", "lifecycle-dir")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = os.Rename(builderPath, filepath.Join(lifecycleDir, "builder"))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = os.Rename(healthcheckPath, filepath.Join(lifecycleDir, "healthcheck"))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = os.Rename(launcherPath, filepath.Join(lifecycleDir, "launcher"))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	cmd := exec.Command("tar", "-czf", "lifecycle.tar.gz", "builder", "launcher", "healthcheck")
+	cmd.Stderr = ginkgo.GinkgoWriter
+	cmd.Stdout = ginkgo.GinkgoWriter
+	cmd.Dir = lifecycleDir
+	err = cmd.Run()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	builtLifecycles[stack] = filepath.Join(lifecycleDir, "lifecycle.tar.gz")
+
+	return builtLifecycles
+}
@@ -0,0 +1,21 @@
+package world
+
+// cellPortStride is how far apart the ports of a spec's extra cells are;
+// the suite's executor and rep ports, which every node has its own of, are
+// the first.
+const cellPortStride = 100
+
+// Cell returns a ComponentMaker for the index'th extra cell of a spec, from
+// 0, whose rep registers as id and whose executor owns its containers as
+// id+"-executor". They listen on the suite's executor and rep ports offset
+// by the index, so that no two cells of a spec, on any node, share a port.
+func (maker ComponentMaker) Cell(id string, index int) ComponentMaker {
+	offset := cellPortStride * (index + 1)
+
+	maker.RepCellID = id
+	maker.ContainerOwnerName = id + "-executor"
+	maker.Addresses.Executor = addressWithPortOffset(maker.Addresses.Executor, offset)
+	maker.Addresses.Rep = addressWithPortOffset(maker.Addresses.Rep, offset)
+
+	return maker
+}
@@ -5,9 +5,11 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -16,10 +18,14 @@ import (
 	"github.com/cloudfoundry-incubator/executor"
 	executorclient "github.com/cloudfoundry-incubator/executor/http/client"
 	"github.com/cloudfoundry-incubator/garden"
-	gardenrunner "github.com/cloudfoundry-incubator/garden-linux/integration/runner"
 	gardenclient "github.com/cloudfoundry-incubator/garden/client"
 	gardenconnection "github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/inigo/fake_blobstore"
 	"github.com/cloudfoundry-incubator/inigo/fake_cc"
+	"github.com/cloudfoundry-incubator/inigo/fake_garden_capacity"
+	"github.com/cloudfoundry-incubator/inigo/fake_metron"
+	"github.com/cloudfoundry-incubator/inigo/file_server_proxy"
+	"github.com/cloudfoundry-incubator/inigo/helpers/tlsfixtures"
 	"github.com/cloudfoundry-incubator/receptor"
 	gorouterconfig "github.com/cloudfoundry/gorouter/config"
 	"github.com/cloudfoundry/gunk/diegonats"
@@ -27,6 +33,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
 )
 
 type BuiltExecutables map[string]string
@@ -34,9 +41,30 @@ type BuiltLifecycles map[string]string
 
 const LifecycleFilename = "some-lifecycle.tar.gz"
 
+// the largest task result file the executor accepts unless told otherwise
+const DefaultMaxResultFileSize = 10 * 1024
+
+const (
+	RouterStatusUsername = "router-status-user"
+	RouterStatusPassword = "router-status-password"
+)
+
 type BuiltArtifacts struct {
 	Executables BuiltExecutables
 	Lifecycles  BuiltLifecycles
+
+	// alternate versions of the executables, keyed by version name
+	Versions map[string]BuiltExecutables `json:",omitempty"`
+
+	// programs for running inside containers, see CompileFixtures
+	Fixtures BuiltExecutables `json:",omitempty"`
+
+	// the file-server the first node runs for all of them, if any
+	SharedFileServer *SharedFileServer `json:",omitempty"`
+
+	// where ImportArtifacts extracted them, if they were imported; see
+	// RemoveImported
+	ImportedDir string `json:",omitempty"`
 }
 
 type ComponentAddresses struct {
@@ -46,14 +74,37 @@ type ComponentAddresses struct {
 	Executor            string
 	Rep                 string
 	FakeCC              string
+	FakeBlobstore       string
+	FakeMetron          string
+	AnnouncementServer  string
+	CCUploader          string
+	FakeGardenCapacity  string
 	FileServer          string
+	FileServerBackend   string
 	Router              string
+	RouterStatus        string
+	RouterTLS           string
 	TPS                 string
 	GardenLinux         string
 	Receptor            string
 	ReceptorTaskHandler string
 	Stager              string
 	Auctioneer          string
+
+	// where each of the DebuggableComponents serves pprof, by name, when
+	// ComponentMaker.DebugServers is set
+	Debug map[string]string
+}
+
+// DebuggableComponents are the components that can serve their debug
+// endpoints; see ComponentMaker.WithDebugServers.
+var DebuggableComponents = []string{
+	"executor",
+	"rep",
+	"converger",
+	"auctioneer",
+	"route-emitter",
+	"receptor",
 }
 
 type ComponentMaker struct {
@@ -67,37 +118,349 @@ type ComponentMaker struct {
 	GardenBinPath    string
 	GardenRootFSPath string
 	GardenGraphPath  string
+
+	// if set, garden keeps its containers here and snapshots them on
+	// shutdown, so that a restarted garden restores them
+	GardenDepotPath     string
+	GardenSnapshotsPath string
+
+	// if nonzero, garden deletes image layers no container uses once its
+	// graph grows past this many MB; see WithGardenGraphCleanup
+	GardenGraphCleanupThresholdMB int
+
+	// the docker registry garden pulls images from, and the credentials
+	// its rootfs URLs carry; see WithDockerRegistry
+	DockerRegistry         string
+	DockerRegistryUsername string
+	DockerRegistryPassword string
+
+	// if set, garden restricts where containers may connect to; see
+	// WithEgressPolicy
+	GardenEgressPolicy *EgressPolicy
+
+	// if set, garden tags its containers with this and hands out their IPs
+	// from this pool; see withSeparateGarden
+	GardenTag         string
+	GardenNetworkPool string
+
+	TempDirs *TempDirs
+
+	// extra environment for every component started by this maker, on top
+	// of the test process's own
+	Env []string
+
+	// if set, FakeCC serves HTTPS and the components talking to it are
+	// configured to trust it
+	FakeCCTLS *FakeCCTLSConfig
+
+	// if nonzero, overrides the executor's task result file size limit
+	MaxResultFileSize int
+
+	// if set, the router also terminates TLS on Addresses.RouterTLS with
+	// the server certificate
+	RouterTLS *tlsfixtures.Credentials
+
+	// if set, the file-server is fronted by a proxy on Addresses.FileServer
+	// that serves HTTPS with the server certificate
+	FileServerTLS *tlsfixtures.Credentials
+
+	// if set, overrides how the auctioneer scores cells; see AuctionWeights
+	AuctionWeights *AuctionWeights
+
+	// if set, the executor talks to Garden through a proxy on
+	// Addresses.FakeGardenCapacity that reports this capacity instead of
+	// the machine's; see FakeGardenCapacity
+	GardenCapacity *garden.Capacity
+
+	// if set, the capacity the executor advertises, in MB, instead of what
+	// Garden reports; either a number, which may exceed the machine's, or
+	// AutoCapacity
+	ExecutorMemoryMB string
+	ExecutorDiskMB   string
+
+	// if nonzero, overrides how many inodes each of the executor's
+	// containers may use
+	ContainerInodeLimit int
+
+	// if set, the executor tags its containers with this owner instead of
+	// its default, e.g. one per spec so that leaked containers can be told
+	// apart
+	ContainerOwnerName string
+
+	// if set, the ID the rep registers its cell with; see Cell
+	RepCellID string
+
+	// if set, the executor runs privileged Tasks and LRPs in privileged
+	// garden containers; otherwise it refuses them, and every container is
+	// unprivileged, i.e. has its root user mapped to a nobody on the host
+	AllowPrivilegedContainers bool
+
+	// if set, records how long each component takes to start
+	Timings *Timings
+
+	// if set, keeps every component's output in files as well
+	LogFiles *LogFiles
+
+	// if set, prints components' output as it happens
+	LiveLogs *LiveLogs
+
+	// if nonzero, overrides how often the route-emitter re-emits every route
+	RouteEmitterSyncInterval time.Duration
+
+	// if set, the receptor requires these credentials, and everything
+	// talking to it presents them
+	ReceptorUsername string
+	ReceptorPassword string
+
+	// if set, the receptor answers CORS preflights and tells browsers that
+	// any origin may call it
+	ReceptorCORS bool
+
+	// if set, each of the DebuggableComponents serves its debug endpoints
+	// on its Addresses.Debug address
+	DebugServers bool
+}
+
+type FakeCCTLSConfig struct {
+	Credentials tlsfixtures.Credentials
+
+	// require the components to present the client certificate
+	RequireClientCert bool
+}
+
+// WithVersion returns a ComponentMaker whose runners use the given version
+// of each component that was built for it, and the current version of the
+// rest.
+func (maker ComponentMaker) WithVersion(version string) ComponentMaker {
+	versioned, found := maker.Artifacts.Versions[version]
+	Ω(found).Should(BeTrue(), "no executables built for version %q", version)
+
+	executables := BuiltExecutables{}
+	for name, path := range maker.Artifacts.Executables {
+		executables[name] = path
+	}
+
+	for name, path := range versioned {
+		executables[name] = path
+	}
+
+	maker.Artifacts.Executables = executables
+
+	return maker
+}
+
+// WithComponentVersion returns a ComponentMaker whose runners use the given
+// version of only the named components, e.g. an old rep alongside the
+// current auctioneer, and the current version of the rest.
+func (maker ComponentMaker) WithComponentVersion(version string, components ...string) ComponentMaker {
+	versioned, found := maker.Artifacts.Versions[version]
+	Ω(found).Should(BeTrue(), "no executables built for version %q", version)
+
+	executables := BuiltExecutables{}
+	for name, path := range maker.Artifacts.Executables {
+		executables[name] = path
+	}
+
+	for _, name := range components {
+		path, found := versioned[name]
+		Ω(found).Should(BeTrue(), "no %s built for version %q", name, version)
+
+		executables[name] = path
+	}
+
+	maker.Artifacts.Executables = executables
+
+	return maker
+}
+
+// WithEnv returns a ComponentMaker whose runners start their components
+// with the given additional environment variables, e.g. "GODEBUG=gctrace=1".
+//
+// Garden is started by its own runner and does not get them.
+func (maker ComponentMaker) WithEnv(env ...string) ComponentMaker {
+	maker.Env = append(append([]string{}, maker.Env...), env...)
+	return maker
+}
+
+// WithFakeCCTLS returns a ComponentMaker whose FakeCC serves HTTPS with the
+// given credentials, and whose stager, TPS, TPS watcher, and file server are
+// pointed at it with the CA (and, if requireClientCert is set, the client
+// certificate).
+func (maker ComponentMaker) WithFakeCCTLS(credentials tlsfixtures.Credentials, requireClientCert bool) ComponentMaker {
+	maker.FakeCCTLS = &FakeCCTLSConfig{
+		Credentials:       credentials,
+		RequireClientCert: requireClientCert,
+	}
+
+	return maker
+}
+
+// WithMaxResultFileSize returns a ComponentMaker whose executor rejects task
+// result files larger than the given number of bytes.
+func (maker ComponentMaker) WithMaxResultFileSize(bytes int) ComponentMaker {
+	maker.MaxResultFileSize = bytes
+	return maker
+}
+
+// WithGardenCapacity returns a ComponentMaker whose executor sees Garden as
+// having the given capacity. FakeGardenCapacity must be running for it to
+// reach Garden at all.
+func (maker ComponentMaker) WithGardenCapacity(capacity garden.Capacity) ComponentMaker {
+	maker.GardenCapacity = &capacity
+	return maker
+}
+
+// WithMaxContainers returns a ComponentMaker whose executor allocates at most
+// maxContainers containers, as that is all the Garden it sees has room for.
+// It changes the capacity set by WithGardenCapacity, so that must come first.
+func (maker ComponentMaker) WithMaxContainers(maxContainers int) ComponentMaker {
+	Ω(maker.GardenCapacity).ShouldNot(BeNil(), "no capacity to limit; see WithGardenCapacity")
+
+	capacity := *maker.GardenCapacity
+	capacity.MaxContainers = uint64(maxContainers)
+
+	maker.GardenCapacity = &capacity
+	return maker
+}
+
+// WithContainerInodeLimit returns a ComponentMaker whose executor limits each
+// container to the given number of inodes.
+func (maker ComponentMaker) WithContainerInodeLimit(inodes int) ComponentMaker {
+	maker.ContainerInodeLimit = inodes
+	return maker
+}
+
+// AutoCapacity makes the executor take a resource's capacity from Garden.
+const AutoCapacity = "auto"
+
+// WithExecutorCapacity returns a ComponentMaker whose executor advertises
+// the given memory and disk, in MB or AutoCapacity, e.g. to overcommit.
+func (maker ComponentMaker) WithExecutorCapacity(memoryMB, diskMB string) ComponentMaker {
+	maker.ExecutorMemoryMB = memoryMB
+	maker.ExecutorDiskMB = diskMB
+	return maker
+}
+
+// WithContainerOwner returns a ComponentMaker whose executor owns its
+// containers under the given name.
+func (maker ComponentMaker) WithContainerOwner(ownerName string) ComponentMaker {
+	maker.ContainerOwnerName = ownerName
+	return maker
+}
+
+// AuctionWeights are how the auctioneer scores cells, each between 0 and 1:
+// the higher BinPackFirstFit, the more it packs instances onto the first
+// cell with room instead of spreading them, and the higher
+// StartingContainer, the more it avoids cells that are busy starting
+// containers.
+type AuctionWeights struct {
+	BinPackFirstFit   float64
+	StartingContainer float64
+}
+
+// WithAuctionWeights returns a ComponentMaker whose auctioneer scores cells
+// with the given weights, zeroes included.
+func (maker ComponentMaker) WithAuctionWeights(binPackFirstFitWeight, startingContainerWeight float64) ComponentMaker {
+	maker.AuctionWeights = &AuctionWeights{
+		BinPackFirstFit:   binPackFirstFitWeight,
+		StartingContainer: startingContainerWeight,
+	}
+
+	return maker
+}
+
+// WithRouterTLS returns a ComponentMaker whose router also serves HTTPS,
+// with freshly generated credentials valid for 127.0.0.1.
+func (maker ComponentMaker) WithRouterTLS() ComponentMaker {
+	credentials := tlsfixtures.Generate(maker.TempDirs.New("router-tls"))
+	maker.RouterTLS = &credentials
+	return maker
+}
+
+// WithFileServerTLS returns a ComponentMaker whose file-server is served
+// over HTTPS, with a certificate signed by a throwaway CA.
+func (maker ComponentMaker) WithFileServerTLS() ComponentMaker {
+	credentials := tlsfixtures.Generate(maker.TempDirs.New("file-server-tls"))
+	maker.FileServerTLS = &credentials
+	return maker
+}
+
+// WithPrivilegedContainers returns a ComponentMaker whose executor allows
+// privileged containers.
+func (maker ComponentMaker) WithPrivilegedContainers() ComponentMaker {
+	maker.AllowPrivilegedContainers = true
+	return maker
+}
+
+// WithReceptorAuth returns a ComponentMaker whose receptor requires basic
+// auth with the given credentials, and whose clients and components use them.
+func (maker ComponentMaker) WithReceptorAuth(username, password string) ComponentMaker {
+	maker.ReceptorUsername = username
+	maker.ReceptorPassword = password
+	return maker
+}
+
+// WithReceptorCORS returns a ComponentMaker whose receptor can be called
+// from browsers on other origins, e.g. dashboards.
+func (maker ComponentMaker) WithReceptorCORS() ComponentMaker {
+	maker.ReceptorCORS = true
+	return maker
+}
+
+// WithDebugServers returns a ComponentMaker whose DebuggableComponents serve
+// their debug endpoints, e.g. for ScrapeDebugEndpoint.
+func (maker ComponentMaker) WithDebugServers() ComponentMaker {
+	maker.DebugServers = true
+	return maker
+}
+
+func (maker ComponentMaker) debugFlags(component string) []string {
+	if !maker.DebugServers || maker.Addresses.Debug[component] == "" {
+		return nil
+	}
+
+	return []string{"-debugAddr", maker.Addresses.Debug[component]}
+}
+
+func (maker ComponentMaker) command(path string, args ...string) *exec.Cmd {
+	cmd := exec.Command(path, args...)
+
+	if len(maker.Env) > 0 {
+		cmd.Env = append(os.Environ(), maker.Env...)
+	}
+
+	return cmd
 }
 
 func (maker ComponentMaker) NATS(argv ...string) ifrit.Runner {
 	host, port, err := net.SplitHostPort(maker.Addresses.NATS)
 	Ω(err).ShouldNot(HaveOccurred())
 
-	return ginkgomon.New(ginkgomon.Config{
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "gnatsd",
 		AnsiColorCode:     "30m",
 		StartCheck:        "gnatsd is ready",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			"gnatsd",
 			append([]string{
 				"--addr", host,
 				"--port", port,
 			}, argv...)...,
 		),
-	})
+	}))
 }
 
 func (maker ComponentMaker) Etcd(argv ...string) ifrit.Runner {
 	nodeName := fmt.Sprintf("etcd_%d", ginkgo.GinkgoParallelNode())
-	dataDir := path.Join(os.TempDir(), nodeName)
+	dataDir := maker.TempDirs.New("etcd")
 
-	return ginkgomon.New(ginkgomon.Config{
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "etcd",
 		AnsiColorCode:     "31m",
 		StartCheck:        "etcdserver: published",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			"etcd",
 			append([]string{
 				"--name", nodeName,
@@ -114,39 +477,115 @@ func (maker ComponentMaker) Etcd(argv ...string) ifrit.Runner {
 			err := os.RemoveAll(dataDir)
 			Ω(err).ShouldNot(HaveOccurred())
 		},
-	})
+	}))
+}
+
+func (maker ComponentMaker) GardenLinux(argv ...string) ifrit.Runner {
+	if maker.GardenEgressPolicy != nil {
+		argv = append(maker.GardenEgressPolicy.gardenFlags(), argv...)
+	}
+
+	if maker.GardenGraphCleanupThresholdMB != 0 {
+		argv = append([]string{"-graphCleanupThresholdMB", strconv.Itoa(maker.GardenGraphCleanupThresholdMB)}, argv...)
+	}
+
+	if maker.GardenTag != "" {
+		argv = append([]string{"-tag", maker.GardenTag}, argv...)
+	}
+
+	if maker.GardenNetworkPool != "" {
+		argv = append([]string{"-networkPool", maker.GardenNetworkPool}, argv...)
+	}
+
+	if maker.GardenSnapshotsPath != "" {
+		argv = append([]string{
+			"-depot", maker.GardenDepotPath,
+			"-snapshots", maker.GardenSnapshotsPath,
+		}, argv...)
+	}
+
+	return maker.loggedGardenLinux(argv...)
 }
 
-func (maker ComponentMaker) GardenLinux(argv ...string) *gardenrunner.Runner {
-	return gardenrunner.New(
-		"tcp",
-		maker.Addresses.GardenLinux,
-		maker.Artifacts.Executables["garden-linux"],
-		maker.GardenBinPath,
-		maker.GardenRootFSPath,
-		maker.GardenGraphPath,
-		argv...,
-	)
+// WithSeparateNATS points the maker at a gnatsd of its own, on the suite's
+// NATS port plus portOffset, e.g. so that it can be taken down without
+// taking the suite's with it.
+func (maker ComponentMaker) WithSeparateNATS(portOffset int) ComponentMaker {
+	maker.Addresses.NATS = addressWithPortOffset(maker.Addresses.NATS, portOffset)
+	return maker
 }
 
-func (maker ComponentMaker) Executor(argv ...string) *ginkgomon.Runner {
-	tmpDir, err := ioutil.TempDir(os.TempDir(), "executor")
+// WithSeparateReceptor returns a ComponentMaker whose receptor listens, and
+// whose receptor clients connect, on ports offset from the usual ones, so
+// that it can run alongside the suite's receptor against the same etcd.
+func (maker ComponentMaker) WithSeparateReceptor(portOffset int) ComponentMaker {
+	maker.Addresses.Receptor = addressWithPortOffset(maker.Addresses.Receptor, portOffset)
+	maker.Addresses.ReceptorTaskHandler = addressWithPortOffset(maker.Addresses.ReceptorTaskHandler, portOffset)
+	return maker
+}
+
+func addressWithPortOffset(address string, portOffset int) string {
+	host, port, err := net.SplitHostPort(address)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	portInt, err := strconv.Atoi(port)
 	Ω(err).ShouldNot(HaveOccurred())
 
+	return net.JoinHostPort(host, strconv.Itoa(portInt+portOffset))
+}
+
+func (maker ComponentMaker) Executor(argv ...string) *TimedRunner {
+	argv = append(maker.debugFlags("executor"), argv...)
+
+	tmpDir := maker.TempDirs.New("executor")
+
 	cachePath := path.Join(tmpDir, "cache")
 
-	return ginkgomon.New(ginkgomon.Config{
+	if maker.MaxResultFileSize != 0 {
+		argv = append([]string{"-maxResultFileSize", strconv.Itoa(maker.MaxResultFileSize)}, argv...)
+	}
+
+	gardenAddr := maker.Addresses.GardenLinux
+	if maker.GardenCapacity != nil {
+		gardenAddr = maker.Addresses.FakeGardenCapacity
+	}
+
+	if maker.ExecutorMemoryMB != "" {
+		argv = append([]string{"-memoryMB", maker.ExecutorMemoryMB}, argv...)
+	}
+
+	if maker.ExecutorDiskMB != "" {
+		argv = append([]string{"-diskMB", maker.ExecutorDiskMB}, argv...)
+	}
+
+	if maker.ContainerOwnerName != "" {
+		argv = append([]string{"-containerOwnerName", maker.ContainerOwnerName}, argv...)
+	}
+
+	if maker.ContainerInodeLimit != 0 {
+		argv = append([]string{"-containerInodeLimit", strconv.Itoa(maker.ContainerInodeLimit)}, argv...)
+	}
+
+	if maker.AllowPrivilegedContainers {
+		argv = append([]string{"-allowPrivileged"}, argv...)
+	}
+
+	if maker.Addresses.FakeMetron != "" {
+		argv = append([]string{"-dropsondeDestination", maker.Addresses.FakeMetron}, argv...)
+	}
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:          "executor",
 		AnsiColorCode: "91m",
 		StartCheck:    "executor.started",
 		// executor may destroy containers on start, which can take a bit
 		StartCheckTimeout: 30 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["exec"],
 			append([]string{
 				"-listenAddr", maker.Addresses.Executor,
 				"-gardenNetwork", "tcp",
-				"-gardenAddr", maker.Addresses.GardenLinux,
+				"-gardenAddr", gardenAddr,
 				"-containerMaxCpuShares", "1024",
 				"-cachePath", cachePath,
 				"-tempDir", tmpDir,
@@ -155,25 +594,27 @@ func (maker ComponentMaker) Executor(argv ...string) *ginkgomon.Runner {
 		Cleanup: func() {
 			os.RemoveAll(tmpDir)
 		},
-	})
+	}))
 }
 
-func (maker ComponentMaker) Rep(argv ...string) *ginkgomon.Runner {
-	return ginkgomon.New(ginkgomon.Config{
+func (maker ComponentMaker) Rep(argv ...string) *TimedRunner {
+	argv = append(maker.debugFlags("rep"), argv...)
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:          "rep",
 		AnsiColorCode: "92m",
 		StartCheck:    "rep.started",
 		// rep is not started until it can ping an executor; executor can take a
 		// bit to start, so account for it
 		StartCheckTimeout: 30 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["rep"],
 			append(
 				[]string{
 					"-stack", maker.Stack,
 					"-etcdCluster", "http://" + maker.Addresses.Etcd,
 					"-listenAddr", maker.Addresses.Rep,
-					"-cellID", "the-cell-id-" + strconv.Itoa(ginkgo.GinkgoParallelNode()),
+					"-cellID", maker.CellID(),
 					"-executorURL", "http://" + maker.Addresses.Executor,
 					"-heartbeatInterval", "1s",
 					"-pollingInterval", "1s",
@@ -183,33 +624,53 @@ func (maker ComponentMaker) Rep(argv ...string) *ginkgomon.Runner {
 				argv...,
 			)...,
 		),
-	})
+	}))
+}
+
+// CellID is the ID the rep registers with unless given -cellID.
+func (maker ComponentMaker) CellID() string {
+	if maker.RepCellID != "" {
+		return maker.RepCellID
+	}
+
+	return "the-cell-id-" + strconv.Itoa(ginkgo.GinkgoParallelNode())
 }
 
 func (maker ComponentMaker) Converger(argv ...string) ifrit.Runner {
-	return ginkgomon.New(ginkgomon.Config{
+	argv = append(maker.debugFlags("converger"), argv...)
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "converger",
 		AnsiColorCode:     "93m",
 		StartCheck:        "converger.started",
 		StartCheckTimeout: 5 * time.Second,
 
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["converger"],
 			append([]string{
 				"-etcdCluster", "http://" + maker.Addresses.Etcd,
 				"-heartbeatInterval", "1s",
 			}, argv...)...,
 		),
-	})
+	}))
 }
 
 func (maker ComponentMaker) Auctioneer(argv ...string) ifrit.Runner {
-	return ginkgomon.New(ginkgomon.Config{
+	argv = append(maker.debugFlags("auctioneer"), argv...)
+
+	if maker.AuctionWeights != nil {
+		argv = append([]string{
+			"-binPackFirstFitWeight", strconv.FormatFloat(maker.AuctionWeights.BinPackFirstFit, 'f', -1, 64),
+			"-startingContainerWeight", strconv.FormatFloat(maker.AuctionWeights.StartingContainer, 'f', -1, 64),
+		}, argv...)
+	}
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "auctioneer",
 		AnsiColorCode:     "94m",
 		StartCheck:        "auctioneer.started",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["auctioneer"],
 			append([]string{
 				"-etcdCluster", "http://" + maker.Addresses.Etcd,
@@ -217,90 +678,165 @@ func (maker ComponentMaker) Auctioneer(argv ...string) ifrit.Runner {
 				"-listenAddr", maker.Addresses.Auctioneer,
 			}, argv...)...,
 		),
-	})
+	}))
+}
+
+// WithRouteEmitterSyncInterval returns a ComponentMaker whose route-emitter
+// re-emits every route at the given interval. Make it long to show that
+// routes get through on events alone, or short to have missed events
+// repaired quickly.
+func (maker ComponentMaker) WithRouteEmitterSyncInterval(interval time.Duration) ComponentMaker {
+	maker.RouteEmitterSyncInterval = interval
+	return maker
 }
 
 func (maker ComponentMaker) RouteEmitter(argv ...string) ifrit.Runner {
-	return ginkgomon.New(ginkgomon.Config{
+	argv = append(maker.debugFlags("route-emitter"), argv...)
+
+	if maker.RouteEmitterSyncInterval != 0 {
+		argv = append([]string{"-syncInterval", maker.RouteEmitterSyncInterval.String()}, argv...)
+	}
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "route-emitter",
 		AnsiColorCode:     "95m",
 		StartCheck:        "route-emitter.started",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["route-emitter"],
 			append([]string{
 				"-etcdCluster", "http://" + maker.Addresses.Etcd,
 				"-natsAddresses", maker.Addresses.NATS,
-				"-diegoAPIURL", "http://" + maker.Addresses.Receptor,
+				"-diegoAPIURL", maker.receptorURL(),
 			}, argv...)...,
 		),
-	})
+	}))
 }
 
 func (maker ComponentMaker) TPS(argv ...string) ifrit.Runner {
-	return ginkgomon.New(ginkgomon.Config{
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "tps",
 		AnsiColorCode:     "96m",
 		StartCheck:        "tps.started",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["tps"],
 			append([]string{
-				"-diegoAPIURL", "http://" + maker.Addresses.Receptor,
+				"-diegoAPIURL", maker.receptorURL(),
 				"-listenAddr", maker.Addresses.TPS,
-				"-ccBaseURL", "http://" + maker.Addresses.FakeCC,
+				"-ccBaseURL", maker.fakeCCURL(),
 				"-ccUsername", fake_cc.CC_USERNAME,
 				"-ccPassword", fake_cc.CC_PASSWORD,
-			}, argv...)...,
+			}, append(maker.fakeCCTLSFlags(), argv...)...)...,
 		),
-	})
+	}))
+}
+
+// TPSWatcher runs the half of TPS that watches for crashed instances and
+// tells CC about them; TPS itself only serves instance status. Only suites
+// that pass "tps-watcher" to CompileTestedExecutables can run it.
+func (maker ComponentMaker) TPSWatcher(argv ...string) ifrit.Runner {
+	Ω(maker.Artifacts.Executables).Should(HaveKey("tps-watcher"), "this suite did not build the tps-watcher")
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
+		Name:              "tps-watcher",
+		AnsiColorCode:     "96m",
+		StartCheck:        "tps-watcher.started",
+		StartCheckTimeout: 5 * time.Second,
+		Command: maker.command(
+			maker.Artifacts.Executables["tps-watcher"],
+			append([]string{
+				"-diegoAPIURL", maker.receptorURL(),
+				"-ccBaseURL", maker.fakeCCURL(),
+				"-ccUsername", fake_cc.CC_USERNAME,
+				"-ccPassword", fake_cc.CC_PASSWORD,
+			}, append(maker.fakeCCTLSFlags(), argv...)...)...,
+		),
+	}))
 }
 
 func (maker ComponentMaker) NsyncListener(argv ...string) ifrit.Runner {
-	return ginkgomon.New(ginkgomon.Config{
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "nsync-listener",
 		AnsiColorCode:     "97m",
 		StartCheck:        "nsync.listener.started",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["nsync-listener"],
 			append([]string{
-				"-diegoAPIURL", "http://" + maker.Addresses.Receptor,
+				"-diegoAPIURL", maker.receptorURL(),
 				"-etcdCluster", "http://" + maker.Addresses.Etcd,
 				"-natsAddresses", maker.Addresses.NATS,
 				"-lifecycles", fmt.Sprintf(`{"%s": "%s"}`, maker.Stack, LifecycleFilename),
 				"-dockerLifecyclePath", "unused",
-				"-fileServerURL", "http://" + maker.Addresses.FileServer,
+				"-fileServerURL", maker.fileServerBaseURL(),
 			}, argv...)...,
 		),
-	})
+	}))
 }
 
 func (maker ComponentMaker) FileServer(argv ...string) (ifrit.Runner, string) {
-	servedFilesDir, err := ioutil.TempDir("", "file-server-files")
-	Ω(err).ShouldNot(HaveOccurred())
+	servedFilesDir := maker.TempDirs.New("file-server-files")
+
+	cleanup := func() {
+		err := os.RemoveAll(servedFilesDir)
+		Ω(err).ShouldNot(HaveOccurred())
+	}
 
-	return ginkgomon.New(ginkgomon.Config{
+	return maker.fileServer(servedFilesDir, cleanup, argv...), servedFilesDir
+}
+
+// fileServer serves servedFilesDir, running cleanup, if any, once it exits.
+func (maker ComponentMaker) fileServer(servedFilesDir string, cleanup func(), argv ...string) ifrit.Runner {
+	proxied := maker.FileServerTLS != nil
+
+	address := maker.Addresses.FileServer
+	if proxied {
+		address = maker.Addresses.FileServerBackend
+	}
+
+	fileServer := maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "file-server",
 		AnsiColorCode:     "90m",
 		StartCheck:        "file-server.ready",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["file-server"],
 			append([]string{
-				"-address", maker.Addresses.FileServer,
-				"-ccAddress", "http://" + maker.Addresses.FakeCC,
+				"-address", address,
+				"-ccAddress", maker.fakeCCURL(),
 				"-ccJobPollingInterval", "100ms",
 				"-ccUsername", fake_cc.CC_USERNAME,
 				"-ccPassword", fake_cc.CC_PASSWORD,
 				"-staticDirectory", servedFilesDir,
-			}, argv...)...,
+			}, append(maker.fakeCCTLSFlags(), argv...)...)...,
 		),
-		Cleanup: func() {
-			err := os.RemoveAll(servedFilesDir)
-			Ω(err).ShouldNot(HaveOccurred())
-		},
-	}), servedFilesDir
+		Cleanup: cleanup,
+	}))
+
+	if !proxied {
+		return fileServer
+	}
+
+	return grouper.NewOrdered(os.Interrupt, grouper.Members{
+		{"file-server", fileServer},
+		{"file-server-proxy", file_server_proxy.New(maker.Addresses.FileServer, address, maker.FileServerTLS.ServerConfig(false))},
+	})
+}
+
+// FileServerURL is where the file-server serves the named file from its
+// static directory.
+func (maker ComponentMaker) FileServerURL(name string) string {
+	return fmt.Sprintf("%s/v1/static/%s", maker.fileServerBaseURL(), name)
+}
+
+// fileServerBaseURL is over HTTPS if WithFileServerTLS was used.
+func (maker ComponentMaker) fileServerBaseURL() string {
+	if maker.FileServerTLS != nil {
+		return "https://" + maker.Addresses.FileServer
+	}
+
+	return "http://" + maker.Addresses.FileServer
 }
 
 func (maker ComponentMaker) Router() ifrit.Runner {
@@ -310,6 +846,12 @@ func (maker ComponentMaker) Router() ifrit.Runner {
 	routerPortInt, err := strconv.Atoi(routerPort)
 	Ω(err).ShouldNot(HaveOccurred())
 
+	_, routerStatusPort, err := net.SplitHostPort(maker.Addresses.RouterStatus)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	routerStatusPortInt, err := strconv.Atoi(routerStatusPort)
+	Ω(err).ShouldNot(HaveOccurred())
+
 	natsHost, natsPort, err := net.SplitHostPort(maker.Addresses.NATS)
 	Ω(err).ShouldNot(HaveOccurred())
 
@@ -319,6 +861,12 @@ func (maker ComponentMaker) Router() ifrit.Runner {
 	routerConfig := &gorouterconfig.Config{
 		Port: uint16(routerPortInt),
 
+		Status: gorouterconfig.StatusConfig{
+			Port: uint16(routerStatusPortInt),
+			User: RouterStatusUsername,
+			Pass: RouterStatusPassword,
+		},
+
 		PruneStaleDropletsIntervalInSeconds: 5,
 		DropletStaleThresholdInSeconds:      10,
 		PublishActiveAppsIntervalInSeconds:  0,
@@ -337,7 +885,20 @@ func (maker ComponentMaker) Router() ifrit.Runner {
 		},
 	}
 
-	configFile, err := ioutil.TempFile(os.TempDir(), "router-config")
+	if maker.RouterTLS != nil {
+		_, routerTLSPort, err := net.SplitHostPort(maker.Addresses.RouterTLS)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		routerTLSPortInt, err := strconv.Atoi(routerTLSPort)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		routerConfig.EnableSSL = true
+		routerConfig.SSLPort = uint16(routerTLSPortInt)
+		routerConfig.SSLCertPath = maker.RouterTLS.ServerCertFile
+		routerConfig.SSLKeyPath = maker.RouterTLS.ServerKeyFile
+	}
+
+	configFile, err := ioutil.TempFile(maker.TempDirs.New("router"), "router-config")
 	Ω(err).ShouldNot(HaveOccurred())
 
 	defer configFile.Close()
@@ -345,12 +906,12 @@ func (maker ComponentMaker) Router() ifrit.Runner {
 	err = candiedyaml.NewEncoder(configFile).Encode(routerConfig)
 	Ω(err).ShouldNot(HaveOccurred())
 
-	return ginkgomon.New(ginkgomon.Config{
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "router",
 		AnsiColorCode:     "32m",
 		StartCheck:        "router.started",
 		StartCheckTimeout: 5 * time.Second, // it waits 1 second before listening. yep.
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["router"],
 			"-c", configFile.Name(),
 		),
@@ -358,57 +919,182 @@ func (maker ComponentMaker) Router() ifrit.Runner {
 			err := os.Remove(configFile.Name())
 			Ω(err).ShouldNot(HaveOccurred())
 		},
-	})
+	}))
 }
 
 func (maker ComponentMaker) FakeCC() *fake_cc.FakeCC {
+	if maker.FakeCCTLS != nil {
+		return fake_cc.NewTLS(
+			maker.Addresses.FakeCC,
+			maker.FakeCCTLS.Credentials.ServerConfig(maker.FakeCCTLS.RequireClientCert),
+		)
+	}
+
 	return fake_cc.New(maker.Addresses.FakeCC)
 }
 
-func (maker ComponentMaker) Stager(argv ...string) ifrit.Runner {
-	return maker.StagerN(0, argv...)
+func (maker ComponentMaker) FakeBlobstore() *fake_blobstore.FakeBlobstore {
+	return fake_blobstore.New(maker.Addresses.FakeBlobstore)
 }
 
-func (maker ComponentMaker) StagerN(portOffset int, argv ...string) ifrit.Runner {
-	address := maker.Addresses.Stager
-	port, err := strconv.Atoi(strings.Split(address, ":")[1])
-	Ω(err).ShouldNot(HaveOccurred())
+// AnnouncementServer records what containers announce to it, persisting
+// them so that a restarted one (see Restart) still has them.
+func (maker ComponentMaker) AnnouncementServer() *TimedRunner {
+	stateFile := filepath.Join(maker.TempDirs.New("announcement-server"), "announcements.json")
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
+		Name:              "announcement-server",
+		AnsiColorCode:     "96m",
+		StartCheck:        "announcement-server.started",
+		StartCheckTimeout: 5 * time.Second,
+		Command: maker.command(
+			maker.Artifacts.Executables["announcement-server"],
+			"-listenAddr", maker.Addresses.AnnouncementServer,
+			"-stateFile", stateFile,
+		),
+	}))
+}
+
+// CCUploader relays uploads from containers to FakeCC, polling the jobs of
+// asynchronous droplet uploads until they finish. Only suites that pass
+// "cc-uploader" to CompileTestedExecutables can run it.
+func (maker ComponentMaker) CCUploader(argv ...string) *TimedRunner {
+	Ω(maker.Artifacts.Executables).Should(HaveKey("cc-uploader"), "this suite did not build the cc-uploader")
+
+	if maker.FakeCCTLS != nil {
+		argv = append([]string{"-skipCertVerify"}, argv...)
+	}
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
+		Name:              "cc-uploader",
+		AnsiColorCode:     "33m",
+		StartCheck:        "cc-uploader.ready",
+		StartCheckTimeout: 5 * time.Second,
+		Command: maker.command(
+			maker.Artifacts.Executables["cc-uploader"],
+			append([]string{
+				"-address", maker.Addresses.CCUploader,
+				"-ccJobPollingInterval", "100ms",
+			}, argv...)...,
+		),
+	}))
+}
+
+// FakeGardenCapacity is the proxy an executor made WithGardenCapacity
+// reaches Garden through.
+func (maker ComponentMaker) FakeGardenCapacity() *fake_garden_capacity.Proxy {
+	Ω(maker.GardenCapacity).ShouldNot(BeNil(), "no capacity to report; see WithGardenCapacity")
+
+	return fake_garden_capacity.New(maker.Addresses.FakeGardenCapacity, maker.Addresses.GardenLinux, *maker.GardenCapacity)
+}
+
+func (maker ComponentMaker) FakeMetron() *fake_metron.FakeMetron {
+	return fake_metron.New(maker.Addresses.FakeMetron)
+}
+
+func (maker ComponentMaker) fakeCCURL() string {
+	if maker.FakeCCTLS != nil {
+		return "https://" + maker.Addresses.FakeCC
+	}
+
+	return "http://" + maker.Addresses.FakeCC
+}
+
+func (maker ComponentMaker) fakeCCTLSFlags() []string {
+	if maker.FakeCCTLS == nil {
+		return []string{}
+	}
 
-	return ginkgomon.New(ginkgomon.Config{
+	flags := []string{"-ccCACert", maker.FakeCCTLS.Credentials.CACertFile}
+
+	if maker.FakeCCTLS.RequireClientCert {
+		flags = append(flags,
+			"-ccClientCert", maker.FakeCCTLS.Credentials.ClientCertFile,
+			"-ccClientKey", maker.FakeCCTLS.Credentials.ClientKeyFile,
+		)
+	}
+
+	return flags
+}
+
+func (maker ComponentMaker) Stager(argv ...string) *TimedRunner {
+	return maker.StagerN(0, argv...)
+}
+
+func (maker ComponentMaker) StagerN(portOffset int, argv ...string) *TimedRunner {
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "stager",
 		AnsiColorCode:     "94m",
 		StartCheck:        "Listening for staging requests!",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["stager"],
 			append([]string{
-				"-ccBaseURL", "http://" + maker.Addresses.FakeCC,
+				"-ccBaseURL", maker.fakeCCURL(),
 				"-ccUsername", fake_cc.CC_USERNAME,
 				"-ccPassword", fake_cc.CC_PASSWORD,
 				"-lifecycles", fmt.Sprintf(`{"buildpack/%s": "%s"}`, maker.Stack, LifecycleFilename),
-				"-diegoAPIURL", "http://" + maker.Addresses.Receptor,
-				"-stagerURL", fmt.Sprintf("http://127.0.0.1:%d", offsetPort(port, portOffset)),
-				"-fileServerURL", "http://" + maker.Addresses.FileServer,
-			}, argv...)...,
+				"-diegoAPIURL", maker.receptorURL(),
+				"-stagerURL", "http://" + maker.StagerAddressN(portOffset),
+				"-fileServerURL", maker.fileServerBaseURL(),
+			}, append(maker.fakeCCTLSFlags(), argv...)...)...,
 		),
-	})
+	}))
+}
+
+// StagerAddressN is the address the stager started by StagerN listens on.
+func (maker ComponentMaker) StagerAddressN(portOffset int) string {
+	port, err := strconv.Atoi(strings.Split(maker.Addresses.Stager, ":")[1])
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return fmt.Sprintf("127.0.0.1:%d", offsetPort(port, portOffset))
 }
 
 func (maker ComponentMaker) Receptor(argv ...string) ifrit.Runner {
-	return ginkgomon.New(ginkgomon.Config{
+	argv = append(maker.debugFlags("receptor"), argv...)
+
+	if maker.ReceptorCORS {
+		argv = append([]string{"-corsEnabled"}, argv...)
+	}
+
+	return maker.timed(ginkgomon.New(ginkgomon.Config{
 		Name:              "receptor",
 		AnsiColorCode:     "37m",
 		StartCheck:        "started",
 		StartCheckTimeout: 5 * time.Second,
-		Command: exec.Command(
+		Command: maker.command(
 			maker.Artifacts.Executables["receptor"],
 			append([]string{
 				"-address", maker.Addresses.Receptor,
 				"-taskHandlerAddress", maker.Addresses.ReceptorTaskHandler,
 				"-etcdCluster", "http://" + maker.Addresses.Etcd,
-			}, argv...)...,
+			}, append(maker.receptorAuthFlags(), argv...)...)...,
 		),
-	})
+	}))
+}
+
+func (maker ComponentMaker) receptorAuthFlags() []string {
+	if maker.ReceptorUsername == "" {
+		return []string{}
+	}
+
+	return []string{
+		"-username", maker.ReceptorUsername,
+		"-password", maker.ReceptorPassword,
+	}
+}
+
+// receptorURL includes the receptor's credentials, if it requires any.
+func (maker ComponentMaker) receptorURL() string {
+	if maker.ReceptorUsername == "" {
+		return "http://" + maker.Addresses.Receptor
+	}
+
+	return (&url.URL{
+		Scheme: "http",
+		User:   url.UserPassword(maker.ReceptorUsername, maker.ReceptorPassword),
+		Host:   maker.Addresses.Receptor,
+	}).String()
 }
 
 func (maker ComponentMaker) NATSClient() diegonats.NATSClient {
@@ -429,6 +1115,12 @@ func (maker ComponentMaker) ExecutorClient() executor.Client {
 }
 
 func (maker ComponentMaker) ReceptorClient() receptor.Client {
+	return receptor.NewClient(maker.receptorURL())
+}
+
+// UnauthenticatedReceptorClient talks to the receptor without credentials,
+// whether or not it requires them.
+func (maker ComponentMaker) UnauthenticatedReceptorClient() receptor.Client {
 	return receptor.NewClient("http://" + maker.Addresses.Receptor)
 }
 
@@ -0,0 +1,118 @@
+package world
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+)
+
+// Config is what would otherwise be passed to the suites as environment
+// variables, kept in one YAML (or JSON) file, e.g.
+//
+//	garden_bin_path: /opt/garden/bin
+//	garden_rootfs: /opt/warden/rootfs
+//	stack: lucid64
+//	timeouts:
+//	  long: 2m
+//	gopaths:
+//	  rep: /home/me/workspace/diego-release
+//	env:
+//	  COMPONENT_VERSIONS: v0
+type Config struct {
+	GardenBinPath    string `yaml:"garden_bin_path"`
+	GardenRootFSPath string `yaml:"garden_rootfs"`
+	GardenGraphPath  string `yaml:"garden_graph_path"`
+	Stack            string `yaml:"stack"`
+	ExternalAddress  string `yaml:"external_address"`
+
+	Timeouts ConfigTimeouts `yaml:"timeouts"`
+
+	// the GOPATH to build each component from, keyed by the prefix of its
+	// *_GOPATH variable, e.g. "rep" or "garden_linux"
+	GOPATHs map[string]string `yaml:"gopaths"`
+
+	// any other variables, by name
+	Env map[string]string `yaml:"env"`
+}
+
+// ConfigTimeouts are durations such as "30s"; see Timeouts.
+type ConfigTimeouts struct {
+	Short        string `yaml:"short"`
+	Long         string `yaml:"long"`
+	Crazy        string `yaml:"crazy"`
+	Consistently string `yaml:"consistently"`
+
+	EventuallyPollingInterval    string `yaml:"eventually_polling_interval"`
+	ConsistentlyPollingInterval  string `yaml:"consistently_polling_interval"`
+	EventFallbackPollingInterval string `yaml:"event_fallback_polling_interval"`
+	ConvergenceBudget            string `yaml:"convergence_budget"`
+}
+
+// LoadConfig reads the config file at path and sets the variables it
+// stands for, leaving any that are already set alone so that the
+// environment still wins. Like LoadTimeouts, it runs before there is a
+// suite to fail, so it panics if the file cannot be read.
+func LoadConfig(path string) Config {
+	file, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+
+	defer file.Close()
+
+	var config Config
+	err = candiedyaml.NewDecoder(file).Decode(&config)
+	if err != nil {
+		panic(fmt.Sprintf("malformed config %s: %s", path, err))
+	}
+
+	config.apply()
+
+	return config
+}
+
+func (config Config) apply() {
+	setDefaultEnv("GARDEN_BINPATH", config.GardenBinPath)
+	setDefaultEnv("GARDEN_ROOTFS", config.GardenRootFSPath)
+	setDefaultEnv("GARDEN_GRAPH_PATH", config.GardenGraphPath)
+	setDefaultEnv("INIGO_STACK", config.Stack)
+	setDefaultEnv("EXTERNAL_ADDRESS", config.ExternalAddress)
+
+	setDefaultEnv("SHORT_TIMEOUT", config.Timeouts.Short)
+	setDefaultEnv("LONG_TIMEOUT", config.Timeouts.Long, "DEFAULT_EVENTUALLY_TIMEOUT")
+	setDefaultEnv("CRAZY_TIMEOUT", config.Timeouts.Crazy)
+	setDefaultEnv("DEFAULT_CONSISTENTLY_DURATION", config.Timeouts.Consistently)
+	setDefaultEnv("EVENTUALLY_POLLING_INTERVAL", config.Timeouts.EventuallyPollingInterval)
+	setDefaultEnv("CONSISTENTLY_POLLING_INTERVAL", config.Timeouts.ConsistentlyPollingInterval)
+	setDefaultEnv("EVENT_FALLBACK_POLLING_INTERVAL", config.Timeouts.EventFallbackPollingInterval)
+	setDefaultEnv("CONVERGENCE_BUDGET", config.Timeouts.ConvergenceBudget)
+
+	for component, gopath := range config.GOPATHs {
+		setDefaultEnv(strings.ToUpper(component)+"_GOPATH", gopath)
+	}
+
+	for name, value := range config.Env {
+		setDefaultEnv(name, value)
+	}
+}
+
+// setDefaultEnv sets the variable to value, unless value is empty or the
+// variable, or any other that means the same, is already set.
+func setDefaultEnv(name string, value string, aliases ...string) {
+	if value == "" {
+		return
+	}
+
+	for _, existing := range append([]string{name}, aliases...) {
+		if os.Getenv(existing) != "" {
+			return
+		}
+	}
+
+	err := os.Setenv(name, value)
+	if err != nil {
+		panic(err)
+	}
+}
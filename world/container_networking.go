@@ -0,0 +1,31 @@
+package world
+
+// WithContainerNetworking returns a ComponentMaker for a separate garden
+// whose containers share ContainerNetworkPool, so tests can connect from one
+// container to another. Its executors talk to that garden; start it with
+// ContainerNetworkingGardenFlags.
+func (maker ComponentMaker) WithContainerNetworking() ComponentMaker {
+	return maker.withSeparateGarden(containerNetworkingGarden)
+}
+
+// ContainerNetworkPool is the subnet that WithContainerNetworking's garden
+// hands container IPs out of.
+func (maker ComponentMaker) ContainerNetworkPool() string {
+	return maker.GardenNetworkPool
+}
+
+// ContainerNetworkingGardenFlags configures garden like a cell's: containers
+// can reach the host but no other network, including each other, unless
+// allowContainerToContainer stands in for a policy component permitting it.
+func (maker ComponentMaker) ContainerNetworkingGardenFlags(allowContainerToContainer bool) []string {
+	flags := []string{
+		"-denyNetworks=0.0.0.0/0",
+		"-allowHostAccess=true",
+	}
+
+	if allowContainerToContainer {
+		flags = append(flags, "-allowNetworks", maker.ContainerNetworkPool())
+	}
+
+	return flags
+}
@@ -0,0 +1,55 @@
+package world
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// WithDockerRegistry returns a ComponentMaker for a separate garden that
+// pulls images from the registry at address, e.g. a
+// helpers.FakeDockerRegistry, as an insecure one. Start it with
+// DockerRegistryFlags.
+func (maker ComponentMaker) WithDockerRegistry(address string) ComponentMaker {
+	maker = maker.withSeparateGarden(dockerRegistryGarden)
+
+	// fresh, so that every image is pulled again rather than found cached
+	maker.GardenGraphPath = maker.TempDirs.New("garden-graph")
+	maker.DockerRegistry = address
+
+	return maker
+}
+
+// WithDockerRegistryCredentials has the maker's DockerRootFSURLs log in to
+// its registry with the given credentials.
+func (maker ComponentMaker) WithDockerRegistryCredentials(username, password string) ComponentMaker {
+	maker.DockerRegistryUsername = username
+	maker.DockerRegistryPassword = password
+
+	return maker
+}
+
+// DockerRegistryFlags lets WithDockerRegistry's garden pull from the
+// registry.
+func (maker ComponentMaker) DockerRegistryFlags() []string {
+	return []string{
+		"-allowHostAccess=true",
+		"-insecureDockerRegistryList", maker.DockerRegistry,
+	}
+}
+
+// DockerRootFSURL is what to create a container with for it to run in the
+// image name from the maker's registry. Garden is handed the credentials, if
+// any, in the URL, as a container's rootfs is all it is told of its image.
+func (maker ComponentMaker) DockerRootFSURL(name string) string {
+	rootfs := url.URL{
+		Scheme: "docker",
+		Host:   maker.DockerRegistry,
+		Path:   "/" + name,
+	}
+
+	if maker.DockerRegistryUsername != "" {
+		rootfs.User = url.UserPassword(maker.DockerRegistryUsername, maker.DockerRegistryPassword)
+	}
+
+	return fmt.Sprint(&rootfs)
+}
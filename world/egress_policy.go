@@ -0,0 +1,39 @@
+package world
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EgressPolicy is what garden lets containers connect out to: nothing in
+// DenyNetworks unless it is also in AllowNetworks, and the host itself only
+// with AllowHostAccess. Networks are CIDRs, e.g. "0.0.0.0/0".
+type EgressPolicy struct {
+	DenyNetworks    []string
+	AllowNetworks   []string
+	AllowHostAccess bool
+}
+
+// WithEgressPolicy returns a ComponentMaker for a separate garden that
+// enforces policy on its containers' outbound connections. Its executors
+// talk to that garden.
+func (maker ComponentMaker) WithEgressPolicy(policy EgressPolicy) ComponentMaker {
+	maker = maker.withSeparateGarden(egressPolicyGarden)
+	maker.GardenEgressPolicy = &policy
+
+	return maker
+}
+
+func (policy EgressPolicy) gardenFlags() []string {
+	flags := []string{"-allowHostAccess=" + strconv.FormatBool(policy.AllowHostAccess)}
+
+	if len(policy.DenyNetworks) > 0 {
+		flags = append(flags, "-denyNetworks="+strings.Join(policy.DenyNetworks, ","))
+	}
+
+	if len(policy.AllowNetworks) > 0 {
+		flags = append(flags, "-allowNetworks="+strings.Join(policy.AllowNetworks, ","))
+	}
+
+	return flags
+}
@@ -0,0 +1,24 @@
+package world
+
+// WithGardenGraphCleanup returns a ComponentMaker for a separate garden, with
+// a graph of its own, that cleans up unused image layers once the graph
+// grows past thresholdMB. Its executors talk to that garden; start it with
+// GardenGraphCleanupFlags.
+func (maker ComponentMaker) WithGardenGraphCleanup(thresholdMB int) ComponentMaker {
+	maker = maker.withSeparateGarden(gardenGraphCleanupGarden)
+
+	// fresh, so that its size is only ever down to the spec
+	maker.GardenGraphPath = maker.TempDirs.New("garden-graph")
+	maker.GardenGraphCleanupThresholdMB = thresholdMB
+
+	return maker
+}
+
+// GardenGraphCleanupFlags lets WithGardenGraphCleanup's garden pull images
+// from the registry at insecureRegistry, e.g. a helpers.FakeDockerRegistry.
+func (maker ComponentMaker) GardenGraphCleanupFlags(insecureRegistry string) []string {
+	return []string{
+		"-allowHostAccess=true",
+		"-insecureDockerRegistryList", insecureRegistry,
+	}
+}
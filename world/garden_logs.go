@@ -0,0 +1,93 @@
+package world
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	gardenrunner "github.com/cloudfoundry-incubator/garden-linux/integration/runner"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+	"github.com/tedsuo/ifrit"
+)
+
+// gardenrunner runs garden-linux with a ginkgomon of its own, which only
+// hands the output to the GinkgoWriter, so for LogFiles garden-linux is run
+// through a wrapper that copies both of its streams into a fifo as well.
+// exec keeps garden-linux the process gardenrunner signals.
+const gardenLogWrapper = `#!/bin/bash
+exec > >(tee %[1]q) 2> >(tee %[1]q >&2)
+exec %[2]q "$@"
+`
+
+type loggedGarden struct {
+	*gardenrunner.Runner
+
+	dir      string
+	timings  *Timings
+	logFiles *LogFiles
+}
+
+// loggedGardenLinux is gardenrunner.New, with the output kept in logFiles.
+func (maker ComponentMaker) loggedGardenLinux(argv ...string) ifrit.Runner {
+	bin := maker.Artifacts.Executables["garden-linux"]
+
+	if maker.LogFiles == nil {
+		return gardenrunner.New("tcp", maker.Addresses.GardenLinux, bin, maker.GardenBinPath, maker.GardenRootFSPath, maker.GardenGraphPath, argv...)
+	}
+
+	dir, err := ioutil.TempDir("", "garden-linux-logs")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	wrapper := filepath.Join(dir, "garden-linux")
+	err = ioutil.WriteFile(wrapper, []byte(fmt.Sprintf(gardenLogWrapper, filepath.Join(dir, "output"), bin)), 0755)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return loggedGarden{
+		Runner:   gardenrunner.New("tcp", maker.Addresses.GardenLinux, wrapper, maker.GardenBinPath, maker.GardenRootFSPath, maker.GardenGraphPath, argv...),
+		dir:      dir,
+		timings:  maker.Timings,
+		logFiles: maker.LogFiles,
+	}
+}
+
+func (garden loggedGarden) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	defer os.RemoveAll(garden.dir)
+
+	fifo := filepath.Join(garden.dir, "output")
+
+	err := syscall.Mkfifo(fifo, 0600)
+	if err != nil {
+		return err
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+
+		// blocks until the wrapper's tees open it
+		output, err := os.Open(fifo)
+		if err != nil {
+			return
+		}
+		defer output.Close()
+
+		io.Copy(gexec.NewPrefixedWriter(specTag(garden.timings.SpecID()), garden.logFiles.writer("garden-linux")), output)
+	}()
+
+	err = garden.Runner.Run(signals, ready)
+
+	// the wrapper may have died before its tees opened the fifo, which
+	// would leave the copy waiting for a writer forever
+	unblock, openErr := os.OpenFile(fifo, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if openErr == nil {
+		unblock.Close()
+	}
+
+	<-copied
+
+	return err
+}
@@ -0,0 +1,14 @@
+package world
+
+// WithGardenSnapshots returns a ComponentMaker for a separate garden that
+// snapshots its containers on shutdown into directories that outlive it, so
+// that the next garden started from the same maker restores them. Its
+// executors talk to that garden.
+func (maker ComponentMaker) WithGardenSnapshots() ComponentMaker {
+	maker = maker.withSeparateGarden(gardenSnapshotsGarden)
+
+	maker.GardenDepotPath = maker.TempDirs.New("garden-depot")
+	maker.GardenSnapshotsPath = maker.TempDirs.New("garden-snapshots")
+
+	return maker
+}
@@ -0,0 +1,158 @@
+package world
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LiveLogs prints every component's output as it is written, merged into
+// one stream, with each line prefixed by the component's name and how far
+// into the spec it was written, e.g.
+//
+//	[rep +3.214s] {"timestamp":...}
+//
+// This is on top of what goes to the GinkgoWriter, which only shows up once
+// a spec has failed, and is meant for watching a failing spec as it runs.
+// If Components is non-empty, only the components named in it are printed.
+//
+// A nil *LiveLogs prints nothing.
+type LiveLogs struct {
+	Out        io.Writer
+	Components map[string]bool
+
+	startedAt time.Time
+	lock      *sync.Mutex
+}
+
+// NewLiveLogs returns LiveLogs printing to stdout if enabled, or if
+// components, a comma-separated list of component names, is non-empty;
+// otherwise it returns nil.
+func NewLiveLogs(enabled bool, components string) *LiveLogs {
+	filter := map[string]bool{}
+	for _, name := range strings.Split(components, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			filter[name] = true
+		}
+	}
+
+	if !enabled && len(filter) == 0 {
+		return nil
+	}
+
+	return &LiveLogs{
+		Out:        os.Stdout,
+		Components: filter,
+
+		startedAt: time.Now(),
+		lock:      new(sync.Mutex),
+	}
+}
+
+// WithLiveLogs returns a ComponentMaker that prints the output of the named
+// components, or of all of them if none are named, as it happens; see
+// LiveLogs.
+func (maker ComponentMaker) WithLiveLogs(components ...string) ComponentMaker {
+	maker.LiveLogs = NewLiveLogs(true, strings.Join(components, ","))
+	return maker
+}
+
+// writer is where the component's output is printed from as it is
+// written, a line at a time; closing it prints whatever is left of the last
+// line. Lines are timed from since, if it returns anything, or else from
+// when the LiveLogs were made. It discards everything if there are no
+// LiveLogs, or the component is not one of their Components.
+func (liveLogs *LiveLogs) writer(name string, color string, since func() time.Time) io.WriteCloser {
+	if liveLogs == nil || (len(liveLogs.Components) > 0 && !liveLogs.Components[name]) {
+		return nopWriteCloser{ioutil.Discard}
+	}
+
+	return &liveLogWriter{
+		liveLogs: liveLogs,
+		name:     name,
+		color:    color,
+		since:    since,
+	}
+}
+
+type liveLogWriter struct {
+	liveLogs *LiveLogs
+	name     string
+	color    string
+	since    func() time.Time
+
+	// the start of a line whose end has not been written yet
+	partial []byte
+	lock    sync.Mutex
+}
+
+func (writer *liveLogWriter) Write(p []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	writer.partial = append(writer.partial, p...)
+
+	end := bytes.LastIndexByte(writer.partial, '\n') + 1
+	if end > 0 {
+		writer.print(writer.partial[:end])
+		writer.partial = append([]byte{}, writer.partial[end:]...)
+	}
+
+	return len(p), nil
+}
+
+func (writer *liveLogWriter) Close() error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	if len(writer.partial) > 0 {
+		writer.print(writer.partial)
+		writer.partial = nil
+	}
+
+	return nil
+}
+
+func (writer *liveLogWriter) print(lines []byte) {
+	liveLogs := writer.liveLogs
+
+	liveLogs.lock.Lock()
+	defer liveLogs.lock.Unlock()
+
+	label := writer.prefix()
+	for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(liveLogs.Out, "%s%s\n", label, bytes.TrimSuffix(line, []byte("\n")))
+	}
+}
+
+func (writer *liveLogWriter) prefix() string {
+	startedAt := writer.since()
+	if startedAt.IsZero() {
+		startedAt = writer.liveLogs.startedAt
+	}
+
+	label := fmt.Sprintf("[%s +%.3fs]", writer.name, time.Since(startedAt).Seconds())
+	if writer.color != "" {
+		label = "\x1b[" + writer.color + label + "\x1b[0m"
+	}
+
+	return label + " "
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,146 @@
+package world
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+)
+
+const (
+	DefaultLogFileMaxBytes = 10 * 1024 * 1024
+	DefaultLogFilesKept    = 5
+)
+
+// LogFiles keeps a copy of each component's output in <dir>/<name>-node-N.log,
+// on top of what goes to the GinkgoWriter, so that nothing is lost to its
+// buffering on long runs. Each file is rotated to .1, .2, ... once it grows
+// past MaxBytes, keeping the last Keep of them.
+//
+// A nil *LogFiles keeps nothing.
+type LogFiles struct {
+	Dir      string
+	MaxBytes int64
+	Keep     int
+
+	files map[string]*rotatingFile
+	lock  *sync.Mutex
+}
+
+// NewLogFiles returns LogFiles writing into dir, or nil if dir is empty.
+func NewLogFiles(dir string) *LogFiles {
+	if dir == "" {
+		return nil
+	}
+
+	return &LogFiles{
+		Dir:      dir,
+		MaxBytes: DefaultLogFileMaxBytes,
+		Keep:     DefaultLogFilesKept,
+
+		files: map[string]*rotatingFile{},
+		lock:  new(sync.Mutex),
+	}
+}
+
+// WithLogDir returns a ComponentMaker that keeps its components' output in
+// dir; see LogFiles.
+func (maker ComponentMaker) WithLogDir(dir string) ComponentMaker {
+	maker.LogFiles = NewLogFiles(dir)
+	return maker
+}
+
+// writer is where the component's output is copied to its log file as it
+// is written; it discards everything if there are no LogFiles.
+func (logFiles *LogFiles) writer(name string) io.Writer {
+	if logFiles == nil {
+		return ioutil.Discard
+	}
+
+	file := logFiles.file(name)
+	file.write([]byte(fmt.Sprintf("===== %s started at %s =====\n", name, time.Now().Format(time.RFC3339))))
+
+	return file
+}
+
+// components with the same name, e.g. several stagers, share a file
+func (logFiles *LogFiles) file(name string) *rotatingFile {
+	logFiles.lock.Lock()
+	defer logFiles.lock.Unlock()
+
+	file, found := logFiles.files[name]
+	if !found {
+		file = &rotatingFile{
+			path:     filepath.Join(logFiles.Dir, fmt.Sprintf("%s-node-%d.log", name, config.GinkgoConfig.ParallelNode)),
+			maxBytes: logFiles.MaxBytes,
+			keep:     logFiles.Keep,
+		}
+
+		logFiles.files[name] = file
+	}
+
+	return file
+}
+
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	keep     int
+
+	file    *os.File
+	written int64
+	lock    sync.Mutex
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.write(p)
+	return len(p), nil
+}
+
+// write never fails the suite; losing a log line is better than losing a run
+func (f *rotatingFile) write(p []byte) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file != nil && f.written+int64(len(p)) > f.maxBytes {
+		f.rotate()
+	}
+
+	if f.file == nil {
+		err := os.MkdirAll(filepath.Dir(f.path), 0755)
+		if err != nil {
+			return
+		}
+
+		f.file, err = os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return
+		}
+
+		info, err := f.file.Stat()
+		if err == nil {
+			f.written = info.Size()
+		}
+	}
+
+	n, _ := f.file.Write(p)
+	f.written += int64(n)
+}
+
+func (f *rotatingFile) rotate() {
+	f.file.Close()
+	f.file = nil
+	f.written = 0
+
+	os.Remove(fmt.Sprintf("%s.%d", f.path, f.keep))
+	for i := f.keep - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+
+	os.Rename(f.path, f.path+".1")
+}
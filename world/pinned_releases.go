@@ -0,0 +1,98 @@
+package world
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/gomega"
+)
+
+// FetchPinnedRelease returns the executables in the artifacts tarball at
+// url, as exported by ExportArtifacts from an older release's build. The
+// tarball is downloaded once into $INIGO_RELEASE_CACHE, or a directory in
+// the system's temp dir, and reused from there by later runs. Unless digest
+// is empty, the download must have that hex-encoded SHA-256.
+func FetchPinnedRelease(version string, url string, digest string) BuiltExecutables {
+	cacheDir := os.Getenv("INIGO_RELEASE_CACHE")
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "inigo-releases")
+	}
+
+	err := os.MkdirAll(cacheDir, 0755)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	tarballPath := filepath.Join(cacheDir, fmt.Sprintf("%s-%x.tgz", version, sha256.Sum256([]byte(url+"#"+digest))))
+
+	if _, err := os.Stat(tarballPath); os.IsNotExist(err) {
+		downloadRelease(url, digest, tarballPath)
+	}
+
+	// extracted next to the tarball, so that later runs reuse the directory
+	// rather than leave one behind each
+	artifacts, _ := ImportArtifacts(tarballPath, strings.TrimSuffix(tarballPath, ".tgz"))
+
+	return artifacts.Executables
+}
+
+// FetchPinnedReleases fetches every release pinned in the comma-separated
+// $PINNED_COMPONENT_VERSIONS, e.g. "v0=https://example.com/v0.tgz", by
+// version. A pin may end in "#<sha256>" to have its tarball verified.
+func FetchPinnedReleases() map[string]BuiltExecutables {
+	versions := map[string]BuiltExecutables{}
+
+	for _, pin := range strings.Split(os.Getenv("PINNED_COMPONENT_VERSIONS"), ",") {
+		pin = strings.TrimSpace(pin)
+		if pin == "" {
+			continue
+		}
+
+		versionAndURL := strings.SplitN(pin, "=", 2)
+		Ω(versionAndURL).Should(HaveLen(2), "pinned version %q is not <version>=<url>", pin)
+
+		url, digest := versionAndURL[1], ""
+		if i := strings.LastIndex(url, "#"); i != -1 {
+			url, digest = url[:i], url[i+1:]
+		}
+
+		versions[versionAndURL[0]] = FetchPinnedRelease(versionAndURL[0], url, digest)
+	}
+
+	return versions
+}
+
+// downloadRelease writes to a temporary file first, so that an interrupted
+// or corrupted download is not mistaken for a cached one.
+func downloadRelease(url string, digest string, tarballPath string) {
+	resp, err := http.Get(url)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer resp.Body.Close()
+
+	Ω(resp.StatusCode).Should(Equal(http.StatusOK), "downloading %s", url)
+
+	partial, err := ioutil.TempFile(filepath.Dir(tarballPath), "partial-release")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	defer os.Remove(partial.Name())
+
+	hash := sha256.New()
+
+	_, err = io.Copy(io.MultiWriter(partial, hash), resp.Body)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	err = partial.Close()
+	Ω(err).ShouldNot(HaveOccurred())
+
+	if digest != "" {
+		Ω(hex.EncodeToString(hash.Sum(nil))).Should(Equal(strings.ToLower(digest)), "sha256 of %s", url)
+	}
+
+	err = os.Rename(partial.Name(), tarballPath)
+	Ω(err).ShouldNot(HaveOccurred())
+}
@@ -0,0 +1,139 @@
+package world
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+
+	. "github.com/onsi/gomega"
+)
+
+const preflightMinimumFreeDiskBytes = 1024 * 1024 * 1024
+
+var preflightRequiredEnv = []string{
+	"GARDEN_BINPATH",
+	"GARDEN_ROOTFS",
+	"EXTERNAL_ADDRESS",
+}
+
+var preflightRequiredBinaries = []string{
+	"gnatsd",
+	"etcd",
+	"zip",
+	"tar",
+	"git",
+}
+
+// Preflight checks that the environment can run the suite at all, failing
+// with every problem found rather than the first one a component trips over.
+func Preflight(addresses ComponentAddresses) {
+	problems := []string{}
+
+	for _, name := range preflightRequiredEnv {
+		if os.Getenv(name) == "" {
+			problems = append(problems, fmt.Sprintf("$%s is not set", name))
+		}
+	}
+
+	if rootFS := os.Getenv("GARDEN_ROOTFS"); rootFS != "" {
+		if _, err := os.Stat(rootFS); err != nil {
+			problems = append(problems, fmt.Sprintf("$GARDEN_ROOTFS (%s) does not exist: %s", rootFS, err))
+		}
+	}
+
+	for _, binary := range preflightRequiredBinaries {
+		if _, err := exec.LookPath(binary); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not on $PATH", binary))
+		}
+	}
+
+	problems = append(problems, preflightKernel()...)
+	problems = append(problems, preflightPorts(addresses)...)
+	problems = append(problems, preflightDisk()...)
+
+	Ω(problems).Should(BeEmpty(), "preflight failed:\n  %s", strings.Join(problems, "\n  "))
+}
+
+func preflightKernel() []string {
+	problems := []string{}
+
+	if info, err := os.Stat("/sys/fs/cgroup"); err != nil || !info.IsDir() {
+		problems = append(problems, "cgroups are not mounted at /sys/fs/cgroup")
+	}
+
+	filesystems, err := ioutil.ReadFile("/proc/filesystems")
+	if err != nil {
+		return append(problems, fmt.Sprintf("cannot read /proc/filesystems: %s", err))
+	}
+
+	if !strings.Contains(string(filesystems), "overlay") && !strings.Contains(string(filesystems), "aufs") {
+		problems = append(problems, "the kernel supports neither overlayfs nor aufs")
+	}
+
+	return problems
+}
+
+func preflightPorts(addresses ComponentAddresses) []string {
+	problems := []string{}
+
+	named := map[string]string{}
+
+	value := reflect.ValueOf(addresses)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).Kind() == reflect.String {
+			named[value.Type().Field(i).Name] = value.Field(i).String()
+		}
+	}
+
+	for component, address := range addresses.Debug {
+		named[component+" debug"] = address
+	}
+
+	for name, address := range named {
+		if address == "" {
+			continue
+		}
+
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s address %s is not available: %s", name, address, err))
+			continue
+		}
+
+		listener.Close()
+	}
+
+	sort.Strings(problems)
+
+	return problems
+}
+
+func preflightDisk() []string {
+	problems := []string{}
+
+	paths := []string{os.TempDir()}
+	if graphPath := os.Getenv("GARDEN_GRAPH_PATH"); graphPath != "" {
+		paths = append(paths, graphPath)
+	}
+
+	for _, path := range paths {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			problems = append(problems, fmt.Sprintf("cannot stat filesystem of %s: %s", path, err))
+			continue
+		}
+
+		free := stat.Bavail * uint64(stat.Bsize)
+		if free < preflightMinimumFreeDiskBytes {
+			problems = append(problems, fmt.Sprintf("only %dMB free under %s", free/1024/1024, path))
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,58 @@
+package world
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// Restart kills a component and starts it again with exactly the same
+// command line, so that it comes back on the same addresses and with the
+// same directories, and returns the new process and runner.
+//
+// The runner's Cleanup is handed to the new runner rather than run, so that
+// the directories survive the restart.
+func Restart(process ifrit.Process, runner *TimedRunner) (ifrit.Process, *TimedRunner) {
+	cleanup := runner.Cleanup
+	runner.Cleanup = nil
+
+	ginkgomon.Kill(process)
+
+	restarted := runner.rerun()
+	restarted.Cleanup = cleanup
+
+	return ginkgomon.Invoke(restarted), restarted
+}
+
+// RestartExecutor restarts an executor made by this maker in place. It comes
+// back with the same cache, but destroys the containers it owned on the way
+// up.
+func (maker ComponentMaker) RestartExecutor(process ifrit.Process, runner *TimedRunner) (ifrit.Process, *TimedRunner) {
+	Ω(runner.Name).Should(Equal("executor"), "RestartExecutor given a %s", runner.Name)
+
+	return Restart(process, runner)
+}
+
+// rerun copies the runner with a fresh, unstarted Command, as an exec.Cmd
+// can only be started once.
+func (runner *TimedRunner) rerun() *TimedRunner {
+	command := exec.Command(runner.Command.Path, runner.Command.Args[1:]...)
+	command.Env = runner.Command.Env
+	command.Dir = runner.Command.Dir
+
+	return newTimedRunner(
+		ginkgomon.New(ginkgomon.Config{
+			Name:              runner.Name,
+			AnsiColorCode:     runner.AnsiColorCode,
+			StartCheck:        runner.StartCheck,
+			StartCheckTimeout: runner.StartCheckTimeout,
+			Command:           command,
+			Cleanup:           runner.Cleanup,
+		}),
+		runner.timings,
+		runner.logFiles,
+		runner.liveLogs,
+	)
+}
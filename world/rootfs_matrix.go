@@ -0,0 +1,50 @@
+package world
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+)
+
+type RootFS struct {
+	Name string
+	Path string
+}
+
+// RootFSMatrix parses $GARDEN_ROOTFS_MATRIX, a comma-separated list of
+// name=path pairs, e.g.
+//
+//	cflinuxfs2=/var/vcap/packages/cflinuxfs2/rootfs,busybox=/opt/busybox
+//
+// It returns nothing if the variable is unset.
+func RootFSMatrix() []RootFS {
+	matrix := os.Getenv("GARDEN_ROOTFS_MATRIX")
+	if matrix == "" {
+		return nil
+	}
+
+	rootfses := []RootFS{}
+	for _, entry := range strings.Split(matrix, ",") {
+		nameAndPath := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(nameAndPath) != 2 || nameAndPath[0] == "" || nameAndPath[1] == "" {
+			ginkgo.Fail(fmt.Sprintf("malformed $GARDEN_ROOTFS_MATRIX entry %q; expected name=path", entry))
+		}
+
+		rootfses = append(rootfses, RootFS{Name: nameAndPath[0], Path: nameAndPath[1]})
+	}
+
+	return rootfses
+}
+
+// WithRootFS returns a ComponentMaker for a separate garden using the given
+// rootfs, and whose executors talk to that garden. Each index (from 1) gets
+// its own garden address and graph, so several can run at once alongside the
+// suite's own garden.
+func (maker ComponentMaker) WithRootFS(index int, rootfs RootFS) ComponentMaker {
+	maker = maker.withSeparateGarden(rootFSMatrixGarden(index, rootfs))
+	maker.GardenRootFSPath = rootfs.Path
+
+	return maker
+}
@@ -0,0 +1,55 @@
+package world
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/onsi/ginkgo/config"
+)
+
+// separateGarden is where a garden running alongside the suite's lives: on
+// the suite garden's port plus portOffset, with a graph in graphSubdir of the
+// suite's, and with containers tagged tagPrefix plus the node and given IPs
+// from 10.<poolOctet>.<node>.0/24, so that neither its containers' handles
+// nor their networks collide with another garden's.
+type separateGarden struct {
+	portOffset  int
+	graphSubdir string
+	tagPrefix   string
+	poolOctet   int
+}
+
+// every separate garden the makers hand out, in one place so that none of
+// them share a port, tag or network pool; WithRootFS's take the port offsets
+// from 100 and the pool octets from 201 up.
+var (
+	gardenSnapshotsGarden     = separateGarden{portOffset: 50, graphSubdir: "snapshots", tagPrefix: "s", poolOctet: 199}
+	containerNetworkingGarden = separateGarden{portOffset: 60, graphSubdir: "container-networking", tagPrefix: "n", poolOctet: 198}
+	gardenGraphCleanupGarden  = separateGarden{portOffset: 70, graphSubdir: "graph-cleanup", tagPrefix: "g", poolOctet: 197}
+	dockerRegistryGarden      = separateGarden{portOffset: 80, graphSubdir: "docker-registry", tagPrefix: "d", poolOctet: 195}
+	egressPolicyGarden        = separateGarden{portOffset: 90, graphSubdir: "egress-policy", tagPrefix: "e", poolOctet: 196}
+)
+
+func rootFSMatrixGarden(index int, rootfs RootFS) separateGarden {
+	return separateGarden{
+		portOffset:  100 * index,
+		graphSubdir: "rootfs-matrix-" + rootfs.Name,
+		// separated, or rootfs 1 on node 11 and rootfs 11 on node 1 would
+		// share a tag
+		tagPrefix: fmt.Sprintf("m%dn", index),
+		poolOctet: 200 + index,
+	}
+}
+
+// withSeparateGarden points the maker at a garden of its own, as described
+// by separate, so that it can run alongside the suite's.
+func (maker ComponentMaker) withSeparateGarden(separate separateGarden) ComponentMaker {
+	node := config.GinkgoConfig.ParallelNode
+
+	maker.Addresses.GardenLinux = addressWithPortOffset(maker.Addresses.GardenLinux, separate.portOffset)
+	maker.GardenGraphPath = filepath.Join(maker.GardenGraphPath, separate.graphSubdir)
+	maker.GardenTag = fmt.Sprintf("%s%d", separate.tagPrefix, node)
+	maker.GardenNetworkPool = fmt.Sprintf("10.%d.%d.0/24", separate.poolOctet, node)
+
+	return maker
+}
@@ -0,0 +1,75 @@
+package world
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/onsi/ginkgo/config"
+	. "github.com/onsi/gomega"
+	archive_helper "github.com/pivotal-golang/archiver/extractor/test_helper"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// SharedFileServerPortOffset puts the one port every parallel node reaches
+// the SharedFileServer on this far above the per-node file-servers' base
+// port, clear of their ports and their backends'. It is left out of
+// Preflight since it is in use by the time the other nodes check.
+const SharedFileServerPortOffset = 999
+
+// SharedFileServer is a file-server that the first parallel node runs for
+// all of them, from SynchronizedBeforeSuite, so that fixtures every node
+// needs are served once rather than once per node. It is handed to the other
+// nodes in BuiltArtifacts.
+type SharedFileServer struct {
+	Address   string
+	StaticDir string
+}
+
+// StartSharedFileServer starts a plain file-server on address, serving a
+// directory of its own rather than one from TempDirs, which belong to a
+// single node and are removed when it finishes. Stop it, and then remove
+// StaticDir, from the suite's SynchronizedAfterSuite.
+func (maker ComponentMaker) StartSharedFileServer(address string) (*SharedFileServer, ifrit.Process) {
+	staticDir, err := ioutil.TempDir("", "shared-file-server")
+	Ω(err).ShouldNot(HaveOccurred())
+
+	maker.Addresses.FileServer = address
+	maker.FileServerTLS = nil
+
+	return &SharedFileServer{
+		Address:   address,
+		StaticDir: staticDir,
+	}, ginkgomon.Invoke(maker.fileServer(staticDir, nil))
+}
+
+// RegisterFixture zips files into the shared file-server as name, kept
+// under this node's own directory so that nodes never overwrite each
+// other's fixtures, and returns the URL it is served from.
+func (shared *SharedFileServer) RegisterFixture(name string, files []archive_helper.ArchiveFile) string {
+	path := filepath.Join(shared.StaticDir, shared.nodePath(name))
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	archive_helper.CreateZipArchive(path, files)
+
+	return shared.URL(name)
+}
+
+// URL is where the fixture this node registered as name is served from.
+func (shared *SharedFileServer) URL(name string) string {
+	return fmt.Sprintf("http://%s/v1/static/%s", shared.Address, shared.nodePath(name))
+}
+
+// Remove removes everything the nodes registered.
+func (shared *SharedFileServer) Remove() {
+	err := os.RemoveAll(shared.StaticDir)
+	Ω(err).ShouldNot(HaveOccurred())
+}
+
+func (shared *SharedFileServer) nodePath(name string) string {
+	return fmt.Sprintf("node-%d/%s", config.GinkgoConfig.ParallelNode, name)
+}
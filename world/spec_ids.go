@@ -0,0 +1,79 @@
+package world
+
+import (
+	"os"
+	"strings"
+)
+
+// SpecIDEnv is set to the spec's id in the environment of every component
+// run by a TimedRunner, so that a component can be told which spec it is
+// serving.
+const SpecIDEnv = "INIGO_SPEC_ID"
+
+// SpecID identifies the current spec among every spec of every node, e.g.
+// "n3-s17" for the 17th spec on node 3. It is empty outside of specs.
+func (timings *Timings) SpecID() string {
+	if timings == nil {
+		return ""
+	}
+
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	return timings.specID
+}
+
+// SpecComponents are the components run during the current spec, in the
+// order they were started.
+func (timings *Timings) SpecComponents() []*TimedRunner {
+	if timings == nil {
+		return nil
+	}
+
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	return append([]*TimedRunner{}, timings.specComponents...)
+}
+
+func (timings *Timings) ranInSpec(runner *TimedRunner) {
+	if timings == nil {
+		return
+	}
+
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	timings.specComponents = append(timings.specComponents, runner)
+}
+
+// withSpecID replaces any spec id in env, e.g. one a restarted component's
+// command kept from an earlier spec. A nil env is the test process's own.
+func withSpecID(env []string, specID string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+
+	tagged := []string{}
+	for _, variable := range env {
+		if !strings.HasPrefix(variable, SpecIDEnv+"=") {
+			tagged = append(tagged, variable)
+		}
+	}
+
+	if specID == "" {
+		return tagged
+	}
+
+	return append(tagged, SpecIDEnv+"="+specID)
+}
+
+// specTag is prepended to every line of a component's Output and log file;
+// components run outside of specs are not tagged.
+func specTag(specID string) string {
+	if specID == "" {
+		return ""
+	}
+
+	return "[" + specID + "] "
+}
@@ -0,0 +1,62 @@
+package world
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// TempDirs hands out temporary directories for components, namespaced under
+// a single root per parallel node so that everything a node created can be
+// removed in one go, even if the component that owned it never cleaned up.
+type TempDirs struct {
+	root string
+	lock *sync.Mutex
+}
+
+func NewTempDirs() *TempDirs {
+	return &TempDirs{
+		lock: new(sync.Mutex),
+	}
+}
+
+func (dirs *TempDirs) New(component string) string {
+	dirs.lock.Lock()
+	defer dirs.lock.Unlock()
+
+	if dirs.root == "" {
+		root, err := ioutil.TempDir("", fmt.Sprintf("inigo-node-%d-", ginkgo.GinkgoParallelNode()))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		dirs.root = root
+	}
+
+	dir, err := ioutil.TempDir(dirs.root, component)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	return dir
+}
+
+// RemoveAll removes every directory handed out so far; it is meant to be
+// called from AfterSuite, where the maker may never have been constructed.
+func (dirs *TempDirs) RemoveAll() {
+	if dirs == nil {
+		return
+	}
+
+	dirs.lock.Lock()
+	defer dirs.lock.Unlock()
+
+	if dirs.root == "" {
+		return
+	}
+
+	err := os.RemoveAll(dirs.root)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	dirs.root = ""
+}
@@ -0,0 +1,94 @@
+package world
+
+import (
+	"os"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+// Timeouts are how long specs wait for things, tunable per environment so
+// that slow CI can be given more time in one place.
+//
+//	Short:  something that should happen almost immediately, e.g. a process
+//	        exiting after a signal ($SHORT_TIMEOUT)
+//	Long:   the default for Eventually, e.g. an LRP starting ($LONG_TIMEOUT,
+//	        or $DEFAULT_EVENTUALLY_TIMEOUT)
+//	Crazy:  something that takes several convergence rounds, e.g. instances
+//	        moving off a dead cell ($CRAZY_TIMEOUT)
+type Timeouts struct {
+	Short time.Duration
+	Long  time.Duration
+	Crazy time.Duration
+
+	// the default for Consistently ($DEFAULT_CONSISTENTLY_DURATION)
+	Consistently time.Duration
+
+	// $EVENTUALLY_POLLING_INTERVAL and $CONSISTENTLY_POLLING_INTERVAL
+	EventuallyPollingInterval   time.Duration
+	ConsistentlyPollingInterval time.Duration
+
+	// how often waits that follow an event stream look anyway, in case it
+	// missed something ($EVENT_FALLBACK_POLLING_INTERVAL)
+	EventFallbackPollingInterval time.Duration
+
+	// how long convergence may take to repair a divergence before the
+	// convergence latency specs fail ($CONVERGENCE_BUDGET)
+	ConvergenceBudget time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Short: 10 * time.Second,
+	Long:  time.Minute,
+	Crazy: 5 * time.Minute,
+
+	Consistently: 5 * time.Second,
+
+	// most things hit some component; don't hammer it
+	EventuallyPollingInterval:   500 * time.Millisecond,
+	ConsistentlyPollingInterval: 100 * time.Millisecond,
+
+	EventFallbackPollingInterval: 5 * time.Second,
+
+	ConvergenceBudget: 30 * time.Second,
+}
+
+// LoadTimeouts returns DefaultTimeouts, overridden by whichever of the
+// variables above are set. It panics on a malformed duration.
+func LoadTimeouts() Timeouts {
+	timeouts := DefaultTimeouts
+
+	loadDuration(&timeouts.Short, "SHORT_TIMEOUT")
+	loadDuration(&timeouts.Long, "DEFAULT_EVENTUALLY_TIMEOUT")
+	loadDuration(&timeouts.Long, "LONG_TIMEOUT")
+	loadDuration(&timeouts.Crazy, "CRAZY_TIMEOUT")
+	loadDuration(&timeouts.Consistently, "DEFAULT_CONSISTENTLY_DURATION")
+	loadDuration(&timeouts.EventuallyPollingInterval, "EVENTUALLY_POLLING_INTERVAL")
+	loadDuration(&timeouts.ConsistentlyPollingInterval, "CONSISTENTLY_POLLING_INTERVAL")
+	loadDuration(&timeouts.EventFallbackPollingInterval, "EVENT_FALLBACK_POLLING_INTERVAL")
+	loadDuration(&timeouts.ConvergenceBudget, "CONVERGENCE_BUDGET")
+
+	return timeouts
+}
+
+// Register makes these the Gomega defaults.
+func (timeouts Timeouts) Register() {
+	gomega.SetDefaultEventuallyTimeout(timeouts.Long)
+	gomega.SetDefaultEventuallyPollingInterval(timeouts.EventuallyPollingInterval)
+	gomega.SetDefaultConsistentlyDuration(timeouts.Consistently)
+	gomega.SetDefaultConsistentlyPollingInterval(timeouts.ConsistentlyPollingInterval)
+}
+
+func loadDuration(duration *time.Duration, envVar string) {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		panic(err)
+	}
+
+	*duration = parsed
+}
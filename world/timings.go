@@ -0,0 +1,418 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// Timings records how long each component took to start and how long each
+// spec took, for a per-node report of what is slowing the suite down.
+type Timings struct {
+	startups []StartupTiming
+	specs    []SpecTiming
+
+	specStartedAt time.Time
+
+	// see SpecID and SpecComponents
+	specCount      int
+	specID         string
+	specComponents []*TimedRunner
+
+	lock *sync.Mutex
+}
+
+type StartupTiming struct {
+	Component string        `json:"component"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+type SpecTiming struct {
+	Spec     string        `json:"spec"`
+	Duration time.Duration `json:"duration_ns"`
+	Failed   bool          `json:"failed"`
+}
+
+type ComponentStartupSummary struct {
+	Component string        `json:"component"`
+	Starts    int           `json:"starts"`
+	Total     time.Duration `json:"total_ns"`
+	Mean      time.Duration `json:"mean_ns"`
+	Max       time.Duration `json:"max_ns"`
+}
+
+type TimingReport struct {
+	Node       int                       `json:"node"`
+	Components []ComponentStartupSummary `json:"components"`
+	Startups   []StartupTiming           `json:"startups"`
+	Specs      []SpecTiming              `json:"specs"`
+}
+
+func NewTimings() *Timings {
+	return &Timings{
+		lock: new(sync.Mutex),
+	}
+}
+
+// StartSpec and FinishSpec bracket each spec, from the suite's top-level
+// BeforeEach and AfterEach.
+func (timings *Timings) StartSpec() {
+	if timings == nil {
+		return
+	}
+
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	timings.specStartedAt = time.Now()
+
+	timings.specCount++
+	timings.specID = fmt.Sprintf("n%d-s%d", ginkgo.GinkgoParallelNode(), timings.specCount)
+	timings.specComponents = nil
+}
+
+func (timings *Timings) FinishSpec() {
+	if timings == nil {
+		return
+	}
+
+	description := ginkgo.CurrentGinkgoTestDescription()
+
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	timings.specs = append(timings.specs, SpecTiming{
+		Spec:     description.FullTestText,
+		Duration: time.Since(timings.specStartedAt),
+		Failed:   description.Failed,
+	})
+}
+
+// SpecStartedAt is when the current spec started, or the zero time if none
+// has.
+func (timings *Timings) SpecStartedAt() time.Time {
+	if timings == nil {
+		return time.Time{}
+	}
+
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	return timings.specStartedAt
+}
+
+func (timings *Timings) RecordStartup(component string, startedAt time.Time, duration time.Duration) {
+	if timings == nil {
+		return
+	}
+
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	timings.startups = append(timings.startups, StartupTiming{
+		Component: component,
+		StartedAt: startedAt,
+		Duration:  duration,
+	})
+}
+
+// Report summarizes startups per component, slowest in total first.
+func (timings *Timings) Report() TimingReport {
+	timings.lock.Lock()
+	defer timings.lock.Unlock()
+
+	summaries := map[string]*ComponentStartupSummary{}
+	for _, startup := range timings.startups {
+		summary, found := summaries[startup.Component]
+		if !found {
+			summary = &ComponentStartupSummary{Component: startup.Component}
+			summaries[startup.Component] = summary
+		}
+
+		summary.Starts++
+		summary.Total += startup.Duration
+		if startup.Duration > summary.Max {
+			summary.Max = startup.Duration
+		}
+	}
+
+	components := []ComponentStartupSummary{}
+	for _, summary := range summaries {
+		summary.Mean = summary.Total / time.Duration(summary.Starts)
+		components = append(components, *summary)
+	}
+
+	sort.Sort(slowestFirst(components))
+
+	return TimingReport{
+		Node:       ginkgo.GinkgoParallelNode(),
+		Components: components,
+		Startups:   append([]StartupTiming{}, timings.startups...),
+		Specs:      append([]SpecTiming{}, timings.specs...),
+	}
+}
+
+// WriteReport writes the report as JSON and HTML to $ARTIFACTS_DIR (or the
+// temp dir); it is meant to be called from AfterSuite, where the maker may
+// never have been constructed.
+func (timings *Timings) WriteReport(suite string) {
+	if timings == nil {
+		return
+	}
+
+	report := timings.Report()
+
+	artifactsDir := os.Getenv("ARTIFACTS_DIR")
+	if artifactsDir == "" {
+		artifactsDir = os.TempDir()
+	}
+
+	base := filepath.Join(artifactsDir, fmt.Sprintf("timings-%s-node-%d", suite, report.Node))
+
+	err := writeTimingReport(base, report)
+	if err != nil {
+		fmt.Fprintf(ginkgo.GinkgoWriter, "failed to write timing report: %s\n", err)
+		return
+	}
+
+	fmt.Fprintf(ginkgo.GinkgoWriter, "wrote timing report to %s.{json,html}\n", base)
+}
+
+func writeTimingReport(base string, report TimingReport) error {
+	payload, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(base+".json", payload, 0644)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(base + ".html")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return timingReportTemplate.Execute(file, report)
+}
+
+var timingReportTemplate = template.Must(template.New("timings").Parse(`<!DOCTYPE html>
+<html>
+<head><title>inigo timings (node {{.Node}})</title></head>
+<body>
+<h1>Component startup</h1>
+<table>
+<tr><th>component</th><th>starts</th><th>total</th><th>mean</th><th>max</th></tr>
+{{range .Components}}<tr><td>{{.Component}}</td><td>{{.Starts}}</td><td>{{.Total}}</td><td>{{.Mean}}</td><td>{{.Max}}</td></tr>
+{{end}}</table>
+<h1>Specs</h1>
+<table>
+<tr><th>spec</th><th>duration</th><th>failed</th></tr>
+{{range .Specs}}<tr><td>{{.Spec}}</td><td>{{.Duration}}</td><td>{{.Failed}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// TimedRunner is a ginkgomon.Runner that records how long its component
+// took to pass its start check, and keeps its output in LogFiles and prints
+// it to LiveLogs if asked to, tagging each line with the spec it was
+// written in.
+//
+// It runs the component itself, as ginkgomon would, so that the output can
+// be copied to those as it is written; the embedded Runner is only its
+// configuration.
+type TimedRunner struct {
+	*ginkgomon.Runner
+
+	timings  *Timings
+	logFiles *LogFiles
+	liveLogs *LiveLogs
+
+	session *gexec.Session
+	output  *gbytes.Buffer
+	started chan struct{}
+	lock    *sync.Mutex
+}
+
+func (runner *TimedRunner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	startedAt := time.Now()
+
+	runner.timings.ranInSpec(runner)
+
+	// live logs are printed from the start, so a component that is slow to
+	// come up can be watched while it is
+	liveLog := runner.liveLogs.writer(runner.Name, runner.AnsiColorCode, runner.timings.SpecStartedAt)
+	defer liveLog.Close()
+
+	// stderr has a writer of its own, so that a partial line on one stream
+	// is not finished by the other
+	liveErrorLog := runner.liveLogs.writer(runner.Name, runner.AnsiColorCode, runner.timings.SpecStartedAt)
+	defer liveErrorLog.Close()
+
+	// keeps both streams, for the start check
+	allOutput := gbytes.NewBuffer()
+
+	// keeps both streams too, with every line tagged with the spec, for
+	// Output
+	output := gbytes.NewBuffer()
+
+	specID := runner.timings.SpecID()
+	runner.Command.Env = withSpecID(runner.Command.Env, specID)
+
+	// both streams go to the one file, so that it has everything the
+	// component wrote however long the run
+	logFile := runner.logFiles.writer(runner.Name)
+
+	session, err := gexec.Start(
+		runner.Command,
+		io.MultiWriter(
+			gexec.NewPrefixedWriter(
+				fmt.Sprintf("\x1b[32m[o]\x1b[%s[%s]\x1b[0m ", runner.AnsiColorCode, runner.Name),
+				io.MultiWriter(allOutput, ginkgo.GinkgoWriter),
+			),
+			gexec.NewPrefixedWriter(
+				specTag(specID),
+				io.MultiWriter(output, logFile),
+			),
+			liveLog,
+		),
+		io.MultiWriter(
+			gexec.NewPrefixedWriter(
+				fmt.Sprintf("\x1b[91m[e]\x1b[%s[%s]\x1b[0m ", runner.AnsiColorCode, runner.Name),
+				io.MultiWriter(allOutput, ginkgo.GinkgoWriter),
+			),
+			gexec.NewPrefixedWriter(specTag(specID), io.MultiWriter(output, logFile)),
+			liveErrorLog,
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	runner.lock.Lock()
+	runner.session = session
+	runner.output = output
+	runner.lock.Unlock()
+	close(runner.started)
+
+	startCheckDuration := runner.StartCheckTimeout
+	if startCheckDuration == 0 {
+		startCheckDuration = 5 * time.Second
+	}
+
+	var startCheckTimeout <-chan time.Time
+	if runner.StartCheck != "" {
+		startCheckTimeout = time.After(startCheckDuration)
+	}
+
+	// fires straight away for an empty StartCheck
+	detectStartCheck := allOutput.Detect(runner.StartCheck)
+
+	for {
+		select {
+		case <-detectStartCheck:
+			allOutput.CancelDetects()
+			startCheckTimeout = nil
+			detectStartCheck = nil
+
+			runner.timings.RecordStartup(runner.Name, startedAt, time.Since(startedAt))
+			close(ready)
+
+		case <-startCheckTimeout:
+			session.Kill().Wait()
+
+			return fmt.Errorf(
+				"did not see %s in command's output within %s. full output:\n\n%s",
+				runner.StartCheck,
+				startCheckDuration,
+				string(allOutput.Contents()),
+			)
+
+		case signal := <-signals:
+			session.Signal(signal)
+
+		case <-session.Exited:
+			if runner.Cleanup != nil {
+				runner.Cleanup()
+			}
+
+			if session.ExitCode() == 0 {
+				return nil
+			}
+
+			return fmt.Errorf("exit status %d", session.ExitCode())
+		}
+	}
+}
+
+// Buffer is the component's standard output so far, or nil if it has not
+// been started.
+func (runner *TimedRunner) Buffer() *gbytes.Buffer {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+
+	if runner.session == nil {
+		return nil
+	}
+
+	return runner.session.Buffer()
+}
+
+// Output is everything the component has written to either stream, each
+// line tagged with the id of the spec it ran in, e.g. "[n1-s12] ", or nil if
+// it has not been started.
+func (runner *TimedRunner) Output() *gbytes.Buffer {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+
+	return runner.output
+}
+
+// ExitCode waits for the component to be started, then returns its exit
+// code, or -1 if it is still running.
+func (runner *TimedRunner) ExitCode() int {
+	<-runner.started
+
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+
+	return runner.session.ExitCode()
+}
+
+func (maker ComponentMaker) timed(runner *ginkgomon.Runner) *TimedRunner {
+	return newTimedRunner(runner, maker.Timings, maker.LogFiles, maker.LiveLogs)
+}
+
+func newTimedRunner(runner *ginkgomon.Runner, timings *Timings, logFiles *LogFiles, liveLogs *LiveLogs) *TimedRunner {
+	return &TimedRunner{
+		Runner:   runner,
+		timings:  timings,
+		logFiles: logFiles,
+		liveLogs: liveLogs,
+
+		started: make(chan struct{}),
+		lock:    new(sync.Mutex),
+	}
+}
+
+type slowestFirst []ComponentStartupSummary
+
+func (s slowestFirst) Len() int           { return len(s) }
+func (s slowestFirst) Less(i, j int) bool { return s[i].Total > s[j].Total }
+func (s slowestFirst) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,106 @@
+package world
+
+import (
+	"sort"
+
+	. "github.com/onsi/gomega"
+)
+
+// Topology says which components each component needs to be up, by the
+// names they run under, e.g. "rep" needs "executor".
+type Topology map[string][]string
+
+var DefaultTopology = Topology{
+	"executor":       {"garden-linux"},
+	"rep":            {"executor", "etcd"},
+	"converger":      {"etcd"},
+	"auctioneer":     {"etcd"},
+	"receptor":       {"etcd"},
+	"route-emitter":  {"gnatsd", "etcd"},
+	"router":         {"gnatsd"},
+	"tps":            {"receptor"},
+	"tps-watcher":    {"receptor"},
+	"nsync-listener": {"receptor", "gnatsd"},
+	"nsync-bulker":   {"receptor"},
+	"stager":         {"receptor"},
+}
+
+// ShutdownWaves orders the named components for shutdown. The components in
+// a wave can be stopped together once every earlier wave has been, without
+// taking anything out from under a component that is still up. Components
+// the topology does not know about depend on nothing, and go first.
+//
+// The names are of single components; a group of them run as one process,
+// e.g. with grouper, is unknown to the topology whatever it holds, so give
+// each component a process of its own, as Bootstrap does.
+func (topology Topology) ShutdownWaves(names []string) [][]string {
+	remaining := map[string]bool{}
+	for _, name := range names {
+		remaining[name] = true
+	}
+
+	waves := [][]string{}
+	for len(remaining) > 0 {
+		wave := []string{}
+		for name := range remaining {
+			if !topology.neededByAnyOf(name, remaining) {
+				wave = append(wave, name)
+			}
+		}
+
+		if !Ω(wave).ShouldNot(BeEmpty(), "dependency cycle among %v", remaining) {
+			// only carried on past when the failure is intercepted
+			return waves
+		}
+
+		sort.Strings(wave)
+		for _, name := range wave {
+			delete(remaining, name)
+		}
+
+		waves = append(waves, wave)
+	}
+
+	return waves
+}
+
+// StartupWaves is ShutdownWaves the other way round: each wave can be
+// started together once every earlier wave is up.
+func (topology Topology) StartupWaves(names []string) [][]string {
+	shutdown := topology.ShutdownWaves(names)
+
+	waves := [][]string{}
+	for i := len(shutdown) - 1; i >= 0; i-- {
+		waves = append(waves, shutdown[i])
+	}
+
+	return waves
+}
+
+func (topology Topology) neededByAnyOf(name string, components map[string]bool) bool {
+	for other := range components {
+		if other != name && topology.needs(other, name, map[string]bool{}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// needs is whether component needs dependency, directly or through the
+// components it needs.
+func (topology Topology) needs(component string, dependency string, seen map[string]bool) bool {
+	if seen[component] {
+		return false
+	}
+
+	seen[component] = true
+
+	for _, needed := range topology[component] {
+		if needed == dependency || topology.needs(needed, dependency, seen) {
+			return true
+		}
+	}
+
+	return false
+}
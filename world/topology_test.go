@@ -0,0 +1,60 @@
+package world_test
+
+import (
+	"github.com/cloudfoundry-incubator/inigo/world"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Topology", func() {
+	Describe("ShutdownWaves", func() {
+		It("stops every component before what it needs", func() {
+			Ω(world.DefaultTopology.ShutdownWaves([]string{"etcd", "garden-linux", "executor", "rep", "receptor"})).Should(Equal([][]string{
+				{"receptor", "rep"},
+				{"etcd", "executor"},
+				{"garden-linux"},
+			}))
+		})
+
+		It("stops a component before what it needs through components that are not named", func() {
+			topology := world.Topology{
+				"a": {"b"},
+				"b": {"c"},
+			}
+
+			Ω(topology.ShutdownWaves([]string{"c", "a"})).Should(Equal([][]string{{"a"}, {"c"}}))
+		})
+
+		It("stops components the topology does not know about first, as nothing needs them", func() {
+			Ω(world.DefaultTopology.ShutdownWaves([]string{"etcd", "announcement-server", "converger"})).Should(Equal([][]string{
+				{"announcement-server", "converger"},
+				{"etcd"},
+			}))
+		})
+
+		It("fails on a dependency cycle", func() {
+			topology := world.Topology{
+				"a": {"b"},
+				"b": {"a"},
+				"c": {"a"},
+			}
+
+			failures := InterceptGomegaFailures(func() {
+				topology.ShutdownWaves([]string{"a", "b", "c"})
+			})
+
+			Ω(failures).Should(ConsistOf(ContainSubstring("dependency cycle")))
+		})
+	})
+
+	Describe("StartupWaves", func() {
+		It("is ShutdownWaves the other way round", func() {
+			Ω(world.DefaultTopology.StartupWaves([]string{"etcd", "garden-linux", "executor", "rep", "receptor"})).Should(Equal([][]string{
+				{"garden-linux"},
+				{"etcd", "executor"},
+				{"receptor", "rep"},
+			}))
+		})
+	})
+})
@@ -0,0 +1,15 @@
+package world_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// unlike the integration suites, these specs start no components, so need
+// nothing built
+func TestWorld(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "World Suite")
+}